@@ -19,6 +19,12 @@ var «$typeID»_type = reflect.TypeOf((*«$goName»)(nil)).Elem()
 
 type «$typeName» struct {
 	codecapi.NonStruct
+	«- if not encoders»
+		codecapi.NoEncoder
+	«- end»
+	«- if not decoders»
+		codecapi.NoDecoder
+	«- end»
 	«if .ElField -»
 		«$elTypeCodec» *«$elTypeCodec»
 	«end -»
@@ -47,36 +53,78 @@ func (c *«$typeName») SetCodecs(tcs []codecapi.TypeCodec) {
 	«end -»
 }
 
+«if encoders»
 func (c *«$typeName») Encode(e *codecapi.Encoder, x interface{}) {
 	a := x.(«$goName»)
 	c.encode(e, &a)
 }
 
 func (c *«$typeName») encode(e *codecapi.Encoder, s *«$goName») {
+	start := e.StatsStart()
 	«encodeStmt .SliceType "(*s)[:]"»
+	if start >= 0 {
+		e.StatsEnd(«$typeID»_type, start)
+	}
 }
+«end»
 
+«if decoders»
 func (c *«$typeName») Decode(d *codecapi.Decoder) interface{} {
 	var x «$goName»
 	c.decode(d, &x)
 	return x
 }
 
+func (c *«$typeName») DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*«$goName»)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z «$goName»
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *«$typeName») decode(d *codecapi.Decoder, p *«$goName») {
+	«if and (not .IsBytes) (numeric .Type.Elem) -»
+		_, elemConv := d.ElemConversions()
+	«end -»
 	«if .IsBytes -»
 		b := d.DecodeBytes()
-		copy((*p)[:], b)
+		n := len(b)
 	«else -»
 		n := d.StartList()
 		if n < 0 { return }
-	    if n != «.Type.Len» {
-			codecapi.Failf("array size mismatch: got %d, want «.Type.Len»", n)
+	«end -»
+	«/* The data may have been encoded from a slice, so allow fewer elements. */ -»
+	if n > «.Type.Len» {
+		codecapi.Failf("array size mismatch: got %d, want at most «.Type.Len»", n)
+	}
+	if n < «.Type.Len» && !d.Merging() {
+		*p = «$goName»{}
+	}
+	«if .IsBytes -»
+		copy((*p)[:], b)
+	«else -»
+		i := -1
+		if d.RecordingPath() {
+			defer func() {
+				if i >= 0 && i < n {
+					d.PathIndex(i)
+				}
+			}()
 		}
-		for i := 0; i < n; i++ {
+		for i = 0; i < n; i++ {
+			«- if numeric .Type.Elem»
+				d.Convert(elemConv)
+			«- end»
 			«decodeStmt .Type.Elem "(*p)[i]"»
 		}
 	«end -»
 }
+«end»
 
 func init() {
   codecapi.Register(«$typeID»_type, func() codecapi.TypeCodec { return &«$typeName»{} })
@@ -5,6 +5,7 @@
 package codec
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 )
@@ -26,3 +27,45 @@ func BenchmarkMapStore(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkDecodeNested(b *testing.B) {
+	// Decoding each node of a list calls the generated decode methods of node
+	// and *node, so this measures the per-value cost of decoding, like
+	// keeping track of the path for errors.
+	var list *node
+	for i := 0; i < 1000; i++ {
+		list = &node{Value: i, Next: list}
+	}
+	data, err := Marshal(list)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var got *node
+			if err := Unmarshal(data, &got); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("merge", func(b *testing.B) {
+		// Merging into the same list each time doesn't allocate, so the
+		// decoding itself dominates.
+		var got *node
+		if err := Unmarshal(data, &got); err != nil {
+			b.Fatal(err)
+		}
+		r := bytes.NewReader(data)
+		d := NewDecoder(r, &DecodeOptions{Merge: true})
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			r.Reset(data)
+			d.Reset(r)
+			if err := d.Decode(&got); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/jba/codec/codecapi"
+)
+
+// maxBytes is the number of bytes of a byte sequence that dump displays.
+const maxBytes = 40
+
+// dump prints each value in data, one line per encoded value, with the offset
+// of the value in the input.
+func dump(w io.Writer, data []byte) error {
+	frames, err := readFrames(data)
+	if len(data) >= headerSize {
+		fmt.Fprintf(w, "%8d: header %q\n", 0, data[:headerSize])
+	}
+	for _, f := range frames {
+		fmt.Fprintf(w, "%8d: frame %d, %d bytes\n", f.Offset-lengthSize, f.Index, len(f.Data))
+		md, v, err := codecapi.ParseFrame(f)
+		if err != nil {
+			return fmt.Errorf("frame %d at offset %d: %v", f.Index, f.Offset-lengthSize, err)
+		}
+		fmt.Fprintf(w, "%8d:   metadata, %d bytes, %d types\n", f.Offset, md.Len, len(md.TypeNames))
+		dumpValue(w, int(f.Offset), md, v, "", 1)
+	}
+	return err
+}
+
+// dumpValue prints v and the values it contains. The base is the offset of
+// v's frame in the input.
+func dumpValue(w io.Writer, base int, md *codecapi.Metadata, v *codecapi.RawValue, prefix string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(w, "%8d: %s%s%s\n", base+v.Offset, indent, prefix, describe(base, md, v))
+	if v.Scalar() != nil {
+		// A complex number's elements aren't worth showing.
+		return
+	}
+	switch v.Kind {
+	case codecapi.RawList:
+		for _, e := range v.Elems {
+			dumpValue(w, base, md, e, "", depth+1)
+		}
+	case codecapi.RawStruct:
+		for _, f := range v.Fields {
+			prefix := fmt.Sprintf("field %d: ", f.Num)
+			if f.Name != "" {
+				prefix = fmt.Sprintf("field %d %s: ", f.Num, f.Name)
+			}
+			dumpValue(w, base, md, f.Value, prefix, depth+1)
+		}
+	case codecapi.RawPtr, codecapi.RawAny:
+		if v.Elem != nil {
+			dumpValue(w, base, md, v.Elem, "", depth+1)
+		}
+	}
+}
+
+// describe returns a one-line description of v, without the values it
+// contains.
+func describe(base int, md *codecapi.Metadata, v *codecapi.RawValue) string {
+	var s string
+	switch x := v.Scalar().(type) {
+	case nil:
+	case string:
+		s = quote([]byte(x))
+	default:
+		s = fmt.Sprint(x)
+	}
+	if s == "" {
+		switch v.Kind {
+		case codecapi.RawUint, codecapi.RawByte:
+			s = fmt.Sprintf("%s %d", v.Kind, v.Uint)
+		case codecapi.RawBytes:
+			s = fmt.Sprintf("bytes(%d) %s", len(v.Bytes), quote(v.Bytes))
+		case codecapi.RawList:
+			s = fmt.Sprintf("list(%d)", len(v.Elems))
+		case codecapi.RawPtr:
+			s = "ptr"
+			if v.Shared {
+				s = "shared ptr"
+			}
+		case codecapi.RawRef:
+			s = fmt.Sprintf("ref to %d", base+v.Target)
+		case codecapi.RawAny:
+			if v.Elem == nil {
+				return "nil interface"
+			}
+			return fmt.Sprintf("interface, type %d %s", v.Uint, md.TypeNames[v.Uint])
+		default:
+			s = v.Kind.String()
+		}
+	}
+	if v.Type != "" {
+		s += " " + v.Type
+	} else if k := v.GoKind; k != reflect.Invalid && k != reflect.Ptr && k != reflect.Struct {
+		// The kind of a pointer or struct adds nothing.
+		s += " " + k.String()
+	}
+	return s
+}
+
+// typeOf returns the name of v's type, or its kind if that is all that is
+// known.
+func typeOf(v *codecapi.RawValue) string {
+	if v.Type != "" {
+		return v.Type
+	}
+	if v.GoKind != reflect.Invalid {
+		return v.GoKind.String()
+	}
+	return ""
+}
+
+// quote quotes b, truncating it if it is long.
+func quote(b []byte) string {
+	if len(b) <= maxBytes {
+		return fmt.Sprintf("%q", b)
+	}
+	return fmt.Sprintf("%q...", b[:maxBytes])
+}
+
+// meta prints the metadata of each frame in data.
+func meta(w io.Writer, data []byte) error {
+	frames, err := readFrames(data)
+	var prev []codecapi.Frame
+	for _, f := range frames {
+		md, _, err := codecapi.ParseFrame(f)
+		if err != nil {
+			return fmt.Errorf("frame %d at offset %d: %v", f.Index, f.Offset-lengthSize, err)
+		}
+		raw := f.Data[:md.Len]
+		if p := sameMetadata(prev, raw); p != nil {
+			fmt.Fprintf(w, "frame %d: same metadata as frame %d\n", f.Index, p.Index)
+			continue
+		}
+		prev = append(prev, codecapi.Frame{Data: raw, Index: f.Index})
+		fmt.Fprintf(w, "frame %d: metadata, %d bytes\n", f.Index, md.Len)
+		if md.Fingerprint != "" {
+			fmt.Fprintf(w, "  schema fingerprint %s\n", md.Fingerprint)
+		}
+		for num, name := range md.TypeNames {
+			fmt.Fprintf(w, "  type %d: %s", num, name)
+			if c, ok := md.CustomNames[num]; ok {
+				fmt.Fprintf(w, " (custom codec %q)", c)
+			}
+			fmt.Fprintln(w)
+			kinds := md.FieldKinds[num]
+			elemKinds := md.FieldElemKinds[num]
+			for i, field := range md.Fields[num] {
+				fmt.Fprintf(w, "    field %d: %s", i, field)
+				if i < len(kinds) {
+					fmt.Fprintf(w, " %s", kinds[i])
+				}
+				if i < len(elemKinds) && len(elemKinds[i]) > 0 {
+					fmt.Fprintf(w, " of %v", elemKinds[i])
+				}
+				fmt.Fprintln(w)
+			}
+		}
+	}
+	return err
+}
+
+// sameMetadata returns the frame of frames whose data is md, or nil if there
+// is none.
+func sameMetadata(frames []codecapi.Frame, md []byte) *codecapi.Frame {
+	for i := range frames {
+		if bytes.Equal(frames[i].Data, md) {
+			return &frames[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package testtypes holds types for testing the codec command.
+package testtypes
+
+//go:generate go run github.com/jba/codec/cmd/codecgen -type Shape
+
+type Point struct {
+	X, Y int
+}
+
+type Shape struct {
+	Name    string
+	Points  []Point
+	Center  *Point
+	Origin  *Point
+	Visible bool
+	Tag     interface{}
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Codec inspects data encoded by the github.com/jba/codec package. It doesn't
+// need the types the data was encoded from.
+//
+// Usage:
+//
+//	codec <command> [file ...]
+//
+// The commands are:
+//
+//	dump      print an annotated listing of the encoded data, with offsets
+//	meta      print the metadata of each value: its types and struct fields
+//	stats     print a breakdown of the data's size, by section, type and field
+//	validate  check that the data is well formed
+//	json      convert the encoded data to JSON
+//	fromjson  convert JSON written by the json command back to encoded data
+//
+// With no files, or a file named "-", codec reads the standard input.
+//
+// To edit an encoded value by hand, convert it to JSON, edit the JSON, and
+// convert it back:
+//
+//	codec json value.gjc > value.json
+//	... edit value.json ...
+//	codec fromjson value.json > value.gjc
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/jba/codec"
+	"github.com/jba/codec/codecapi"
+)
+
+var commands = map[string]func(w io.Writer, data []byte) error{
+	"dump":     dump,
+	"meta":     meta,
+	"stats":    stats,
+	"validate": validate,
+	"json":     toJSON,
+	"fromjson": fromJSON,
+}
+
+func usage() {
+	fmt.Fprintln(flag.CommandLine.Output(), `usage: codec <command> [file ...]
+
+Commands:
+  dump      print an annotated listing of the encoded data, with offsets
+  meta      print the metadata of each value: its types and struct fields
+  stats     print a breakdown of the data's size, by section, type and field
+  validate  check that the data is well formed
+  json      convert the encoded data to JSON
+  fromjson  convert JSON written by the json command back to encoded data`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("codec: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		log.Printf("unknown command %q", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	files := flag.Args()[1:]
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	failed := false
+	for _, file := range files {
+		var (
+			data []byte
+			err  error
+		)
+		if file == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(files) > 1 {
+			fmt.Printf("%s:\n", file)
+		}
+		if err := cmd(os.Stdout, data); err != nil {
+			log.Printf("%s: %v", file, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+const (
+	headerSize = 4 // the stream header, which identifies the format
+	lengthSize = 8 // each frame's length, as a uint64
+)
+
+func toJSON(w io.Writer, data []byte) error {
+	return codec.ToJSON(w, bytes.NewReader(data))
+}
+
+func fromJSON(w io.Writer, data []byte) error {
+	return codec.FromJSON(w, bytes.NewReader(data))
+}
+
+// readFrames reads the frames of data. If it fails, it returns the frames it
+// read before the failure along with the error.
+func readFrames(data []byte) ([]codecapi.Frame, error) {
+	if len(data) < headerSize {
+		return nil, errors.New("missing header")
+	}
+	d := codecapi.NewDecoder(bytes.NewReader(data), codecapi.DecodeOptions{})
+	var frames []codecapi.Frame
+	for {
+		// Give each frame its own buffer, since the frames are kept.
+		f, err := d.ReadFrame(nil)
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			offset := int64(headerSize)
+			if n := len(frames); n > 0 {
+				offset = frames[n-1].Offset + int64(len(frames[n-1].Data))
+			}
+			return frames, fmt.Errorf("frame %d at offset %d: truncated", len(frames), offset)
+		}
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, f)
+	}
+}
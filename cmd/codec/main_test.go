@@ -0,0 +1,171 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jba/codec"
+	"github.com/jba/codec/cmd/codec/internal/testtypes"
+)
+
+// encode encodes values with pointer tracking.
+func encode(t *testing.T, values ...interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	e := codec.NewEncoder(&buf, &codec.EncodeOptions{TrackPointers: true})
+	for _, v := range values {
+		if err := e.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+const shapeName = "github.com/jba/codec/cmd/codec/internal/testtypes.Shape"
+
+func testData(t *testing.T) []byte {
+	center := &testtypes.Point{X: 1, Y: 2}
+	return encode(t,
+		testtypes.Shape{
+			Name:    "tri",
+			Points:  []testtypes.Point{{}, {X: 3}, {Y: 4}},
+			Center:  center,
+			Origin:  center,
+			Visible: true,
+			Tag:     "x",
+		},
+		testtypes.Shape{Name: "empty", Tag: "y"},
+		"done")
+}
+
+func run(t *testing.T, cmd string, data []byte) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	err := commands[cmd](&buf, data)
+	return buf.String(), err
+}
+
+func TestDump(t *testing.T) {
+	got, err := run(t, "dump", testData(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`header "GJC2"`,
+		"frame 0,",
+		"interface, type 0 " + shapeName,
+		`field 0 Name: "tri" string`,
+		"field 1 Points: list(3) slice",
+		"field 1: uint 8",
+		"field 2 Center: shared ptr",
+		"field 3 Origin: ref to",
+		"field 4 Visible: true bool",
+		`field 5 Tag: interface, type 4 string`,
+		`"done" string`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestMeta(t *testing.T) {
+	got, err := run(t, "meta", testData(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type 0: " + shapeName,
+		"field 5: Tag interface",
+		"field 1: Y int",
+		"frame 1: same metadata as frame 0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	data := testData(t)
+	got, err := run(t, "stats", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("total  %d", len(data)),
+		"frames    3",
+		"?.1 ",
+		shapeName + ".Points ",
+		shapeName + " ",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	data := testData(t)
+	got, err := run(t, "validate", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ok: 3 frames\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, test := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "missing header"},
+		{"header", []byte("GJC9"), "bad header"},
+		{"truncated", data[:len(data)-1], "frame 2 at offset"},
+		{"bool", replaceLast(data, "\x04\x01\x05", "\x04\x02\x05"), "bad bool value 2"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := run(t, "validate", test.data)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+// replaceLast replaces the last occurrence of old in data with new, which
+// must have the same length.
+func replaceLast(data []byte, old, new string) []byte {
+	i := bytes.LastIndex(data, []byte(old))
+	if i < 0 {
+		panic("not found")
+	}
+	d := append([]byte(nil), data...)
+	copy(d[i:], new)
+	return d
+}
+
+func TestJSON(t *testing.T) {
+	data := testData(t)
+	js, err := run(t, "json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(js, `"Name": "tri"`) {
+		t.Errorf("JSON does not contain the name:\n%s", js)
+	}
+	got, err := run(t, "fromjson", []byte(js))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(data) {
+		t.Error("json and fromjson did not reproduce the data")
+	}
+}
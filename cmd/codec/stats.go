@@ -0,0 +1,127 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/jba/codec/codecapi"
+)
+
+// A sizeCount records the total size of some values, and how many there are.
+type sizeCount struct {
+	bytes, count int
+}
+
+// sizes accumulates the sizes of values by type and by struct field.
+type sizes struct {
+	byType  map[string]*sizeCount
+	byField map[string]*sizeCount
+}
+
+func (s *sizes) add(m map[string]*sizeCount, key string, n int) {
+	sc := m[key]
+	if sc == nil {
+		sc = &sizeCount{}
+		m[key] = sc
+	}
+	sc.bytes += n
+	sc.count++
+}
+
+// walk adds the sizes of v and the values it contains.
+func (s *sizes) walk(v *codecapi.RawValue) {
+	if t := typeOf(v); t != "" {
+		s.add(s.byType, t, v.End-v.Offset)
+	}
+	for _, e := range v.Elems {
+		s.walk(e)
+	}
+	owner := v.Type
+	if owner == "" {
+		owner = "?"
+	}
+	for _, f := range v.Fields {
+		name := f.Name
+		if name == "" {
+			name = fmt.Sprint(f.Num)
+		}
+		s.add(s.byField, owner+"."+name, f.Value.End-f.Offset)
+		s.walk(f.Value)
+	}
+	if v.Elem != nil {
+		s.walk(v.Elem)
+	}
+}
+
+// stats prints how the bytes of data are divided among the parts of each
+// frame, and among types and struct fields.
+func stats(w io.Writer, data []byte) error {
+	frames, err := readFrames(data)
+	if err != nil {
+		return err
+	}
+	s := &sizes{byType: map[string]*sizeCount{}, byField: map[string]*sizeCount{}}
+	var metadata, values int
+	for _, f := range frames {
+		md, v, err := codecapi.ParseFrame(f)
+		if err != nil {
+			return fmt.Errorf("frame %d at offset %d: %v", f.Index, f.Offset-lengthSize, err)
+		}
+		metadata += md.Len
+		values += len(f.Data) - md.Len
+		s.walk(v)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	percent := func(n int) string {
+		return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(len(data)))
+	}
+	fmt.Fprintf(tw, "frames\t%d\t\t\n", len(frames))
+	fmt.Fprintf(tw, "total\t%d\t\t\n", len(data))
+	fmt.Fprintf(tw, "header\t%d\t%s\t\n", headerSize, percent(headerSize))
+	fmt.Fprintf(tw, "lengths\t%d\t%s\t\n", lengthSize*len(frames), percent(lengthSize*len(frames)))
+	fmt.Fprintf(tw, "metadata\t%d\t%s\t\n", metadata, percent(metadata))
+	fmt.Fprintf(tw, "values\t%d\t%s\t\n", values, percent(values))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(s.byType) > 0 {
+		fmt.Fprintln(w, "\nby type, including contained values:")
+		if err := printSizes(w, s.byType); err != nil {
+			return err
+		}
+	}
+	if len(s.byField) > 0 {
+		fmt.Fprintln(w, "\nby field, including field numbers (? is a struct of unknown type):")
+		if err := printSizes(w, s.byField); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printSizes prints m from largest to smallest.
+func printSizes(w io.Writer, m map[string]*sizeCount) error {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]].bytes != m[keys[j]].bytes {
+			return m[keys[i]].bytes > m[keys[j]].bytes
+		}
+		return keys[i] < keys[j]
+	})
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "  \tbytes\tcount")
+	for _, k := range keys {
+		fmt.Fprintf(tw, "  %s\t%d\t%d\n", k, m[k].bytes, m[k].count)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/jba/codec/codecapi"
+)
+
+// validate checks that data is well formed. Beyond what parsing checks, it
+// checks that references refer to earlier shared pointers, that no struct has
+// a field twice, and that booleans are 0 or 1.
+func validate(w io.Writer, data []byte) error {
+	frames, err := readFrames(data)
+	if err != nil {
+		return err
+	}
+	for _, f := range frames {
+		_, v, err := codecapi.ParseFrame(f)
+		if err != nil {
+			return fmt.Errorf("frame %d at offset %d: %v", f.Index, f.Offset-lengthSize, err)
+		}
+		if err := check(int(f.Offset), v, map[int]bool{}); err != nil {
+			return fmt.Errorf("frame %d: %v", f.Index, err)
+		}
+	}
+	fmt.Fprintf(w, "ok: %d frames\n", len(frames))
+	return nil
+}
+
+// check checks v and the values it contains. The base is the offset of v's
+// frame in the input, and the shared map holds the offsets in the frame of the
+// shared pointers that precede v.
+func check(base int, v *codecapi.RawValue, shared map[int]bool) error {
+	switch v.Kind {
+	case codecapi.RawUint:
+		if v.GoKind == reflect.Bool && v.Uint > 1 {
+			return fmt.Errorf("offset %d: bad bool value %d", base+v.Offset, v.Uint)
+		}
+	case codecapi.RawPtr:
+		if v.Shared {
+			shared[v.Offset] = true
+		}
+	case codecapi.RawRef:
+		if !shared[v.Target] {
+			return fmt.Errorf("offset %d: reference to %d, which is not a shared pointer", base+v.Offset, base+v.Target)
+		}
+	case codecapi.RawStruct:
+		seen := map[int]bool{}
+		for _, f := range v.Fields {
+			if seen[f.Num] {
+				return fmt.Errorf("offset %d: field %d appears twice", base+f.Offset, f.Num)
+			}
+			seen[f.Num] = true
+		}
+	}
+	for _, e := range v.Elems {
+		if err := check(base, e, shared); err != nil {
+			return err
+		}
+	}
+	for _, f := range v.Fields {
+		if err := check(base, f.Value, shared); err != nil {
+			return err
+		}
+	}
+	if v.Elem != nil {
+		return check(base, v.Elem, shared)
+	}
+	return nil
+}
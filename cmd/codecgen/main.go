@@ -0,0 +1,96 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Codecgen generates encoders and decoders for the github.com/jba/codec
+// package. It loads a package from source, so it works even when the package
+// doesn't compile because the generated code is missing or out of date.
+//
+// Usage:
+//
+//	codecgen -type T1,T2 [flags] [dir]
+//
+// It generates code for the named types of the package in dir, by default the
+// current directory, and the types they depend on. It is meant to be run by go
+// generate, from a line like this in one of the package's files:
+//
+//	//go:generate go run github.com/jba/codec/cmd/codecgen -type T1,T2
+//
+// The generated code is the same as that written by codec.GenerateFile.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jba/codec"
+)
+
+var (
+	typeNames  = flag.String("type", "", "comma-separated list of type names; required")
+	output     = flag.String("o", "types.gen.go", "output file; a relative path is relative to the package directory")
+	fieldTag   = flag.String("tag", "codec", "name of the struct field tag to use")
+	encodeOnly = flag.Bool("encodeonly", false, "generate only encoders")
+	decodeOnly = flag.Bool("decodeonly", false, "generate only decoders")
+)
+
+func usage() {
+	fmt.Fprintln(flag.CommandLine.Output(), "usage: codecgen -type T1,T2 [flags] [dir]")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("codecgen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	filename := *output
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+	opts := &codec.GenerateOptions{
+		FieldTag:   *fieldTag,
+		EncodeOnly: *encodeOnly,
+		DecodeOnly: *decodeOnly,
+	}
+	if err := generate(filename, dir, opts, strings.Split(*typeNames, ",")); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate writes code for the named types of the package in dir to filename.
+func generate(filename, dir string, opts *codec.GenerateOptions, typeNames []string) error {
+	pkgPath, err := packagePath(dir)
+	if err != nil {
+		return err
+	}
+	return codec.GenerateFileFromSource(filename, dir, pkgPath, opts, typeNames...)
+}
+
+// packagePath returns the import path of the package in dir.
+func packagePath(dir string) (string, error) {
+	// The -e flag reports the path even if the package has errors.
+	cmd := exec.Command("go", "list", "-e", "-f", "{{.ImportPath}}")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list: %v: %s", err, stderr.Bytes())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
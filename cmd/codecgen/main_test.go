@@ -0,0 +1,55 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jba/codec"
+)
+
+const testtypesDir = "../codec/internal/testtypes"
+
+func TestGenerate(t *testing.T) {
+	// The testtypes package's code is generated by this command, so
+	// generating it again should give the same result.
+	filename := filepath.Join(t.TempDir(), "types.gen.go")
+	if err := generate(filename, testtypesDir, &codec.GenerateOptions{}, []string{"Shape"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile(filepath.Join(testtypesDir, "types.gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	got, err := packagePath(testtypesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "github.com/jba/codec/cmd/codec/internal/testtypes"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "types.gen.go")
+	err := generate(filename, testtypesDir, nil, []string{"Circle"})
+	if want := "no type named Circle"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v, want error containing %q", err, want)
+	}
+}
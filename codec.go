@@ -6,6 +6,7 @@ package codec
 
 import (
 	"io"
+	"sync"
 
 	api "github.com/jba/codec/codecapi"
 )
@@ -30,6 +31,31 @@ type EncodeOptions struct {
 	// encoding is large, providing a buffer of sufficient size can speed up
 	// encoding by reducing allocation.
 	Buffer []byte
+
+	// Parallelism is the maximum number of goroutines that Encode uses to
+	// encode a large top-level slice or map, such as an export of many
+	// records. Its elements are encoded in parts, in parallel, and the parts
+	// are joined into a single encoded value. If Parallelism is zero or one,
+	// or TrackPointers is true, Encode uses only the calling goroutine.
+	Parallelism int
+
+	// If SchemaFingerprint is true, Encode records with each value the
+	// fingerprint of the schema of the types it encoded, as computed by
+	// codecapi.RegisteredSchema and Schema.Fingerprint. A reader can retrieve
+	// it with Decoder.SchemaFingerprint. Older decoders ignore it.
+	SchemaFingerprint bool
+
+	// If Stats is non-nil, each call to Encode adds to it: the number of values
+	// and bytes of each type, the bytes of metadata and of data, and the number
+	// of references to shared pointers. Encoders that share Stats, including
+	// those of an EncoderPool, may be used concurrently, but read Stats only
+	// when none of them is encoding. Code generated before Stats was added
+	// doesn't collect per-type statistics until it is regenerated.
+	Stats *api.EncodeStats
+
+	// Registry holds the types that Encode can encode. If it is nil, Encode
+	// uses codecapi.DefaultRegistry, where generated code registers its types.
+	Registry *api.Registry
 }
 
 // NewEncoder returns an Encoder that writes to w.
@@ -38,6 +64,10 @@ func NewEncoder(w io.Writer, opts *EncodeOptions) *Encoder {
 	if opts != nil {
 		aopts.TrackPointers = opts.TrackPointers
 		aopts.Buffer = opts.Buffer
+		aopts.Parallelism = opts.Parallelism
+		aopts.SchemaFingerprint = opts.SchemaFingerprint
+		aopts.Stats = opts.Stats
+		aopts.Registry = opts.Registry
 	}
 	return &Encoder{state: api.NewEncoder(w, aopts)}
 }
@@ -47,6 +77,98 @@ func (e *Encoder) Encode(x interface{}) (err error) {
 	return e.state.Encode(x)
 }
 
+// Reset makes e write a new stream to w, as if it were newly created by
+// NewEncoder with the same options. Unlike a new Encoder, e keeps the buffers
+// and other state it built up while encoding, so reusing an Encoder is faster
+// than creating one.
+func (e *Encoder) Reset(w io.Writer) {
+	e.state.Reset(w)
+}
+
+// maxPooledBufferCap is the largest total buffer capacity of an Encoder or
+// Decoder that a pool will retain. Encoding or decoding one huge value should
+// not cause the pool to hold on to a lot of memory.
+const maxPooledBufferCap = 1 << 20
+
+// An EncoderPool holds Encoders with the same options for reuse.
+// It is safe for concurrent use.
+//
+// An EncoderPool can reduce the cost of encoding many small values to
+// different writers, for instance one per RPC:
+//
+//	e := pool.Get(w)
+//	defer pool.Put(e)
+//	err := e.Encode(x)
+type EncoderPool struct {
+	opts EncodeOptions
+	pool sync.Pool
+}
+
+// NewEncoderPool returns an EncoderPool whose Encoders use the given options.
+// The Buffer option is ignored, since each Encoder needs its own buffer.
+func NewEncoderPool(opts *EncodeOptions) *EncoderPool {
+	p := &EncoderPool{}
+	if opts != nil {
+		p.opts = *opts
+		p.opts.Buffer = nil
+	}
+	return p
+}
+
+// Get returns an Encoder from the pool, or a new one if the pool is empty.
+// The Encoder writes a new stream to w.
+func (p *EncoderPool) Get(w io.Writer) *Encoder {
+	if e, ok := p.pool.Get().(*Encoder); ok {
+		e.Reset(w)
+		return e
+	}
+	return NewEncoder(w, &p.opts)
+}
+
+// Put returns e to the pool. The Encoder must have come from p, and must not
+// be used after Put is called.
+func (p *EncoderPool) Put(e *Encoder) {
+	if e.state.BufferCap() > maxPooledBufferCap {
+		return
+	}
+	e.Reset(nil) // don't retain the writer
+	p.pool.Put(e)
+}
+
+// appendEncoders holds Encoders for AppendEncode. Reusing them
+// avoids allocating their buffers on each call.
+var appendEncoders = NewEncoderPool(nil)
+
+// Marshal returns the encoding of x. The result can be decoded with Unmarshal,
+// or with a Decoder, since it is a stream holding a single value.
+//
+// To set encoding options, use an Encoder.
+func Marshal(x interface{}) ([]byte, error) {
+	return AppendEncode(nil, x)
+}
+
+// AppendEncode appends the encoding of x to dst and returns the extended
+// slice. What it appends is the same as what Marshal returns: the stream
+// header, followed by the size and encoding of x.
+//
+// AppendEncode reuses its internal buffers across calls, so repeated calls
+// don't allocate them. It encodes x into those buffers and then copies the
+// result to dst, since the metadata that precedes the value in the encoding is
+// known only after the value is encoded.
+func AppendEncode(dst []byte, x interface{}) ([]byte, error) {
+	e := appendEncoders.Get(nil)
+	defer appendEncoders.Put(e)
+	return e.state.AppendEncode(dst, x)
+}
+
+// Unmarshal decodes data, which must hold the encoding of a single value as
+// produced by Marshal or AppendEncode, and stores the result in the value
+// pointed to by p, as Decoder.Decode does. The decoded value does not refer to
+// data.
+func Unmarshal(data []byte, p interface{}) error {
+	return api.Unmarshal(data, p, api.DecodeOptions{})
+}
+
 // A Decoder decodes a Go value encoded by an Encoder.
 // To use a Decoder:
 // - Pass NewDecoder the return value of Encoder.Bytes.
@@ -60,21 +182,133 @@ type DecodeOptions struct {
 	// DisallowUnknownFields configures whether unknown struct fields are skipped
 	// (the default) or cause decoding to fail immediately.
 	DisallowUnknownFields bool
+
+	// If Merge is true, Decode decodes into the existing value pointed to by
+	// its argument, like json.Unmarshal does with a non-nil value. Struct fields
+	// that are not in the encoded data keep their values, and maps are added
+	// to instead of replaced. Existing pointers are followed rather than
+	// replaced. Defaults, from Defaulter or the "default" tag option, are not
+	// applied to existing values, but values that decoding creates, like the
+	// target of a nil pointer, a new map entry or a slice element, get them.
+	// If the encoded value's type is different from the argument's, Decode
+	// replaces the value as usual.
+	Merge bool
+
+	// If AppendSlices is true and Merge is true, decoded slices are appended
+	// to existing ones instead of replacing them. Byte slices are always
+	// replaced.
+	AppendSlices bool
+
+	// Registry holds the types that Decode can decode. If it is nil, Decode
+	// uses codecapi.DefaultRegistry, where generated code registers its types.
+	Registry *api.Registry
 }
 
 // NewDecoder creates a Decoder that reads from r.
 func NewDecoder(r io.Reader, opts *DecodeOptions) *Decoder {
+	return &Decoder{state: api.NewDecoder(r, apiDecodeOptions(opts))}
+}
+
+func apiDecodeOptions(opts *DecodeOptions) api.DecodeOptions {
 	aopts := api.DecodeOptions{}
 	if opts != nil {
 		aopts.DisallowUnknownFields = opts.DisallowUnknownFields
+		aopts.Merge = opts.Merge
+		aopts.AppendSlices = opts.AppendSlices
+		aopts.Registry = opts.Registry
+	}
+	return aopts
+}
+
+// SchemaFingerprint returns the schema fingerprint recorded with the last value
+// that d decoded, or "" if none was recorded. See
+// EncodeOptions.SchemaFingerprint.
+func (d *Decoder) SchemaFingerprint() string {
+	return d.state.SchemaFingerprint()
+}
+
+// Reset makes d read a new stream from r, as if it were newly created by
+// NewDecoder with the same options. Unlike a new Decoder, d keeps the buffers
+// and other state it built up while decoding, so reusing a Decoder is faster
+// than creating one.
+func (d *Decoder) Reset(r io.Reader) {
+	d.state.Reset(r)
+}
+
+// A DecoderPool holds Decoders with the same options for reuse.
+// It is safe for concurrent use.
+type DecoderPool struct {
+	opts DecodeOptions
+	pool sync.Pool
+}
+
+// NewDecoderPool returns a DecoderPool whose Decoders use the given options.
+func NewDecoderPool(opts *DecodeOptions) *DecoderPool {
+	p := &DecoderPool{}
+	if opts != nil {
+		p.opts = *opts
+	}
+	return p
+}
+
+// Get returns a Decoder from the pool, or a new one if the pool is empty.
+// The Decoder reads a new stream from r.
+func (p *DecoderPool) Get(r io.Reader) *Decoder {
+	if d, ok := p.pool.Get().(*Decoder); ok {
+		d.Reset(r)
+		return d
+	}
+	return NewDecoder(r, &p.opts)
+}
+
+// Put returns d to the pool. The Decoder must have come from p, and must not
+// be used after Put is called.
+func (p *DecoderPool) Put(d *Decoder) {
+	if d.state.BufferCap() > maxPooledBufferCap {
+		return
 	}
-	return &Decoder{state: api.NewDecoder(r, aopts)}
+	d.Reset(nil) // don't retain the reader
+	p.pool.Put(d)
+}
+
+// A Defaulter supplies default values for a struct's fields.
+//
+// If a pointer to a struct implements Defaulter, the generated decoder for the
+// struct calls Default before decoding the struct's fields. Fields that are
+// absent from the encoded data keep the values that Default sets. Since zero
+// values are not encoded, a field that Default sets should have the "always"
+// tag option, so that a zero value overrides the default.
+type Defaulter interface {
+	Default()
+}
+
+// A Marshaler is a type that encodes itself directly, using the methods of a
+// codecapi.Encoder, without the intermediate byte slice of
+// encoding.BinaryMarshaler or encoding.TextMarshaler.
+//
+// MarshalCodec must encode exactly one value. To encode several, precede them
+// with a call to StartList. The generated code prefers Marshaler to the
+// encoding package's marshaler interfaces. A type that implements Marshaler
+// must also implement Unmarshaler with a pointer receiver.
+type Marshaler interface {
+	MarshalCodec(*api.Encoder) error
+}
+
+// An Unmarshaler is a type that decodes itself directly, using the methods of
+// a codecapi.Decoder. UnmarshalCodec must decode exactly the values written by
+// the corresponding MarshalCodec method.
+type Unmarshaler interface {
+	UnmarshalCodec(*api.Decoder) error
 }
 
 // Decode decodes a value encoded with Encoder.Encode
 // and stores the result in the value pointed to by p.
 // The decoded value must be assignable to the pointee's
-// type; no conversions are performed.
+// type, or convertible to it as described in the package
+// documentation under "Changing Field Types".
+// If the pointee's type is the type of the encoded value,
+// Decode decodes directly into it, which is faster than
+// decoding into an interface{} or a different type.
 // Decode returns io.EOF if there are no more values.
 func (d *Decoder) Decode(p interface{}) error {
 	return d.state.Decode(p)
@@ -6,20 +6,27 @@ package codec
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"go/token"
+	"io"
 	"log"
 	"math"
 	"net"
 	"os"
 	"reflect"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jba/codec/codecapi"
 	foo "github.com/jba/codec/internal/testpkg"
 )
 
@@ -55,6 +62,27 @@ type generatedTestTypes struct {
 	PtrMap      *map[int]int
 	PtrTime     *time.Time
 	SlicePtrInt []*int
+	Promoted    promoted
+	Patch       patch
+	ReqdA       reqdA
+	ReqdB       reqdB
+	ReqdC       reqdC
+	ReqdEmbA    reqdEmbA
+	ReqdEmbB    reqdEmbB
+	RenamedA    renamedA
+	RenamedB    renamedB
+	RenamedC    renamedC
+	Moved       []moved
+	ConvOld     convOld
+	ConvNew     convNew
+	Merge       mergeConfig
+	MergeDflt   mergeDfltNew
+	MergeOld    mergeDfltOld
+	Parallel    []parallelItem
+	Panicky     panicky
+	Reading     reading
+	Invoice     invoice
+	Library     library
 }
 
 // for testing sharing and cycles
@@ -74,10 +102,45 @@ type embed struct {
 	E int
 }
 
+// for testing promotion of embedded fields
+type promoted struct {
+	A int
+	embed
+	*ptrEmbed
+	In inlined `codec:",inline"`
+}
+
+type ptrEmbed struct {
+	P string
+}
+
+type inlined struct {
+	Q bool
+}
+
+// for testing the always option and Presence
+type patch struct {
+	A       int `codec:",always"`
+	B       int
+	C       string `codec:"Cee"`
+	Present Presence
+}
+
 func TestMain(m *testing.M) {
 	flag.Parse()
 	if *generateTestCodeFilename != "" {
-		if err := GenerateFile(*generateTestCodeFilename, "github.com/jba/codec", nil, generatedTestTypes{}); err != nil {
+		opts := &GenerateOptions{
+			PreviousNames: map[reflect.Type][]string{
+				reflect.TypeOf(moved{}): {movedFrom},
+			},
+			Custom: map[reflect.Type]CustomCodec{
+				reflect.TypeOf(money{}): {Encode: encodeMoney, Decode: decodeMoney, Name: "money/v1"},
+			},
+			Proxies: map[reflect.Type]Proxy{
+				reflect.TypeOf(rgb{}): {To: rgbToHex, From: rgbFromHex},
+			},
+		}
+		if err := GenerateFile(*generateTestCodeFilename, "github.com/jba/codec", opts, generatedTestTypes{}); err != nil {
 			log.Fatal(err)
 		}
 		fmt.Println("generated file, now run tests again")
@@ -134,6 +197,8 @@ func testEncodeDecode(t *testing.T, opts EncodeOptions) {
 		&[]int{7, 8},
 		&[1]int{9},
 		&map[int]int{10: 11},
+		promoted{A: 1, embed: embed{E: 2}, ptrEmbed: &ptrEmbed{P: "p"}, In: inlined{Q: true}},
+		promoted{A: 3},
 	}
 	var buf bytes.Buffer
 	e := NewEncoder(&buf, &opts)
@@ -149,7 +214,7 @@ func testEncodeDecode(t *testing.T, opts EncodeOptions) {
 		if err := d.Decode(&g); err != nil {
 			t.Fatalf("%#v: %v", w, err)
 		}
-		if !cmp.Equal(g, w, cmpopts.EquateNaNs(), cmp.AllowUnexported(structType{})) {
+		if !cmp.Equal(g, w, cmpopts.EquateNaNs(), cmp.AllowUnexported(structType{}, promoted{})) {
 			t.Errorf("got %v, want %v", g, w)
 		}
 	}
@@ -193,6 +258,1203 @@ func roundTripSharing(t *testing.T, in, out interface{}) {
 	}
 }
 
+// for testing required fields and defaults
+type reqdA struct {
+	R int       `codec:",required"`
+	D int       `codec:",default=7"`
+	S string    `codec:",default=hi"`
+	F float32   `codec:",default=1.5"`
+	L []string  `codec:",always"`
+	P *ptrEmbed `codec:",always"`
+}
+
+func (r *reqdA) Default() {
+	r.L = []string{"a"}
+	r.P = &ptrEmbed{P: "p"}
+}
+
+// reqdB and reqdC are older versions of reqdA.
+// See TestRequiredAndDefaults.
+type reqdB struct{}
+
+type reqdC struct {
+	R int
+}
+
+func TestRequiredAndDefaults(t *testing.T) {
+	// encode encodes x, then changes its type name to reqdA.
+	encode := func(x interface{}) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf, nil).Encode(x); err != nil {
+			t.Fatal(err)
+		}
+		old := []byte(reflect.TypeOf(x).Name())
+		return bytes.Replace(buf.Bytes(), old, []byte("reqdA"), 1)
+	}
+
+	// Zero values override defaults.
+	in := reqdA{L: []string{}, P: &ptrEmbed{}}
+	var got reqdA
+	if err := NewDecoder(bytes.NewReader(encode(in)), nil).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, in) {
+		t.Errorf("got %+v, want %+v", got, in)
+	}
+
+	// Missing required field.
+	err := NewDecoder(bytes.NewReader(encode(reqdB{})), nil).Decode(&got)
+	checkMessage(t, err, "reqdA: missing required field R")
+
+	// Missing fields get defaults.
+	got = reqdA{}
+	if err := NewDecoder(bytes.NewReader(encode(reqdC{R: 1})), nil).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := reqdA{R: 1, D: 7, S: "hi", F: 1.5, L: []string{"a"}, P: &ptrEmbed{P: "p"}}
+	if !cmp.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// for testing a required field promoted through an embedded pointer
+type reqdEmbA struct {
+	A int
+	*reqdInA
+}
+
+type reqdInA struct {
+	R int `codec:",required"`
+	S string
+}
+
+// reqdEmbB is an older version of reqdEmbA, without the required field.
+type reqdEmbB struct {
+	A int
+	*reqdInB
+}
+
+type reqdInB struct {
+	S string
+}
+
+func TestRequiredEmbeddedPtr(t *testing.T) {
+	// A nil embedded pointer isn't encoded, so its required field isn't
+	// missing.
+	for _, in := range []reqdEmbA{{A: 1}, {A: 1, reqdInA: &reqdInA{}}} {
+		data, err := Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got reqdEmbA
+		if err := Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(got, in, cmp.AllowUnexported(reqdEmbA{})) {
+			t.Errorf("got %+v, want %+v", got, in)
+		}
+	}
+
+	// It is missing if another field promoted through the pointer is present.
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, nil).Encode(reqdEmbB{reqdInB: &reqdInB{S: "s"}}); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Replace(buf.Bytes(), []byte("reqdEmbB"), []byte("reqdEmbA"), 1)
+	var got reqdEmbA
+	err := Unmarshal(data, &got)
+	checkMessage(t, err, "reqdEmbA: missing required field R")
+}
+
+// for testing field aliases
+type renamedA struct {
+	New int `codec:",alias=Old,alias=Older"`
+	X   int
+}
+
+// renamedB and renamedC are older versions of renamedA.
+// See TestAliases.
+type renamedB struct {
+	X, Old int
+}
+
+type renamedC struct {
+	Older, X int
+}
+
+func TestAliases(t *testing.T) {
+	for _, x := range []interface{}{renamedB{X: 1, Old: 2}, renamedC{X: 1, Older: 2}} {
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf, nil).Encode(x); err != nil {
+			t.Fatal(err)
+		}
+		old := []byte(reflect.TypeOf(x).Name())
+		data := bytes.Replace(buf.Bytes(), old, []byte("renamedA"), 1)
+		var got renamedA
+		if err := NewDecoder(bytes.NewReader(data), nil).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := renamedA{New: 2, X: 1}
+		if got != want {
+			t.Errorf("%T: got %+v, want %+v", x, got, want)
+		}
+	}
+}
+
+// for testing PreviousNames
+type moved struct {
+	A int
+}
+
+// movedFrom is the former name of moved. It has the same length as the current
+// name, so TestPreviousNames can substitute one for the other in encoded data.
+const movedFrom = "example.com/old/path.Moved"
+
+func TestPreviousNames(t *testing.T) {
+	for _, in := range []interface{}{moved{A: 1}, []moved{{A: 2}}} {
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf, nil).Encode(in); err != nil {
+			t.Fatal(err)
+		}
+		data := bytes.ReplaceAll(buf.Bytes(), []byte("github.com/jba/codec.moved"), []byte(movedFrom))
+		if bytes.Equal(data, buf.Bytes()) {
+			t.Fatal("type name not found in encoded data")
+		}
+		got := reflect.New(reflect.TypeOf(in))
+		if err := NewDecoder(bytes.NewReader(data), nil).Decode(got.Interface()); err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(got.Elem().Interface(), in) {
+			t.Errorf("got %+v, want %+v", got, in)
+		}
+	}
+}
+
+// for testing conversions
+// convOld is an older version of convNew.
+type convOld struct {
+	I int8
+	U int
+	F float64
+	S []int
+	D definedSlice
+	N []int64
+	W []int32
+	M map[string]int64
+	A [2]int64
+	X [][]int64
+	T []int
+}
+
+type convNew struct {
+	I int64
+	U uint16
+	F float32
+	S [3]int
+	D []int
+	N []int32
+	W []int64
+	M map[string]uint16
+	A [2]int8
+	X [][]int32
+	T []string
+}
+
+func TestConversions(t *testing.T) {
+	// decodeWith encodes x as a convOld, then decodes it as a convNew.
+	decodeWith := func(x convOld, opts *DecodeOptions) (convNew, error) {
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf, nil).Encode(x); err != nil {
+			t.Fatal(err)
+		}
+		data := bytes.Replace(buf.Bytes(), []byte("convOld"), []byte("convNew"), 1)
+		var got convNew
+		err := NewDecoder(bytes.NewReader(data), opts).Decode(&got)
+		return got, err
+	}
+	decode := func(x convOld) (convNew, error) { return decodeWith(x, nil) }
+
+	in := convOld{
+		I: -3, U: 7, F: 1.5, S: []int{1, 2}, D: definedSlice{4},
+		N: []int64{1, -2}, W: []int32{3}, M: map[string]int64{"a": 5}, A: [2]int64{6},
+	}
+	want := convNew{
+		I: -3, U: 7, F: 1.5, S: [3]int{1, 2, 0}, D: []int{4},
+		N: []int32{1, -2}, W: []int64{3}, M: map[string]uint16{"a": 5}, A: [2]int8{6},
+	}
+	got, err := decode(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// The decoder needs no codecs for the types of the encoded fields, like
+	// []int32, which nothing registered in r uses.
+	r := codecapi.NewRegistry()
+	r.RegisterFrom(codecapi.DefaultRegistry, reflect.TypeOf(convNew{}))
+	got, err = decodeWith(in, &DecodeOptions{Registry: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("with registry: got %+v, want %+v", got, want)
+	}
+
+	_, err = decode(convOld{U: -1})
+	checkMessage(t, err, "cannot convert encoded int -1 to uint16")
+	_, err = decode(convOld{S: []int{1, 2, 3, 4}})
+	checkMessage(t, err, "array size mismatch")
+	_, err = decode(convOld{N: []int64{1 << 40}})
+	checkMessage(t, err, "cannot convert encoded int64 1099511627776 to int32")
+	_, err = decode(convOld{M: map[string]int64{"a": -1}})
+	checkMessage(t, err, "cannot convert encoded int64 -1 to uint16")
+	_, err = decode(convOld{A: [2]int64{1, 300}})
+	checkMessage(t, err, "cannot convert encoded int64 300 to int8")
+	_, err = decode(convOld{X: [][]int64{{1}}})
+	checkMessage(t, err, "codec.convNew.X: cannot convert nested int64 elements to int32")
+	_, err = decode(convOld{T: []int{1}})
+	checkMessage(t, err, "codec.convNew.T: cannot convert encoded int elements to string")
+
+	// The top-level value is converted too.
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, nil).Encode([]int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	var s definedSlice
+	if err := NewDecoder(bytes.NewReader(buf.Bytes()), nil).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if want := (definedSlice{1, 2}); !cmp.Equal(s, want) {
+		t.Errorf("got %v, want %v", s, want)
+	}
+}
+
+// for testing merging
+type mergeConfig struct {
+	Name   string
+	Port   int
+	Tags   []string
+	Limits map[string]int
+	Sub    *mergeSub
+	Subs   map[string]mergeSub
+	Array  [3]int
+}
+
+type mergeSub struct {
+	A, B int
+}
+
+// for testing that values created while merging get their defaults
+type mergeDfltNew struct {
+	Ptr *dfltNew
+	Map map[string]dfltNew
+}
+
+type dfltNew struct {
+	A int
+	D int `codec:",default=7"`
+	L []string
+}
+
+func (x *dfltNew) Default() { x.L = []string{"l"} }
+
+// mergeDfltOld and dfltOld are older versions of mergeDfltNew and dfltNew,
+// from before D was added.
+type mergeDfltOld struct {
+	Ptr *dfltOld
+	Map map[string]dfltOld
+}
+
+type dfltOld struct{ A int }
+
+func TestMerge(t *testing.T) {
+	newBase := func() mergeConfig {
+		return mergeConfig{
+			Name:   "a",
+			Port:   80,
+			Tags:   []string{"x"},
+			Limits: map[string]int{"a": 1, "b": 2},
+			Sub:    &mergeSub{A: 1, B: 2},
+			Subs:   map[string]mergeSub{"k": {A: 1, B: 2}},
+			Array:  [3]int{1, 2, 3},
+		}
+	}
+	overlay := mergeConfig{
+		Port:   8080,
+		Tags:   []string{"y"},
+		Limits: map[string]int{"b": 20, "c": 3},
+		Sub:    &mergeSub{B: 5},
+		Subs:   map[string]mergeSub{"k": {B: 5}, "l": {}, "m": {A: 6}},
+		Array:  [3]int{7},
+	}
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, nil).Encode(overlay); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// Without merging, the overlay replaces the value.
+	got := newBase()
+	if err := NewDecoder(bytes.NewReader(data), nil).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(overlay, got); diff != "" {
+		t.Errorf("no merge: mismatch (-want, +got):\n%s", diff)
+	}
+
+	for _, appendSlices := range []bool{false, true} {
+		got := newBase()
+		sub := got.Sub
+		opts := &DecodeOptions{Merge: true, AppendSlices: appendSlices}
+		if err := NewDecoder(bytes.NewReader(data), opts).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := mergeConfig{
+			Name:   "a",
+			Port:   8080,
+			Tags:   []string{"y"},
+			Limits: map[string]int{"a": 1, "b": 20, "c": 3},
+			Sub:    &mergeSub{A: 1, B: 5},
+			Subs:   map[string]mergeSub{"k": {A: 1, B: 5}, "l": {}, "m": {A: 6}},
+			Array:  [3]int{7, 0, 0}, // all elements of an array are encoded
+		}
+		if appendSlices {
+			want.Tags = []string{"x", "y"}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("append=%t: mismatch (-want, +got):\n%s", appendSlices, diff)
+		}
+		if got.Sub != sub {
+			t.Errorf("append=%t: pointer replaced", appendSlices)
+		}
+	}
+
+	// Defaults are not applied when merging.
+	buf.Reset()
+	if err := NewEncoder(&buf, nil).Encode(reqdC{R: 1}); err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(buf.Bytes(), []byte("reqdC"), []byte("reqdA"), 1)
+	r := reqdA{D: 9}
+	if err := NewDecoder(bytes.NewReader(data), &DecodeOptions{Merge: true}).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if want := (reqdA{R: 1, D: 9}); !cmp.Equal(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+}
+
+func TestMergeNewValueDefaults(t *testing.T) {
+	var buf bytes.Buffer
+	old := mergeDfltOld{Ptr: &dfltOld{A: 1}, Map: map[string]dfltOld{"k": {A: 2}, "l": {A: 3}}}
+	if err := NewEncoder(&buf, nil).Encode(old); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.ReplaceAll(buf.Bytes(), []byte("Old"), []byte("New"))
+
+	got := mergeDfltNew{Map: map[string]dfltNew{"k": {D: 9}}}
+	if err := NewDecoder(bytes.NewReader(data), &DecodeOptions{Merge: true}).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := mergeDfltNew{
+		// A nil pointer gets a new value, with defaults.
+		Ptr: &dfltNew{A: 1, D: 7, L: []string{"l"}},
+		Map: map[string]dfltNew{
+			"k": {A: 2, D: 9},                   // existing: merged, without defaults
+			"l": {A: 3, D: 7, L: []string{"l"}}, // new: defaults
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestDecodeIntoAllocs(t *testing.T) {
+	// Decoding into a variable of the encoded type should not allocate
+	// the interface value that decoding into an interface{} requires.
+	const runs = 10
+	allocs := func(decode func(*Decoder) error) float64 {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf, nil)
+		for i := 0; i < runs+1; i++ {
+			if err := e.Encode(mergeSub{A: i}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		d := NewDecoder(&buf, nil)
+		return testing.AllocsPerRun(runs, func() {
+			if err := decode(d); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	var s mergeSub
+	into := allocs(func(d *Decoder) error { return d.Decode(&s) })
+	var x interface{}
+	viaAny := allocs(func(d *Decoder) error { return d.Decode(&x) })
+	if into >= viaAny {
+		t.Errorf("decoding into mergeSub: %g allocations; into interface{}: %g", into, viaAny)
+	}
+}
+
+func TestDecodeIntoError(t *testing.T) {
+	// A failed decode leaves the argument unchanged.
+	data, err := Marshal(mergeConfig{Name: "n", Tags: []string{"a", "tag1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[bytes.Index(data, []byte("tag1"))-1] = 244 // a reserved code
+	want := mergeConfig{Name: "x", Port: 1, Sub: &mergeSub{A: 1}}
+	got := want
+	if err := Unmarshal(data, &got); err == nil {
+		t.Fatal("got nil, want error")
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	in := mergeConfig{Name: "m", Tags: []string{"a"}, Sub: &mergeSub{A: 1}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got mergeConfig
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, in) {
+		t.Errorf("Unmarshal: got %+v, want %+v", got, in)
+	}
+
+	// A Decoder can read what Marshal writes.
+	got = mergeConfig{}
+	if err := NewDecoder(bytes.NewReader(data), nil).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, in) {
+		t.Errorf("Decoder: got %+v, want %+v", got, in)
+	}
+
+	// AppendEncode appends the same bytes.
+	prefix := []byte("prefix")
+	for i := 0; i < 2; i++ {
+		buf, err := AppendEncode(prefix[:len(prefix):len(prefix)], in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(buf, prefix) || !bytes.Equal(buf[len(prefix):], data) {
+			t.Errorf("AppendEncode: got %q, want %q + %q", buf, prefix, data)
+		}
+	}
+
+	// Unmarshal wants exactly one value.
+	if err := Unmarshal(data[:len(data)-1], &got); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated: got %v, want io.ErrUnexpectedEOF", err)
+	}
+	err = Unmarshal(append(data[:len(data):len(data)], 0), &got)
+	checkMessage(t, err, "more than one value")
+}
+
+func TestReset(t *testing.T) {
+	in := []mergeConfig{
+		{Name: "a", Tags: []string{"x"}},
+		{Name: "b", Sub: &mergeSub{A: 2}},
+	}
+	var buf1, buf2 bytes.Buffer
+	e := NewEncoder(&buf1, nil)
+	if err := e.Encode(in[0]); err != nil {
+		t.Fatal(err)
+	}
+	// After Reset, e writes a complete stream to the new writer.
+	e.Reset(&buf2)
+	if err := e.Encode(in[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(bytes.NewReader(buf1.Bytes()), nil)
+	for i, buf := range []*bytes.Buffer{&buf1, &buf2} {
+		d.Reset(bytes.NewReader(buf.Bytes()))
+		var got mergeConfig
+		if err := d.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(got, in[i]) {
+			t.Errorf("#%d: got %+v, want %+v", i, got, in[i])
+		}
+		if err := d.Decode(&got); err != io.EOF {
+			t.Errorf("#%d: got %v, want io.EOF", i, err)
+		}
+	}
+}
+
+func TestTrackPointersAcrossValues(t *testing.T) {
+	// Pointers are shared only within a single value. A pointer seen in one
+	// call to Encode must be encoded in full by the next.
+	n := &node{Value: 1}
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, &EncodeOptions{TrackPointers: true})
+	for i := 0; i < 2; i++ {
+		if err := e.Encode(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := NewDecoder(&buf, nil)
+	for i := 0; i < 2; i++ {
+		var got *node
+		if err := d.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(got, n) {
+			t.Errorf("#%d: got %+v, want %+v", i, got, n)
+		}
+	}
+}
+
+func TestPools(t *testing.T) {
+	// The Encoders share stats while they run concurrently.
+	var stats codecapi.EncodeStats
+	ep := NewEncoderPool(&EncodeOptions{Stats: &stats})
+	dp := NewDecoderPool(&DecodeOptions{Merge: true})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				in := mergeConfig{Name: fmt.Sprint(i, j)}
+				var buf bytes.Buffer
+				e := ep.Get(&buf)
+				err := e.Encode(in)
+				ep.Put(e)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				d := dp.Get(&buf)
+				got := mergeConfig{Tags: []string{"kept"}}
+				err = d.Decode(&got)
+				dp.Put(d)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				want := mergeConfig{Name: in.Name, Tags: []string{"kept"}}
+				if !cmp.Equal(got, want) {
+					t.Errorf("got %+v, want %+v", got, want)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if got, want := stats.Values, 40; got != want {
+		t.Errorf("stats.Values: got %d, want %d", got, want)
+	}
+	if got, want := stats.Types[reflect.TypeOf(mergeConfig{})].Count, 40; got != want {
+		t.Errorf("mergeConfig count: got %d, want %d", got, want)
+	}
+}
+
+// for testing parallel encoding
+type parallelItem struct {
+	N int
+	V interface{}
+}
+
+func TestParallel(t *testing.T) {
+	const n = 5000
+	items := make([]parallelItem, n)
+	ints := make([]int, n)
+	m := map[string]int{}
+	for i := range items {
+		// Each worker sees different dynamic types first.
+		var v interface{}
+		switch i % 4 {
+		case 0:
+			v = i
+		case 1:
+			v = fmt.Sprint(i)
+		case 2:
+			v = mergeSub{A: i}
+		case 3:
+			v = []int{i}
+		}
+		items[i] = parallelItem{N: i, V: v}
+		ints[i] = i
+		m[fmt.Sprint(i)] = i
+	}
+
+	encode := func(x interface{}, opts *EncodeOptions) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		e := NewEncoder(&buf, opts)
+		// Encode twice, to check that the Encoder's state is reset.
+		for i := 0; i < 2; i++ {
+			if err := e.Encode(x); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return buf.Bytes()
+	}
+
+	for _, x := range []interface{}{items, ints, m} {
+		data := encode(x, &EncodeOptions{Parallelism: 4})
+		d := NewDecoder(bytes.NewReader(data), nil)
+		for i := 0; i < 2; i++ {
+			got := reflect.New(reflect.TypeOf(x))
+			if err := d.Decode(got.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got.Elem().Interface(), x) {
+				t.Errorf("%T: decoded value differs", x)
+			}
+		}
+	}
+
+	// Parallel encoding collects the same statistics.
+	var serial, parallel codecapi.EncodeStats
+	encode(items, &EncodeOptions{Stats: &serial})
+	encode(items, &EncodeOptions{Parallelism: 4, Stats: &parallel})
+	if !cmp.Equal(parallel, serial) {
+		t.Errorf("parallel stats differ from serial:\n%s", cmp.Diff(serial, parallel))
+	}
+
+	// Without dynamic types, the encoding is the same as a serial one.
+	if got, want := encode(ints, &EncodeOptions{Parallelism: 4}), encode(ints, nil); !bytes.Equal(got, want) {
+		t.Error("parallel encoding of []int differs from serial encoding")
+	}
+
+	// Errors in workers are returned.
+	items[n-1].V = struct{}{}
+	err := NewEncoder(&bytes.Buffer{}, &EncodeOptions{Parallelism: 4}).Encode(items)
+	checkMessage(t, err, "unregistered type")
+}
+
+func TestParallelDecoder(t *testing.T) {
+	const n = 100
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, nil)
+	for i := 0; i < n; i++ {
+		var x interface{} = mergeConfig{Name: fmt.Sprint(i), Port: i}
+		if i == n/2 {
+			x = "not a mergeConfig"
+		}
+		if err := e.Encode(x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+
+	// decode decodes data in parallel and returns the decoded values.
+	decode := func(data []byte, f func(*mergeConfig) error) ([]mergeConfig, error) {
+		var got []mergeConfig
+		pd := NewParallelDecoder(bytes.NewReader(data), 4, nil)
+		err := pd.Decode(
+			func() interface{} { return new(mergeConfig) },
+			func(p interface{}) error {
+				c := p.(*mergeConfig)
+				got = append(got, *c)
+				if f != nil {
+					return f(c)
+				}
+				return nil
+			})
+		return got, err
+	}
+
+	check := func(got []mergeConfig, wantLen int) {
+		t.Helper()
+		if len(got) != wantLen {
+			t.Fatalf("got %d values, want %d", len(got), wantLen)
+		}
+		for i, c := range got {
+			if c.Port != i || c.Name != fmt.Sprint(i) {
+				t.Fatalf("#%d: got %+v", i, c)
+			}
+		}
+	}
+
+	// The values before the one that can't be decoded are delivered in order.
+	got, err := decode(data, nil)
+	checkMessage(t, err, "not assignable")
+	check(got, n/2)
+
+	// An error from f stops decoding.
+	errStop := errors.New("stop")
+	got, err = decode(data, func(c *mergeConfig) error {
+		if c.Port == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Errorf("got %v, want %v", err, errStop)
+	}
+	check(got, 11)
+
+	// A stream without the bad value decodes completely.
+	buf.Reset()
+	e = NewEncoder(&buf, nil)
+	for i := 0; i < n; i++ {
+		if err := e.Encode(mergeConfig{Name: fmt.Sprint(i), Port: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err = decode(buf.Bytes(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(got, n)
+
+	// A truncated stream is an error after the complete values.
+	got, err = decode(buf.Bytes()[:buf.Len()-1], nil)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+	check(got, n-1)
+
+	// An empty stream has no values.
+	got, err = decode(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(got, 0)
+
+	// Panics from newValue, f and decoding happen on the calling goroutine,
+	// and the other goroutines finish.
+	var pbuf bytes.Buffer
+	e = NewEncoder(&pbuf, nil)
+	for i := 0; i < n; i++ {
+		if err := e.Encode(panicky(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := runtime.NumGoroutine()
+	for _, test := range []struct {
+		name      string
+		data      []byte
+		newValue  func() interface{}
+		f         func(interface{}) error
+		wantPanic string
+	}{
+		{
+			"newValue",
+			buf.Bytes(),
+			func() interface{} { panic("newValue") },
+			func(interface{}) error { return nil },
+			"newValue",
+		},
+		{
+			"f",
+			buf.Bytes(),
+			func() interface{} { return new(mergeConfig) },
+			func(interface{}) error { panic("f") },
+			"f",
+		},
+		{
+			"UnmarshalCodec",
+			pbuf.Bytes(),
+			func() interface{} { return new(panicky) },
+			func(interface{}) error { return nil },
+			"UnmarshalCodec",
+		},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != test.wantPanic {
+					t.Errorf("%s: got panic %v, want %q", test.name, r, test.wantPanic)
+				}
+			}()
+			NewParallelDecoder(bytes.NewReader(test.data), 4, nil).Decode(test.newValue, test.f)
+		}()
+	}
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("%d goroutines still running", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// for testing panics in custom decoders
+type panicky int
+
+func (p panicky) MarshalCodec(e *codecapi.Encoder) error {
+	e.EncodeInt(int64(p))
+	return nil
+}
+
+func (p *panicky) UnmarshalCodec(d *codecapi.Decoder) error {
+	panic("UnmarshalCodec")
+}
+
+// for testing Marshaler
+type reading struct {
+	Where string
+	Temp  celsius
+}
+
+type celsius struct {
+	deg   float64
+	label string // computed from deg; not encoded
+}
+
+func (c celsius) MarshalCodec(e *codecapi.Encoder) error {
+	if math.IsNaN(c.deg) {
+		return errors.New("celsius: NaN")
+	}
+	e.EncodeFloat(c.deg)
+	return nil
+}
+
+func (c *celsius) UnmarshalCodec(d *codecapi.Decoder) error {
+	c.deg = d.DecodeFloat()
+	c.label = fmt.Sprintf("%g°C", c.deg)
+	return nil
+}
+
+// The text marshaling methods are not used, because celsius implements
+// Marshaler.
+func (c celsius) MarshalText() ([]byte, error) { return nil, errors.New("MarshalText called") }
+func (c *celsius) UnmarshalText([]byte) error  { return errors.New("UnmarshalText called") }
+
+func TestMarshaler(t *testing.T) {
+	in := reading{Where: "here", Temp: celsius{deg: 21.5}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got reading
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := reading{Where: "here", Temp: celsius{deg: 21.5, label: "21.5°C"}}
+	if !cmp.Equal(got, want, cmp.AllowUnexported(celsius{})) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	_, err = Marshal(celsius{deg: math.NaN()})
+	checkMessage(t, err, "celsius: NaN")
+}
+
+// for testing custom codecs
+type invoice struct {
+	Total money
+	Items []money
+}
+
+// Pretend that money is from another module, so we can't add methods to it.
+type money struct {
+	cents    int64
+	currency string
+}
+
+func encodeMoney(e *codecapi.Encoder, m money) error {
+	e.StartList(2)
+	e.EncodeInt(m.cents)
+	e.EncodeString(m.currency)
+	return nil
+}
+
+func decodeMoney(d *codecapi.Decoder, m *money) error {
+	if n := d.StartList(); n != 2 {
+		return fmt.Errorf("money: bad list length %d", n)
+	}
+	m.cents = d.DecodeInt()
+	m.currency = d.DecodeString()
+	return nil
+}
+
+func TestCustomCodec(t *testing.T) {
+	in := invoice{
+		Total: money{300, "EUR"},
+		Items: []money{{100, "EUR"}, {200, "EUR"}},
+	}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got invoice
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, in, cmp.AllowUnexported(money{})) {
+		t.Errorf("got %+v, want %+v", got, in)
+	}
+
+	// The name of the custom codec must match.
+	data = bytes.Replace(data, []byte("money/v1"), []byte("money/v2"), 1)
+	err = Unmarshal(data, &got)
+	checkMessage(t, err, `encoded by custom codec "money/v2", but has custom codec "money/v1"`)
+}
+
+// for testing proxies
+type library struct {
+	Name  string
+	Index *index
+	Color rgb
+}
+
+// An index is encoded as an indexProxy.
+type index struct {
+	mu     sync.Mutex
+	words  []string
+	byWord map[string]int // built from words
+}
+
+type indexProxy struct {
+	Words []string
+}
+
+func newIndex(words ...string) *index {
+	x := &index{}
+	x.FromCodec(indexProxy{Words: words})
+	return x
+}
+
+func (x *index) ToCodec() indexProxy {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return indexProxy{Words: x.words}
+}
+
+func (x *index) FromCodec(p indexProxy) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.words = p.Words
+	x.byWord = map[string]int{}
+	for i, w := range x.words {
+		if _, ok := x.byWord[w]; ok {
+			return fmt.Errorf("index: duplicate word %q", w)
+		}
+		x.byWord[w] = i
+	}
+	return nil
+}
+
+// Pretend that rgb is from another module. It is encoded as a string, using
+// the functions in GenerateOptions.Proxies.
+type rgb struct {
+	r, g, b uint8
+}
+
+func rgbToHex(c *rgb) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
+}
+
+func rgbFromHex(c *rgb, s string) error {
+	_, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.r, &c.g, &c.b)
+	return err
+}
+
+func TestProxy(t *testing.T) {
+	in := library{Name: "lib", Index: newIndex("a", "b"), Color: rgb{1, 2, 255}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got library
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != in.Name || got.Color != in.Color {
+		t.Errorf("got %+v, want %+v", got, in)
+	}
+	if !cmp.Equal(got.Index.byWord, map[string]int{"a": 0, "b": 1}) {
+		t.Errorf("index not rebuilt: got %v", got.Index.byWord)
+	}
+
+	// The proxy's encoding is used.
+	if !bytes.Contains(data, []byte("#0102ff")) {
+		t.Error("rgb proxy not encoded as a string")
+	}
+
+	// Errors from FromCodec are returned.
+	in.Index = newIndex("a")
+	in.Index.words = append(in.Index.words, "a")
+	data, err = Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Unmarshal(data, &got)
+	checkMessage(t, err, "duplicate word")
+}
+
+func TestSchema(t *testing.T) {
+	s := codecapi.RegisteredSchema(reflect.TypeOf(renamedA{}), reflect.TypeOf(invoice{}))
+	const p = "github.com/jba/codec."
+	want := &codecapi.Schema{Types: []*codecapi.TypeSchema{
+		{Name: "[]" + p + "money", Kind: "slice", Elem: p + "money"},
+		{Name: "int", Kind: "int"},
+		{Name: p + "invoice", Kind: "struct", Fields: []*codecapi.FieldSchema{
+			{Name: "Total", Type: p + "money", WireKind: "interface"},
+			{Name: "Items", Type: "[]" + p + "money", WireKind: "slice"},
+		}},
+		{Name: p + "money", Kind: "struct", Custom: "money/v1"},
+		{Name: p + "renamedA", Kind: "struct", Fields: []*codecapi.FieldSchema{
+			{Name: "New", Type: "int", WireKind: "int", Aliases: []string{"Old", "Older"}},
+			{Name: "X", Type: "int", WireKind: "int"},
+		}},
+	}}
+	sort.Slice(want.Types, func(i, j int) bool { return want.Types[i].Name < want.Types[j].Name })
+	if diff := cmp.Diff(want, s); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	if got := s.Lookup(p + "money"); got == nil || got.Custom != "money/v1" {
+		t.Errorf("Lookup: got %+v", got)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s2 codecapi.Schema
+	if err := json.Unmarshal(data, &s2); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(s, &s2); diff != "" {
+		t.Errorf("JSON round trip mismatch (-want, +got):\n%s", diff)
+	}
+
+	// All registered types, including moved's former name.
+	all := codecapi.RegisteredSchema()
+	ts := all.Lookup(p + "moved")
+	if ts == nil || !cmp.Equal(ts.Aliases, []string{"example.com/old/path.Moved"}) {
+		t.Errorf("moved: got %+v", ts)
+	}
+	if all.Fingerprint() == s.Fingerprint() {
+		t.Error("different schemas have the same fingerprint")
+	}
+}
+
+func TestSchemaFingerprint(t *testing.T) {
+	in := invoice{Total: money{cents: 100, currency: "USD"}}
+	for _, record := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf, &EncodeOptions{SchemaFingerprint: record}).Encode(in); err != nil {
+			t.Fatal(err)
+		}
+		d := NewDecoder(&buf, nil)
+		var got invoice
+		if err := d.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Total != in.Total {
+			t.Errorf("got %+v, want %+v", got, in)
+		}
+		want := ""
+		if record {
+			want = codecapi.RegisteredSchema(reflect.TypeOf(in)).Fingerprint()
+		}
+		if got := d.SchemaFingerprint(); got != want {
+			t.Errorf("record=%t: got fingerprint %q, want %q", record, got, want)
+		}
+	}
+}
+
+func TestEncodeStats(t *testing.T) {
+	n := &node{Value: 1, Next: &node{Value: 2}}
+	n.Next.Next = n
+	var (
+		buf   bytes.Buffer
+		stats codecapi.EncodeStats
+	)
+	e := NewEncoder(&buf, &EncodeOptions{TrackPointers: true, Stats: &stats})
+	for _, x := range []interface{}{n, "hello", []int{1, 2}} {
+		if err := e.Encode(x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := stats.Values, 3; got != want {
+		t.Errorf("Values: got %d, want %d", got, want)
+	}
+	// The header, and a length for each value.
+	if got, want := stats.Metadata+stats.Data, buf.Len()-4-3*8; got != want {
+		t.Errorf("Metadata+Data: got %d, want %d", got, want)
+	}
+	if got, want := stats.Refs, 1; got != want {
+		t.Errorf("Refs: got %d, want %d", got, want)
+	}
+	for _, test := range []struct {
+		x            interface{}
+		count, bytes int
+	}{
+		// Two pointers and a ref of 14, 8 and 2 bytes. The first includes the
+		// second, which includes the ref.
+		{&node{}, 3, 24},
+		{node{}, 2, 13 + 7},
+		{"", 1, 7}, // nBytes, 5, "hello"
+		{[]int{}, 1, 4},
+	} {
+		typ := reflect.TypeOf(test.x)
+		ts := stats.Types[typ]
+		if ts == nil {
+			t.Errorf("%s: no stats", typ)
+			continue
+		}
+		if ts.Count != test.count || ts.Bytes != test.bytes {
+			t.Errorf("%s: got %+v, want {Count:%d Bytes:%d}", typ, *ts, test.count, test.bytes)
+		}
+	}
+}
+
+func TestPresence(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, nil)
+	in := patch{A: 0, B: 0, C: "c"}
+	if err := e.Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var got patch
+	// Presence is reset by decoding.
+	got.Present.Reset([]string{"X"})
+	got.Present.Set(0)
+	if err := NewDecoder(&buf, nil).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.A != in.A || got.B != in.B || got.C != in.C {
+		t.Errorf("got %+v, want %+v", got, in)
+	}
+	for _, test := range []struct {
+		name string
+		want bool
+	}{
+		{"A", true}, // zero, but always encoded
+		{"B", false},
+		{"Cee", true},
+		{"C", false}, // Go name, not encoded name
+		{"X", false},
+	} {
+		if g := got.Present.Has(test.name); g != test.want {
+			t.Errorf("Has(%q) = %t, want %t", test.name, g, test.want)
+		}
+	}
+	if g, w := got.Present.Fields(), []string{"A", "Cee"}; !cmp.Equal(g, w) {
+		t.Errorf("Fields() = %v, want %v", g, w)
+	}
+
+	// Decoding into a copy doesn't change the original.
+	buf.Reset()
+	if err := NewEncoder(&buf, nil).Encode(patch{B: 1}); err != nil {
+		t.Fatal(err)
+	}
+	cp := got
+	if err := NewDecoder(&buf, nil).Decode(&cp); err != nil {
+		t.Fatal(err)
+	}
+	if !cp.Present.Has("B") || cp.Present.Has("Cee") {
+		t.Errorf("copy: got fields %v, want [A B]", cp.Present.Fields())
+	}
+	if g, w := got.Present.Fields(), []string{"A", "Cee"}; !cmp.Equal(g, w) {
+		t.Errorf("after decoding a copy, Fields() = %v, want %v", g, w)
+	}
+}
+
 func TestEncodeErrors(t *testing.T) {
 	// The only encoding error is an unregistered type.
 	e := NewEncoder(&bytes.Buffer{}, nil)
@@ -200,6 +1462,86 @@ func TestEncodeErrors(t *testing.T) {
 	checkMessage(t, e.Encode(MyInt(0)), "unregistered")
 }
 
+func TestRegisterTwice(t *testing.T) {
+	// Another package that generated code for these types would register
+	// identical codecs for them.
+	codecapi.DefaultRegistry.RegisterFrom(codecapi.DefaultRegistry, reflect.TypeOf([]int(nil)), reflect.TypeOf(node{}))
+	var n *node
+	roundTripSharing(t, &node{Value: 1}, &n)
+	var s []int
+	roundTripSharing(t, []int{2, 3}, &s)
+}
+
+func TestRegistryOption(t *testing.T) {
+	// A node can't be encoded or decoded without its codecs.
+	empty := codecapi.NewRegistry()
+	var buf bytes.Buffer
+	err := NewEncoder(&buf, &EncodeOptions{Registry: empty}).Encode(&node{Value: 1})
+	checkMessage(t, err, "unregistered type")
+
+	// The codec for node uses the one for *node, which RegisterFrom also
+	// registers.
+	r := codecapi.NewRegistry()
+	r.RegisterFrom(codecapi.DefaultRegistry, reflect.TypeOf(node{}))
+	buf.Reset()
+	if err := NewEncoder(&buf, &EncodeOptions{Registry: r}).Encode(&node{Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	var n *node
+	err = NewDecoder(bytes.NewReader(data), &DecodeOptions{Registry: empty}).Decode(&n)
+	checkMessage(t, err, "unregistered type")
+	if err := NewDecoder(bytes.NewReader(data), &DecodeOptions{Registry: r}).Decode(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n.Value != 1 {
+		t.Errorf("got %+v", n)
+	}
+}
+
+func TestDecodeErrorPath(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, nil)
+	if err := e.Encode(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Encode(mergeConfig{Tags: []string{"a", "tag1"}, Subs: map[string]mergeSub{"key1": {A: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	for _, test := range []struct {
+		marker string
+		delta  int // from the marker to the byte to corrupt
+		want   string
+	}{
+		{"tag1", -1, "codec.mergeConfig.Tags[1]"},
+		{"key1", -1, "codec.mergeConfig.Subs[key 0]"},
+		{"key1", 4, `codec.mergeConfig.Subs["key1"]`},
+	} {
+		bad := append([]byte(nil), data...)
+		i := bytes.Index(bad, []byte(test.marker)) + test.delta
+		bad[i] = 244 // a reserved code
+		// The path is the same when merging into an existing value.
+		for _, merge := range []bool{false, true} {
+			d := NewDecoder(bytes.NewReader(bad), &DecodeOptions{Merge: merge})
+			var n int
+			if err := d.Decode(&n); err != nil {
+				t.Fatal(err)
+			}
+			got := mergeConfig{Subs: map[string]mergeSub{"key1": {B: 2}}}
+			err := d.Decode(&got)
+			var de *codecapi.DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("%s%+d, merge=%t: got %v, want a DecodeError", test.marker, test.delta, merge, err)
+			}
+			if de.Frame != 1 || de.Offset != int64(i+1) || de.Path != test.want {
+				t.Errorf("%s%+d, merge=%t: got frame %d, offset %d, path %q; want 1, %d, %q",
+					test.marker, test.delta, merge, de.Frame, de.Offset, de.Path, i+1, test.want)
+			}
+		}
+	}
+}
+
 func checkMessage(t *testing.T, err error, target string) {
 	t.Helper()
 	if err == nil {
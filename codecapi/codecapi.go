@@ -3,7 +3,8 @@
 // license that can be found in the LICENSE file.
 
 // Package codecapi is used by the codec package and by code generated by
-// codec.GenerateFile. It should NOT be used directly.
+// codec.GenerateFile. It should NOT be used directly, except by the methods of
+// types that implement codec.Marshaler and codec.Unmarshaler.
 package codecapi
 
 import (
@@ -15,25 +16,59 @@ import (
 	"math"
 	"math/bits"
 	"reflect"
+	"strings"
+	"sync"
 )
 
 const uint64Size = 8
 
 // Header for an encoded stream.
 // A 3-byte identifier and a version number.
-var header = []byte("GJC1")
+var header = []byte("GJC2")
+
+// Header for streams written before metadata sections were added.
+// Decoders still accept it.
+var headerV1 = []byte("GJC1")
 
 type Encoder struct {
-	opts      EncodeOptions
-	w         io.Writer
-	buf       []byte
-	typeInfos map[reflect.Type]typeInfo
-	seen      map[uintptr]int // for references; see StartStruct
+	opts        EncodeOptions
+	w           io.Writer
+	wroteHeader bool
+	buf         []byte
+	initial     []byte // buffer for initial metadata, reused across calls to Encode
+	typeInfos   map[reflect.Type]typeInfo
+	codecs      map[reflect.Type]TypeCodec // TypeCodecs, reused across calls to Encode
+	seen        map[uintptr]int            // for references; see StartStruct
+
+	// The statistics of the value being encoded, added to EncodeOptions.Stats
+	// when it is done, so that Encoders can share Stats.
+	stats *EncodeStats
+
+	// Schema fingerprints, by the type names of a frame, for
+	// EncodeOptions.SchemaFingerprint.
+	fingerprints map[string]string
+
+	// For parallel encoding; see parallel.go.
+	mu      sync.Mutex // guards typeInfos and codecs while workers run
+	workers []*Encoder
+	parent  *Encoder // for a worker, the Encoder that records types
 }
 
 type EncodeOptions struct {
 	TrackPointers bool
 	Buffer        []byte
+	Parallelism   int // maximum number of goroutines to encode with
+
+	// SchemaFingerprint records the fingerprint of the schema of the encoded
+	// types with each value.
+	SchemaFingerprint bool
+
+	// Stats, if non-nil, accumulates statistics about the encoded values.
+	Stats *EncodeStats
+
+	// Registry holds the types that can be encoded. If nil, DefaultRegistry
+	// is used.
+	Registry *Registry
 }
 
 type typeInfo struct {
@@ -42,25 +77,83 @@ type typeInfo struct {
 }
 
 func NewEncoder(w io.Writer, opts EncodeOptions) *Encoder {
-	e := &Encoder{w: w, opts: opts}
+	e := &Encoder{
+		w:         w,
+		opts:      opts,
+		typeInfos: map[reflect.Type]typeInfo{},
+		codecs:    map[reflect.Type]TypeCodec{},
+	}
 	if e.opts.TrackPointers {
 		e.seen = make(map[uintptr]int, 1000)
 	}
 	return e
 }
 
+// Reset prepares e to write a new stream to w, as if it were newly created
+// with the same options. It keeps e's buffers and TypeCodecs.
+func (e *Encoder) Reset(w io.Writer) {
+	e.w = w
+	e.wroteHeader = false
+}
+
+// BufferCap returns the total capacity of e's buffers.
+func (e *Encoder) BufferCap() int {
+	return cap(e.buf) + cap(e.initial)
+}
+
 // Encode encodes x.
 func (e *Encoder) Encode(x interface{}) (err error) {
 	// Each call to Encode results in the following output:
 	// - A size in bytes (uint64)
 	// - Initial metadata
 	// - The encoded value
-	if e.typeInfos == nil {
+	if !e.wroteHeader {
 		// First call to encode: write the header.
 		if _, err := e.w.Write(header); err != nil {
 			return err
 		}
+		e.wroteHeader = true
+	}
+	initial, data, err := e.encodeFrame(x)
+	if err != nil {
+		return err
+	}
+	return e.writeFrame(initial, data)
+}
+
+// writeFrame writes a frame consisting of initial followed by data.
+func (e *Encoder) writeFrame(initial, data []byte) error {
+	// Encode total size in a uint64.
+	var buf [uint64Size]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(len(initial)+len(data)))
+	if _, err := e.w.Write(buf[:]); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(initial); err != nil {
+		return err
+	}
+	_, err := e.w.Write(data)
+	return err
+}
+
+// WriteFrame writes a frame, like one read by Decoder.ReadFrame, to the
+// stream. The frame must be of the current version.
+func (e *Encoder) WriteFrame(f Frame) error {
+	if f.Version != 2 {
+		return fmt.Errorf("cannot write a frame of version %d", f.Version)
+	}
+	if !e.wroteHeader {
+		if _, err := e.w.Write(header); err != nil {
+			return err
+		}
+		e.wroteHeader = true
 	}
+	return e.writeFrame(nil, f.Data)
+}
+
+// encodeFrame encodes x, returning the initial metadata and the encoded value.
+// The returned slices are reused by the next call.
+func (e *Encoder) encodeFrame(x interface{}) (initial, data []byte, err error) {
 	if e.buf != nil {
 		e.buf = e.buf[:0]
 	} else if e.opts.Buffer != nil {
@@ -68,87 +161,314 @@ func (e *Encoder) Encode(x interface{}) (err error) {
 	} else {
 		e.buf = make([]byte, 0, 64*1024)
 	}
-	// Each call to Encode gets a fresh set of types.
-	e.typeInfos = map[reflect.Type]typeInfo{}
+	// Each call to Encode gets a fresh set of types, and pointers.
+	for t := range e.typeInfos {
+		delete(e.typeInfos, t)
+	}
+	for p := range e.seen {
+		delete(e.seen, p)
+	}
+	if e.opts.Stats != nil {
+		if e.stats == nil {
+			e.stats = &EncodeStats{}
+		}
+		e.stats.reset()
+	}
 
 	defer handlePanic(&err)
 
-	e.EncodeAny(x)
-	data := e.buf     // remember the data
-	e.buf = nil       // start with a fresh buffer
-	e.encodeInitial() // encode metadata
-	initial := e.buf  // remember that
-	e.buf = data      // restore e.buf for next call to Encode
+	if !e.encodeParallel(x) {
+		e.EncodeAny(x)
+	}
+	data = e.buf          // remember the data
+	e.buf = e.initial[:0] // use a separate buffer for the metadata
+	e.encodeInitial()     // encode metadata
+	initial = e.buf       // remember that
+	e.initial = initial   // reuse its buffer next time
+	e.buf = data          // restore e.buf for next call to Encode
+	if st := e.opts.Stats; st != nil {
+		e.stats.Values++
+		e.stats.Metadata += len(initial)
+		e.stats.Data += len(data)
+		statsMu.Lock()
+		st.add(e.stats)
+		statsMu.Unlock()
+	}
+	return initial, data, nil
+}
 
-	// Encode total size in a uint64.
+// AppendEncode appends to dst the encoding of x, as a complete stream: the
+// header followed by a single value. The value is encoded into e's buffers and
+// then copied to dst, because its metadata, which comes first, isn't known
+// until the value has been encoded.
+func (e *Encoder) AppendEncode(dst []byte, x interface{}) ([]byte, error) {
+	initial, data, err := e.encodeFrame(x)
+	if err != nil {
+		return dst, err
+	}
 	var buf [uint64Size]byte
 	binary.BigEndian.PutUint64(buf[:], uint64(len(initial)+len(data)))
-	if _, err := e.w.Write(buf[:]); err != nil {
-		return err
-	}
-	if _, err := e.w.Write(initial); err != nil {
-		return err
-	}
-	_, err = e.w.Write(data)
-	return err
+	dst = append(dst, header...)
+	dst = append(dst, buf[:]...)
+	dst = append(dst, initial...)
+	return append(dst, data...), nil
 }
 
 type Decoder struct {
 	opts       DecodeOptions
 	r          io.Reader
+	version    byte // from the header
 	buf        []byte
-	i          int // offset into buf
+	i          int    // offset into buf
+	initial    []byte // the initial metadata of the last frame
 	typeCodecs []TypeCodec
+	types      []reflect.Type      // the type of each element of typeCodecs
 	storeIndex int                 // for StartPtr to communicate with StoreRef
 	refMap     map[int]interface{} // from buf offset to pointer
+	// A conversion for the next numeric value, set by NextStructField.
+	convFrom, convTo reflect.Kind
+	// The conversions for the struct fields of the frame's types, and those
+	// of the field that NextStructField last returned, if any.
+	conversions []fieldConversions
+	fieldConv   *fieldConversions
+
+	fingerprint string // from the last frame's metadata; see SchemaFingerprint
+	merging     bool   // see Merging and SetMerging
+
+	// For errors.
+	offset      int64    // number of bytes of the stream read so far
+	frames      int      // number of frames read so far
+	frameIndex  int      // index of the frame being decoded
+	frameOffset int64    // offset in the stream of the frame being decoded
+	path        []string // path to the value that failed, innermost first
+	recording   bool     // see RecordingPath
 }
 
 type DecodeOptions struct {
 	DisallowUnknownFields bool
+	Merge                 bool // decode into the existing value
+	AppendSlices          bool // when merging, append to slices instead of replacing them
+
+	// Registry holds the types that can be decoded. If nil, DefaultRegistry
+	// is used.
+	Registry *Registry
 }
 
 func NewDecoder(r io.Reader, opts DecodeOptions) *Decoder {
-	return &Decoder{r: r, opts: opts}
+	return &Decoder{r: r, opts: opts, merging: opts.Merge}
+}
+
+// Unmarshal decodes data, which must hold a stream of a single encoded value,
+// into p. It does not copy data, but the decoded value does not refer to it.
+func Unmarshal(data []byte, p interface{}, opts DecodeOptions) error {
+	d := NewDecoder(nil, opts)
+	if len(data) < len(header) {
+		return io.ErrUnexpectedEOF
+	}
+	if err := d.checkHeader(data[:len(header)]); err != nil {
+		return err
+	}
+	data = data[len(header):]
+	if len(data) < uint64Size {
+		return io.ErrUnexpectedEOF
+	}
+	sz := binary.BigEndian.Uint64(data)
+	data = data[uint64Size:]
+	if uint64(len(data)) < sz {
+		return io.ErrUnexpectedEOF
+	}
+	if uint64(len(data)) > sz {
+		return errors.New("codec.Unmarshal: data holds more than one value")
+	}
+	d.buf = data
+	d.frameIndex, d.frameOffset = 0, int64(len(header)+uint64Size)
+	return d.decodeFrame(p)
+}
+
+// checkHeader checks the header of an encoded stream, and records its version.
+func (d *Decoder) checkHeader(h []byte) error {
+	switch {
+	case bytes.Equal(header, h):
+		d.version = 2
+	case bytes.Equal(headerV1, h):
+		d.version = 1
+	default:
+		return fmt.Errorf("bad header: got %q, want %q", h, header)
+	}
+	return nil
+}
+
+// Reset prepares d to read a new stream from r, as if it were newly created
+// with the same options. It keeps d's buffers. It also keeps the TypeCodecs of
+// the last value decoded, and reuses them if the next value has the same
+// initial metadata.
+func (d *Decoder) Reset(r io.Reader) {
+	d.r = r
+	d.version = 0
+	d.i = 0
+	d.offset = 0
+	d.frames = 0
+	for k := range d.refMap {
+		delete(d.refMap, k)
+	}
+}
+
+// BufferCap returns the total capacity of d's buffers.
+func (d *Decoder) BufferCap() int {
+	return cap(d.buf) + cap(d.initial)
+}
+
+// SchemaFingerprint returns the schema fingerprint recorded with the last
+// decoded value, or "" if there was none. See EncodeOptions.SchemaFingerprint.
+func (d *Decoder) SchemaFingerprint() string {
+	return d.fingerprint
+}
+
+// Merging reports whether the decoder is merging the encoded data into an
+// existing value. Generated decoders should then leave alone parts of the value
+// that aren't in the encoded data.
+func (d *Decoder) Merging() bool {
+	return d.merging
+}
+
+// SetMerging sets whether the decoder is merging. It starts each value with
+// DecodeOptions.Merge. Generated decoders turn merging off while they decode
+// into a value they have just created, like the target of a nil pointer or a
+// new map entry, so that the value gets its defaults, and then restore it.
+func (d *Decoder) SetMerging(m bool) {
+	d.merging = m
+}
+
+// AppendingSlices reports whether generated slice decoders should append to the
+// existing slice.
+func (d *Decoder) AppendingSlices() bool {
+	return d.merging && d.opts.AppendSlices
 }
 
 // Decode decodes a value encoded with Encoder.Encode
 // and stores the result in the value pointed to by p.
-// The decoded value must be assignable to the pointee's
-// type; no conversions are performed.
+// The decoded value must be assignable or convertible to the pointee's
+// type; see convertValue for the conversions that are performed.
 // Decode returns io.EOF if there are no more values.
 func (d *Decoder) Decode(p interface{}) (err error) {
-	rp := reflect.ValueOf(p)
-	if rp.Kind() != reflect.Ptr {
+	if reflect.ValueOf(p).Kind() != reflect.Ptr {
 		return errors.New("codec.Decode: argument is nil or non-pointer")
 	}
-	if d.buf == nil {
-		// First call to decode: read header.
-		var buf [4]byte
-		if _, err := io.ReadFull(d.r, buf[:]); err != nil {
-			return err
+	// We can reuse d.buf because we didn't let slices from it escape previously.
+	f, err := d.ReadFrame(d.buf)
+	if err != nil {
+		return err
+	}
+	d.buf = f.Data
+	d.frameIndex, d.frameOffset = f.Index, f.Offset
+	return d.decodeFrame(p)
+}
+
+// A Frame holds one encoded value of a stream, as read by ReadFrame: the
+// initial metadata followed by the value. Since the metadata describes all the
+// types in the value, frames can be decoded independently of each other.
+type Frame struct {
+	Version byte // the version of the stream, from its header
+	Data    []byte
+	Index   int   // the position of the frame in its stream, from 0
+	Offset  int64 // the offset of Data in its stream
+}
+
+// ReadFrame reads the next frame of the stream without decoding it. It reads
+// into buf if it is large enough, and otherwise into a new slice. ReadFrame
+// returns io.EOF if there are no more values.
+func (d *Decoder) ReadFrame(buf []byte) (Frame, error) {
+	if d.version == 0 {
+		// First read: read header.
+		var h [4]byte
+		if _, err := io.ReadFull(d.r, h[:]); err != nil {
+			return Frame{}, err
 		}
-		if !bytes.Equal(header, buf[:]) {
-			return fmt.Errorf("bad header: got %q, want %q", buf[:], header)
+		if err := d.checkHeader(h[:]); err != nil {
+			return Frame{}, err
 		}
+		d.offset += int64(len(h))
 	}
 	var szbuf [uint64Size]byte
 	if _, err := io.ReadFull(d.r, szbuf[:]); err != nil {
-		return err
+		return Frame{}, err
 	}
 	sz := binary.BigEndian.Uint64(szbuf[:])
-	// We can reuse d.buf because we didn't let slices from it escape previously.
-	if cap(d.buf) >= int(sz) {
-		d.buf = d.buf[:sz]
+	// When the size of the rest of the input is known, as it is for a
+	// bytes.Reader, check the length before allocating for it.
+	if lr, ok := d.r.(interface{ Len() int }); ok && sz > uint64(lr.Len()) {
+		return Frame{}, io.ErrUnexpectedEOF
+	}
+	if cap(buf) >= int(sz) {
+		buf = buf[:sz]
 	} else {
-		d.buf = make([]byte, sz)
+		buf = make([]byte, sz)
 	}
-	d.i = 0
-	if _, err := io.ReadFull(d.r, d.buf); err != nil {
-		return err
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Frame{}, err
 	}
+	f := Frame{Version: d.version, Data: buf, Index: d.frames, Offset: d.offset + uint64Size}
+	d.offset += int64(uint64Size + len(buf))
+	d.frames++
+	return f, nil
+}
+
+// DecodeFrame decodes a frame read by ReadFrame, possibly by another Decoder,
+// and stores the result in the value pointed to by p, as Decode does. The
+// decoded value does not refer to the frame's data.
+func (d *Decoder) DecodeFrame(f Frame, p interface{}) error {
+	if reflect.ValueOf(p).Kind() != reflect.Ptr {
+		return errors.New("codec.Decode: argument is nil or non-pointer")
+	}
+	d.version = f.Version
+	d.buf = f.Data
+	d.frameIndex, d.frameOffset = f.Index, f.Offset
+	// Don't retain the caller's data.
+	defer func() { d.buf = nil }()
+	return d.decodeFrame(p)
+}
+
+// decodeFrame decodes the value in d.buf, which holds a single frame: the
+// initial metadata and the encoded value. It stores the value in *p.
+func (d *Decoder) decodeFrame(p interface{}) (err error) {
+	defer func() {
+		if err != nil {
+			err = d.decodeError(err)
+		}
+	}()
 	defer handlePanic(&err)
-	d.decodeInitial()
+	d.i = 0
+	d.path = d.path[:0]
+	d.merging = d.opts.Merge
+	d.convFrom, d.convTo = reflect.Invalid, reflect.Invalid
+	d.fieldConv = nil
+	if len(d.initial) > 0 && bytes.HasPrefix(d.buf, d.initial) {
+		// The frame has the same metadata as the last one, so we can reuse
+		// the TypeCodecs.
+		d.i = len(d.initial)
+	} else {
+		d.initial = d.initial[:0] // in case decodeInitial fails
+		d.decodeInitial()
+		d.initial = append(d.initial, d.buf[:d.i]...)
+	}
+	rp := reflect.ValueOf(p)
+	start := d.i
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(codecError); ok {
+				d.recordPath(start)
+			}
+			panic(r)
+		}
+	}()
 
+	// If possible, decode directly into *p.
+	if d.decodeInto(p) {
+		return nil
+	}
 	v := d.DecodeAny()
 	rv := reflect.ValueOf(v)
 	if !rv.IsValid() {
@@ -158,8 +478,12 @@ func (d *Decoder) Decode(p interface{}) (err error) {
 		rv = reflect.ValueOf(&z).Elem()
 	}
 	if !rv.Type().AssignableTo(rp.Elem().Type()) {
-		return fmt.Errorf("codec.Decode: decoded type %s is not assignable to argument type %s",
-			rv.Type(), rp.Elem().Type())
+		cv, err := convertValue(rv, rp.Elem().Type())
+		if err != nil {
+			return fmt.Errorf("codec.Decode: decoded type %s is not assignable to argument type %s: %v",
+				rv.Type(), rp.Elem().Type(), err)
+		}
+		rv = cv
 	}
 	rp.Elem().Set(rv)
 	return nil
@@ -180,6 +504,9 @@ func (d *Decoder) readByte() byte {
 // curByte returns the next byte to be read
 // without actually consuming it.
 func (d *Decoder) curByte() byte {
+	if d.i >= len(d.buf) {
+		panic(errEndOfFrame)
+	}
 	return d.buf[d.i]
 }
 
@@ -188,9 +515,12 @@ func (e *Encoder) writeBytes(b []byte) {
 }
 
 // readBytes reads and returns the given number of bytes.
-// It panics if there are not enough bytes in the input.
+// It fails if there are not enough bytes in the input.
 // It does not copy.
 func (d *Decoder) readBytes(n int) []byte {
+	if n < 0 || n > len(d.buf)-d.i {
+		panic(errEndOfFrame)
+	}
 	d.i += n
 	return d.buf[d.i-n : d.i]
 }
@@ -262,6 +592,9 @@ func (e *Encoder) EncodeUint(u uint64) {
 
 // DecodeUint decodes a uint64.
 func (d *Decoder) DecodeUint() uint64 {
+	if d.convTo != reflect.Invalid {
+		return d.convertUint()
+	}
 	b := d.readByte()
 	switch {
 	case b < endCode:
@@ -288,6 +621,9 @@ func (e *Encoder) EncodeByte(b byte) {
 }
 
 func (d *Decoder) DecodeByte() byte {
+	if d.convTo != reflect.Invalid {
+		return d.convertByte()
+	}
 	return d.readByte()
 }
 
@@ -306,6 +642,9 @@ func (e *Encoder) EncodeInt(i int64) {
 
 // DecodeInt decodes a signed integer.
 func (d *Decoder) DecodeInt() int64 {
+	if d.convTo != reflect.Invalid {
+		return d.convertInt()
+	}
 	u := d.DecodeUint()
 	if u&1 == 1 {
 		return int64(^(u >> 1))
@@ -405,6 +744,9 @@ func (e *Encoder) EncodeFloat(f float64) {
 
 // DecodeFloat decodes a float64.
 func (d *Decoder) DecodeFloat() float64 {
+	if d.convTo != reflect.Invalid {
+		return d.convertFloat()
+	}
 	return math.Float64frombits(bits.ReverseBytes64(d.DecodeUint()))
 }
 
@@ -417,6 +759,9 @@ func (e *Encoder) EncodeComplex(c complex128) {
 
 // DecodeComplex decodes a complex128.
 func (d *Decoder) DecodeComplex() complex128 {
+	if d.convTo != reflect.Invalid {
+		return d.convertComplex()
+	}
 	n := d.StartList()
 	if n != 2 {
 		Failf("DecodeComplex: bad list length %d", n)
@@ -465,6 +810,9 @@ func (e *Encoder) StartPtr(isNil bool, p interface{}) bool {
 		if u, ok := e.seen[ptr]; ok {
 			// If we have already seen this struct pointer,
 			// encode a reference to it.
+			if e.stats != nil {
+				e.stats.Refs++
+			}
 			e.writeByte(refCode)
 			// Encode the relative position, because the buffer
 			// will have data prepended to it.
@@ -538,6 +886,7 @@ func (e *Encoder) EndStruct() {
 // It returns the field number of the next encoded field, or -1
 // if there are no more fields.
 func (d *Decoder) NextStructField(fieldMap []int) int {
+	d.fieldConv = nil
 	if d.curByte() == endCode {
 		d.readByte() // consume the end byte
 		return -1
@@ -546,7 +895,39 @@ func (d *Decoder) NextStructField(fieldMap []int) int {
 	if n >= len(fieldMap) {
 		Failf("field number %d >= field map length %d", n, len(fieldMap))
 	}
-	return fieldMap[n]
+	f := fieldMap[n]
+	if f > fieldNumMask {
+		// The field's value or its elements must be converted. Arrange for
+		// the next call to a numeric Decode method, and the decoder of the
+		// field's slice, array or map, to do so.
+		fc := &d.conversions[f>>fieldNumBits-1]
+		if fc.err != nil {
+			Fail(fc.err)
+		}
+		d.convFrom, d.convTo = fc.value.from, fc.value.to
+		d.fieldConv = fc
+		f &= fieldNumMask
+	}
+	return f
+}
+
+// ElemConversions returns the conversions for the keys and elements of the
+// map, slice or array in the struct field that NextStructField last returned,
+// and clears them. A decoder for a map, slice or array with numeric keys or
+// elements should call it before decoding them, and pass the conversions to
+// Convert before decoding each numeric key or element.
+func (d *Decoder) ElemConversions() (key, elem Conversion) {
+	fc := d.fieldConv
+	if fc == nil {
+		return Conversion{}, Conversion{}
+	}
+	d.fieldConv = nil
+	return fc.key, fc.elem
+}
+
+// Convert arranges for the next call to a numeric Decode method to perform c.
+func (d *Decoder) Convert(c Conversion) {
+	d.convFrom, d.convTo = c.from, c.to
 }
 
 // UnknownField should be called by a struct decoder
@@ -615,6 +996,13 @@ func (e *Encoder) EncodeAny(x interface{}) {
 	// Encode a 2-element list of the type number and the encoded value.
 	e.StartList(2)
 	e.EncodeUint(uint64(tnum))
+	if _, ok := tc.(builtinCodec); ok && e.stats != nil {
+		// Generated codecs record their own statistics; builtin ones don't.
+		start := len(e.buf)
+		tc.Encode(e, x)
+		e.StatsEnd(t, start)
+		return
+	}
 	tc.Encode(e, x)
 }
 
@@ -625,12 +1013,28 @@ func (e *Encoder) recordType(t reflect.Type) (TypeCodec, int) {
 	if ti, ok := e.typeInfos[t]; ok {
 		return ti.tc, ti.num
 	}
-	tcb := typeCodecBuildersByType[t]
+	if e.parent != nil {
+		return e.recordParentType(t)
+	}
+	num := len(e.typeInfos)
+	if tc, ok := e.codecs[t]; ok {
+		// A previous call to Encode built the TypeCodec. Its codecs are set,
+		// but we still must record the types it uses.
+		e.typeInfos[t] = typeInfo{tc, num}
+		for _, tu := range tc.TypesUsed() {
+			e.recordType(tu)
+		}
+		return tc, num
+	}
+	tcb := registryOr(e.opts.Registry).load().buildersByType[t]
 	if tcb == nil {
 		Failf("unregistered type %q", t)
 	}
 	tc := tcb()
-	num := len(e.typeInfos)
+	if hasNoEncoder(tc) {
+		Failf("cannot encode %s: its codec was generated with DecodeOnly", t)
+	}
+	e.codecs[t] = tc
 	e.typeInfos[t] = typeInfo{tc, num}
 	tus := tc.TypesUsed()
 	tcs := make([]TypeCodec, len(tus))
@@ -660,62 +1064,242 @@ func (d *Decoder) DecodeAny() interface{} {
 	if num >= uint64(len(d.typeCodecs)) {
 		Failf("type number %d out of range", num)
 	}
-	tc := d.typeCodecs[num]
-	return tc.Decode(d)
+	done := false
+	if d.recording {
+		defer func() {
+			if !done {
+				d.pathType(d.types[num])
+			}
+		}()
+	}
+	// The value is new, so it isn't merged into.
+	merging := d.merging
+	d.merging = false
+	v := d.typeCodecs[num].Decode(d)
+	d.merging = merging
+	done = true
+	return v
+}
+
+// decodeInto decodes a value encoded by EncodeAny into p, a pointer, without
+// creating a new value. It returns false without consuming any input if the
+// encoded value is not of p's element type, or if its TypeCodec is not an
+// IntoDecoder.
+func (d *Decoder) decodeInto(p interface{}) bool {
+	if d.curByte() == 0 {
+		// A nil interface.
+		return false
+	}
+	start := d.i
+	if n := d.StartList(); n != 2 {
+		Failf("DecodeAny: bad list length %d", n)
+	}
+	num := d.DecodeUint()
+	if num >= uint64(len(d.typeCodecs)) {
+		Failf("type number %d out of range", num)
+	}
+	if id, ok := d.typeCodecs[num].(IntoDecoder); ok && d.types[num] == reflect.TypeOf(p).Elem() {
+		done := false
+		if d.recording {
+			defer func() {
+				if !done {
+					d.pathType(d.types[num])
+				}
+			}()
+		}
+		id.DecodeInto(d, p)
+		done = true
+		return true
+	}
+	d.i = start
+	return false
 }
 
 // encodeInitial encodes metadata that appears at the start of the
 // encoded byte slice.
 func (e *Encoder) encodeInitial() {
-	type fieldInfo struct {
-		num    int
-		fields []string
+	md := &Metadata{
+		TypeNames:      make([]string, len(e.typeInfos)),
+		Fields:         map[int][]string{},
+		FieldKinds:     map[int][]reflect.Kind{},
+		FieldElemKinds: map[int][][]reflect.Kind{},
+		CustomNames:    map[int]string{},
 	}
-
-	// Encode the list of type names we saw, in the order we
-	// assigned numbers to them.
-	// Also encode the field names of each struct we saw, with their field
-	// numbers.
-	typeNames := make([]string, len(e.typeInfos))
-	var fieldNames []fieldInfo
 	for t, ti := range e.typeInfos {
-		typeNames[ti.num] = TypeString(t, nil)
-		fs := ti.tc.Fields()
-		if fs != nil {
-			fieldNames = append(fieldNames, fieldInfo{ti.num, fs})
+		md.TypeNames[ti.num] = TypeString(t, nil)
+		if fs := ti.tc.Fields(); fs != nil {
+			md.Fields[ti.num] = fs
+		}
+		if fk, ok := ti.tc.(FieldKinder); ok {
+			md.FieldKinds[ti.num] = fk.FieldKinds()
+		}
+		if eks := fieldElemKinds(ti.tc); eks != nil {
+			md.FieldElemKinds[ti.num] = eks
+		}
+		if cn, ok := ti.tc.(CustomNamer); ok {
+			md.CustomNames[ti.num] = cn.CustomName()
+		}
+	}
+	if e.opts.SchemaFingerprint {
+		md.Fingerprint = e.schemaFingerprint(md.TypeNames)
+	}
+	e.encodeMetadata(md)
+}
+
+// encodeMetadata encodes md. Everything keyed by type number is written in
+// order of type number, so the output is deterministic.
+func (e *Encoder) encodeMetadata(md *Metadata) {
+	// Encode the list of type names, in the order their numbers were
+	// assigned.
+	e.encodeStringSlice(md.TypeNames)
+
+	// Encode the field names of each struct, with their type numbers.
+	e.StartList(len(md.Fields))
+	for num := range md.TypeNames {
+		if fs, ok := md.Fields[num]; ok {
+			e.EncodeUint(uint64(num))
+			e.encodeStringSlice(fs)
 		}
 	}
 
-	e.encodeStringSlice(typeNames)
-	e.StartList(len(fieldNames))
-	for _, fi := range fieldNames {
-		e.EncodeUint(uint64(fi.num))
-		e.encodeStringSlice(fi.fields)
+	// Encode the sections of additional metadata. Each is a name followed by a
+	// single value. Decoders skip sections they don't know.
+	nSections := 0
+	for _, n := range []int{len(md.FieldKinds), len(md.FieldElemKinds), len(md.CustomNames), len(md.Fingerprint)} {
+		if n > 0 {
+			nSections++
+		}
+	}
+	e.StartList(nSections)
+	if len(md.FieldKinds) > 0 {
+		// A list of pairs of type number and field kinds.
+		e.EncodeString(fieldKindsSection)
+		e.StartList(2 * len(md.FieldKinds))
+		for num := range md.TypeNames {
+			if kinds, ok := md.FieldKinds[num]; ok {
+				e.EncodeUint(uint64(num))
+				e.StartList(len(kinds))
+				for _, k := range kinds {
+					e.EncodeUint(uint64(k))
+				}
+			}
+		}
+	}
+	if len(md.FieldElemKinds) > 0 {
+		// A list of pairs of type number and the element kinds of each field.
+		e.EncodeString(fieldElemKindsSection)
+		e.StartList(2 * len(md.FieldElemKinds))
+		for num := range md.TypeNames {
+			if eks, ok := md.FieldElemKinds[num]; ok {
+				e.EncodeUint(uint64(num))
+				e.encodeElemKinds(eks)
+			}
+		}
+	}
+	if len(md.CustomNames) > 0 {
+		// A list of pairs of type number and custom codec name.
+		e.EncodeString(customCodecsSection)
+		e.StartList(2 * len(md.CustomNames))
+		for num := range md.TypeNames {
+			if name, ok := md.CustomNames[num]; ok {
+				e.EncodeUint(uint64(num))
+				e.EncodeString(name)
+			}
+		}
+	}
+	if md.Fingerprint != "" {
+		e.EncodeString(schemaFingerprintSection)
+		e.EncodeString(md.Fingerprint)
 	}
 }
 
+// fieldElemKinds returns the element kinds of the fields of the struct that tc
+// encodes, or nil if it doesn't report them or none of its fields has
+// elements.
+func fieldElemKinds(tc TypeCodec) [][]reflect.Kind {
+	fek, ok := tc.(FieldElemKinder)
+	if !ok {
+		return nil
+	}
+	eks := fek.FieldElemKinds()
+	for _, ks := range eks {
+		if len(ks) > 0 {
+			return eks
+		}
+	}
+	return nil
+}
+
+// encodeElemKinds encodes the element kinds of a struct's fields.
+func (e *Encoder) encodeElemKinds(eks [][]reflect.Kind) {
+	e.StartList(len(eks))
+	for _, ks := range eks {
+		e.StartList(len(ks))
+		for _, k := range ks {
+			e.EncodeUint(uint64(k))
+		}
+	}
+}
+
+// schemaFingerprint returns the fingerprint of the schema of the types with
+// the given names.
+func (e *Encoder) schemaFingerprint(typeNames []string) string {
+	key := strings.Join(typeNames, ";")
+	if fp, ok := e.fingerprints[key]; ok {
+		return fp
+	}
+	types := make([]reflect.Type, len(typeNames))
+	for t, ti := range e.typeInfos {
+		types[ti.num] = t
+	}
+	fp := registryOr(e.opts.Registry).Schema(types...).Fingerprint()
+	if e.fingerprints == nil {
+		e.fingerprints = map[string]string{}
+	}
+	e.fingerprints[key] = fp
+	return fp
+}
+
+// Names of metadata sections.
+const (
+	fieldKindsSection     = "fieldKinds"     // the kinds of struct fields
+	fieldElemKindsSection = "fieldElemKinds" // the kinds of the elements of struct fields
+	customCodecsSection   = "customCodecs"   // the names of custom codecs
+
+	schemaFingerprintSection = "schemaFingerprint" // from EncodeOptions.SchemaFingerprint
+)
+
 // decodeInitial decodes metadata that appears at the start of the
 // encoded byte slice.
 func (d *Decoder) decodeInitial() {
 	// Decode the list of type names. The number of a type is its position in
 	// the list.
 	typeNames := d.decodeStringSlice()
+	d.fingerprint = ""
+	d.conversions = d.conversions[:0]
 	d.typeCodecs = make([]TypeCodec, len(typeNames))
-	types := make([]reflect.Type, len(typeNames))
+	d.types = make([]reflect.Type, len(typeNames))
 	tcMap := map[reflect.Type]TypeCodec{}
+	regs := registryOr(d.opts.Registry).load()
 	for num, name := range typeNames {
-		t := nameToType[name]
+		t := regs.lookupType(name)
 		if t == nil {
-			Failf("unregistered type: %s", name)
+			// The type may be one that has since changed, like the type of a
+			// struct field. Fail only if a value of it is decoded.
+			d.typeCodecs[num] = unregisteredCodec{name: name}
+			continue
 		}
-		tcb := typeCodecBuildersByType[t]
+		tcb := regs.decodeBuilder(t)
 		if tcb == nil {
 			panic(fmt.Sprintf("have type for name %q but not builder", name))
 		}
 		tc := tcb()
+		if hasNoDecoder(tc) {
+			Failf("cannot decode %s: its codec was generated with EncodeOnly", t)
+		}
 		d.typeCodecs[num] = tc
 		tcMap[t] = tc
-		types[num] = t
+		d.types[num] = t
 	}
 	n := d.StartList()
 	fieldMaps := make(map[int][]int, n)
@@ -724,30 +1308,78 @@ func (d *Decoder) decodeInitial() {
 		if int(num) >= len(d.typeCodecs) {
 			Failf("bad type number: %d", num)
 		}
-		generatedFields := d.typeCodecs[num].Fields()
+		tc := d.typeCodecs[num]
+		var aliases map[string]string
+		if fa, ok := tc.(FieldAliaser); ok {
+			aliases = fa.FieldAliases()
+		}
 		encodedFields := d.decodeStringSlice()
-		fieldMaps[int(num)] = buildFieldMap(generatedFields, encodedFields)
+		fieldMaps[int(num)] = buildFieldMap(tc.Fields(), encodedFields, aliases)
+	}
+
+	var (
+		customNames map[int]string
+		kinds       map[int][]reflect.Kind
+		elemKinds   map[int][][]reflect.Kind
+	)
+	if d.version >= 2 {
+		n := d.StartList()
+		for i := 0; i < n; i++ {
+			switch d.DecodeString() {
+			case fieldKindsSection:
+				kinds = d.decodeFieldKinds()
+			case fieldElemKindsSection:
+				elemKinds = d.decodeFieldElemKinds()
+			case customCodecsSection:
+				customNames = d.decodeCustomNames()
+			case schemaFingerprintSection:
+				d.fingerprint = d.DecodeString()
+			default:
+				d.skip()
+			}
+		}
+	}
+	d.checkCustomNames(customNames)
+	for num, fm := range fieldMaps {
+		d.addConversions(num, fm, kinds[num], elemKinds[num])
 	}
 
 	// Give each TypeCodec the chance to initialize itself with the other TypeCodecs,
 	// and its own field map.
+	// If a type has changed since the data was encoded, its codec may use types
+	// that aren't in the encoded data. Create codecs for those too.
 	var tcs []TypeCodec
-	for num, tc := range d.typeCodecs {
-		tus := tc.TypesUsed()
-		for _, tu := range tus {
-			tcs = append(tcs, tcMap[tu])
+	pending := append([]TypeCodec(nil), d.typeCodecs...)
+	for len(pending) > 0 {
+		tc := pending[0]
+		pending = pending[1:]
+		for _, tu := range tc.TypesUsed() {
+			tc2, ok := tcMap[tu]
+			if !ok {
+				tcb := regs.decodeBuilder(tu)
+				if tcb == nil {
+					Failf("unregistered type %q", tu)
+				}
+				tc2 = tcb()
+				tcMap[tu] = tc2
+				pending = append(pending, tc2)
+			}
+			tcs = append(tcs, tc2)
 		}
 		tc.SetCodecs(tcs)
 		tcs = tcs[:0]
+	}
+	for num, tc := range d.typeCodecs {
 		tc.SetFieldMap(fieldMaps[num])
 	}
 }
 
 // buildFieldMap constructs a mapping from encoded field numbers to generated field numbers.
 // For example, if field F was encoded with number 1 but the generated code uses 2 for it,
-// then mapping[1] == 2. If there is no generated field corresponding to an encoded one, then
-// the mapping value is -2.
-func buildFieldMap(generatedFields, encodedFields []string) []int {
+// then mapping[1] == 2. An encoded field whose name isn't generated is looked up in
+// aliases, a map from former field names to current ones. If there is no generated field
+// corresponding to an encoded one, then the mapping value is -2.
+func buildFieldMap(generatedFields, encodedFields []string, aliases map[string]string) []int {
 	if encodedFields == nil {
 		return nil
 	}
@@ -757,7 +1389,13 @@ func buildFieldMap(generatedFields, encodedFields []string) []int {
 	}
 	m := make([]int, len(encodedFields))
 	for i, e := range encodedFields {
-		if j, ok := g[e]; ok {
+		j, ok := g[e]
+		if !ok {
+			if a, isAlias := aliases[e]; isAlias {
+				j, ok = g[a]
+			}
+		}
+		if ok {
 			m[i] = j
 		} else {
 			m[i] = -2
@@ -766,6 +1404,99 @@ func buildFieldMap(generatedFields, encodedFields []string) []int {
 	return m
 }
 
+// decodeCustomNames decodes the names of the custom codecs used for encoding,
+// and returns a map from type number to name.
+func (d *Decoder) decodeCustomNames() map[int]string {
+	n := d.StartList()
+	m := make(map[int]string, n/2)
+	for i := 0; i < n/2; i++ {
+		num := d.DecodeUint()
+		if int(num) >= len(d.typeCodecs) {
+			Failf("bad type number: %d", num)
+		}
+		m[int(num)] = d.DecodeString()
+	}
+	return m
+}
+
+// checkCustomNames checks that each type was encoded with the same custom
+// codec, or lack of one, that will decode it.
+func (d *Decoder) checkCustomNames(customNames map[int]string) {
+	for num, tc := range d.typeCodecs {
+		if _, ok := tc.(unregisteredCodec); ok {
+			continue
+		}
+		encoded, wasCustom := customNames[num]
+		var decoding string
+		cn, isCustom := tc.(CustomNamer)
+		if isCustom {
+			decoding = cn.CustomName()
+		}
+		switch {
+		case wasCustom && !isCustom:
+			Failf("%s was encoded by custom codec %q, but has no custom codec", d.types[num], encoded)
+		case !wasCustom && isCustom:
+			Failf("%s was not encoded by a custom codec, but has custom codec %q", d.types[num], decoding)
+		case encoded != decoding:
+			Failf("%s was encoded by custom codec %q, but has custom codec %q", d.types[num], encoded, decoding)
+		}
+	}
+}
+
+// decodeFieldKinds decodes the kinds of encoded struct fields, and returns
+// them by type number.
+func (d *Decoder) decodeFieldKinds() map[int][]reflect.Kind {
+	n := d.StartList()
+	m := make(map[int][]reflect.Kind, n/2)
+	for i := 0; i < n; i += 2 {
+		num := d.decodeTypeNum()
+		m[num] = d.decodeKinds()
+	}
+	return m
+}
+
+// decodeFieldElemKinds decodes the element kinds of encoded struct fields,
+// and returns them by type number.
+func (d *Decoder) decodeFieldElemKinds() map[int][][]reflect.Kind {
+	n := d.StartList()
+	m := make(map[int][][]reflect.Kind, n/2)
+	for i := 0; i < n; i += 2 {
+		num := d.decodeTypeNum()
+		nf := d.StartList()
+		if nf < 0 || nf > len(d.buf)-d.i {
+			Failf("bad field list length %d", nf)
+		}
+		eks := make([][]reflect.Kind, nf)
+		for j := range eks {
+			eks[j] = d.decodeKinds()
+		}
+		m[num] = eks
+	}
+	return m
+}
+
+// decodeTypeNum decodes a type number in the metadata.
+func (d *Decoder) decodeTypeNum() int {
+	num := d.DecodeUint()
+	if num >= uint64(len(d.typeCodecs)) {
+		Failf("bad type number: %d", num)
+	}
+	return int(num)
+}
+
+// decodeKinds decodes a list of reflect.Kinds.
+func (d *Decoder) decodeKinds() []reflect.Kind {
+	n := d.StartList()
+	if n < 0 || n > len(d.buf)-d.i {
+		Failf("bad kind list length %d", n)
+	}
+	kinds := make([]reflect.Kind, n)
+	for i := range kinds {
+		kinds[i] = reflect.Kind(d.DecodeUint())
+	}
+	return kinds
+}
+
 func (e *Encoder) encodeStringSlice(s []string) {
 	e.StartList(len(s))
 	for _, x := range s {
@@ -775,6 +1506,9 @@ func (e *Encoder) encodeStringSlice(s []string) {
 
 func (d *Decoder) decodeStringSlice() []string {
 	n := d.StartList()
+	if n < 0 || n > len(d.buf)-d.i {
+		Failf("bad string list length %d", n)
+	}
 	s := make([]string, n)
 	for i := 0; i < n; i++ {
 		s[i] = d.DecodeString()
@@ -811,6 +1545,119 @@ func Fail(err error) {
 	panic(codecError{err})
 }
 
+// A DecodeError describes a failure to decode a value. Err is the underlying
+// error.
+type DecodeError struct {
+	Frame  int    // the index of the frame in the stream, from 0
+	Offset int64  // the offset in the stream where decoding stopped
+	Path   string // the path to the value that failed, if known
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "codec: decoding frame %d at offset %d", e.Frame, e.Offset)
+	if e.Path != "" {
+		fmt.Fprintf(&b, ", in %s", e.Path)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// decodeError returns a DecodeError for err, which happened while decoding
+// the current frame.
+func (d *Decoder) decodeError(err error) error {
+	return &DecodeError{
+		Frame:  d.frameIndex,
+		Offset: d.frameOffset + int64(d.i),
+		Path:   d.pathString(),
+		Err:    err,
+	}
+}
+
+// recordPath records the path to the part of the value starting at offset
+// start that failed to decode. It decodes the value again, this time with
+// RecordingPath reporting true, so that the generated code records the path as
+// the failure unwinds the stack. That costs nothing when decoding succeeds.
+// The path is recorded only if the second failure happens where the first
+// did.
+func (d *Decoder) recordPath(start int) {
+	i, merging := d.i, d.merging
+	defer func() {
+		if recover() == nil || d.i != i {
+			d.path = d.path[:0]
+		}
+		d.i, d.merging, d.recording = i, merging, false
+	}()
+	d.i, d.merging, d.recording = start, false, true
+	d.convFrom, d.convTo = reflect.Invalid, reflect.Invalid
+	d.fieldConv = nil
+	d.DecodeAny()
+}
+
+// RecordingPath reports whether d is decoding a value again after it failed,
+// to record the path to the failure. Generated code defers calls to the Path
+// methods only when it is.
+func (d *Decoder) RecordingPath() bool {
+	return d.recording
+}
+
+// The Path methods record the path to a value that failed to decode. The calls
+// are made innermost first, as the failure unwinds the stack.
+
+// PathField records that the failure was in the struct field with the given
+// name.
+func (d *Decoder) PathField(name string) {
+	d.path = append(d.path, "."+name)
+}
+
+// PathIndex records that the failure was in the list element with the given
+// index.
+func (d *Decoder) PathIndex(i int) {
+	d.path = append(d.path, fmt.Sprintf("[%d]", i))
+}
+
+// PathKey records that the failure was in the map value with the given key.
+func (d *Decoder) PathKey(k interface{}) {
+	d.path = append(d.path, fmt.Sprintf("[%#v]", k))
+}
+
+// PathMapKey records that the failure was in the key of the map entry with the
+// given index.
+func (d *Decoder) PathMapKey(i int) {
+	d.path = append(d.path, fmt.Sprintf("[key %d]", i))
+}
+
+// pathType records that the failure was in a value of type t, held in an
+// interface.
+func (d *Decoder) pathType(t reflect.Type) {
+	if t == nil {
+		// The type isn't registered.
+		return
+	}
+	d.path = append(d.path, ".("+t.String()+")")
+}
+
+// pathString returns the recorded path, outermost first. The outermost
+// element is normally the type of the top-level value, which is written
+// without pointers, like "ast.File" rather than ".(*ast.File)".
+func (d *Decoder) pathString() string {
+	if len(d.path) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i := len(d.path) - 1; i >= 0; i-- {
+		e := d.path[i]
+		if i == len(d.path)-1 && strings.HasPrefix(e, ".(") {
+			e = strings.TrimLeft(e[2:len(e)-1], "*")
+		}
+		b.WriteString(e)
+	}
+	return b.String()
+}
+
 func (d *Decoder) badcode(c byte) {
 	Failf("bad code %d at %d", c, d.i-1)
 }
@@ -821,4 +1668,9 @@ type codecError struct {
 	err error
 }
 
+// errEndOfFrame is the panic value when the input ends in the middle of a
+// value. Panicking with it directly, rather than calling Fail, keeps the
+// functions that read bytes small enough to inline.
+var errEndOfFrame interface{} = codecError{errors.New("unexpected end of frame")}
+
 func (c codecError) String() string { return c.err.Error() }
@@ -6,8 +6,11 @@ package codecapi
 
 import (
 	"bytes"
+	"encoding/binary"
 	"io"
 	"math"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -87,6 +90,103 @@ func TestDecodeEOF(t *testing.T) {
 	}
 }
 
+// Types whose codecs lack an encoder or a decoder, like those generated with
+// DecodeOnly or EncodeOnly.
+type (
+	encodeOnly int
+	decodeOnly int
+)
+
+type encodeOnlyCodec struct {
+	prim
+	NoDecoder
+}
+
+func (encodeOnlyCodec) Encode(e *Encoder, x interface{}) { e.EncodeInt(int64(x.(encodeOnly))) }
+
+type decodeOnlyCodec struct {
+	prim
+	NoEncoder
+}
+
+func (decodeOnlyCodec) Decode(d *Decoder) interface{} { return decodeOnly(d.DecodeInt()) }
+
+func init() {
+	Register(reflect.TypeOf(encodeOnly(0)), func() TypeCodec { return encodeOnlyCodec{} })
+	Register(reflect.TypeOf(decodeOnly(0)), func() TypeCodec { return decodeOnlyCodec{} })
+}
+
+func TestNoEncoderOrDecoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, EncodeOptions{}).Encode(encodeOnly(1)); err != nil {
+		t.Fatal(err)
+	}
+	var got interface{}
+	err := NewDecoder(&buf, DecodeOptions{}).Decode(&got)
+	if want := "generated with EncodeOnly"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("decoding: got %v, want error containing %q", err, want)
+	}
+
+	err = NewEncoder(&buf, EncodeOptions{}).Encode(decodeOnly(1))
+	if want := "generated with DecodeOnly"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("encoding: got %v, want error containing %q", err, want)
+	}
+}
+
+func TestEncodeOnlyAndDecodeOnly(t *testing.T) {
+	// Codecs for the same type, one with only an encoder and one with only a
+	// decoder, as from two packages that generated code for it.
+	typ := reflect.TypeOf(encodeOnly(0))
+	r := NewRegistry()
+	r.Register(typ, func() TypeCodec { return encodeOnlyCodec{} })
+	r.Register(typ, func() TypeCodec { return encodeOnlyDecoder{} })
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, EncodeOptions{Registry: r}).Encode(encodeOnly(3)); err != nil {
+		t.Fatal(err)
+	}
+	var got interface{}
+	if err := NewDecoder(&buf, DecodeOptions{Registry: r}).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != encodeOnly(3) {
+		t.Errorf("got %v, want 3", got)
+	}
+}
+
+type encodeOnlyDecoder struct {
+	prim
+	NoEncoder
+}
+
+func (encodeOnlyDecoder) Decode(d *Decoder) interface{} { return encodeOnly(d.DecodeInt()) }
+
+func TestDecodeV1(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, EncodeOptions{}).Encode(7); err != nil {
+		t.Fatal(err)
+	}
+	// Convert to version 1 by changing the header and removing the empty list
+	// of metadata sections that follows the empty list of struct fields.
+	data := buf.Bytes()
+	empties := []byte{nValuesCode, 0, nValuesCode, 0}
+	i := bytes.Index(data, empties)
+	if i < 0 {
+		t.Fatal("no metadata sections")
+	}
+	data = append(data[:i+2], data[i+4:]...)
+	copy(data, headerV1)
+	sz := binary.BigEndian.Uint64(data[len(header):])
+	binary.BigEndian.PutUint64(data[len(header):], sz-2)
+
+	var got int
+	if err := NewDecoder(bytes.NewReader(data), DecodeOptions{}).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != 7 {
+		t.Errorf("got %d, want 7", got)
+	}
+}
+
 func TestBuildFieldMap(t *testing.T) {
 	generatedFields := []string{"A", "B", "C"}
 	for _, test := range []struct {
@@ -99,16 +199,23 @@ func TestBuildFieldMap(t *testing.T) {
 		{[]string{"B", "A", "C"}, []int{1, 0, 2}},
 		{[]string{"C", "D"}, []int{2, -2}},
 	} {
-		got := buildFieldMap(generatedFields, test.encodedFields)
+		got := buildFieldMap(generatedFields, test.encodedFields, nil)
 		if !cmp.Equal(got, test.want) {
 			t.Errorf("%v: got %v, want %v", test.encodedFields, got, test.want)
 		}
 	}
 
-	got := buildFieldMap(nil, []string{"A", "B"})
+	got := buildFieldMap(nil, []string{"A", "B"}, nil)
 	want := []int{-2, -2}
 	if !cmp.Equal(got, want) {
 		t.Errorf("got %v, want %v", got, want)
 	}
 
+	aliases := map[string]string{"OldA": "A", "OlderA": "A", "Gone": "D"}
+	got = buildFieldMap(generatedFields, []string{"C", "OlderA", "B", "Gone"}, aliases)
+	want = []int{2, 0, 1, -2}
+	if !cmp.Equal(got, want) {
+		t.Errorf("aliases: got %v, want %v", got, want)
+	}
+
 }
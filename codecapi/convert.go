@@ -0,0 +1,630 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codecapi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"reflect"
+)
+
+// This file implements conversions between the type of an encoded value and
+// the type it is decoded into.
+//
+// Struct fields are converted as they are decoded. The encoder records the
+// kind of each struct field, and the kinds of its elements. When a field's
+// encoded kind differs from the kind of the generated field in a way that
+// affects the encoding, or the kinds of the elements of a slice, array or map
+// field differ that way, the decoder records the conversions in the field
+// map. NextStructField then sets the Decoder's conversion, which the next call
+// to a numeric Decode method performs, and its element conversions, which the
+// generated decoder for the slice, array or map passes to Convert before
+// decoding each key or element. Other changes to a field's type, like from a
+// defined type to its underlying type, from a slice to an array, or from
+// []int32 to []int64, don't affect the encoding and need no conversion. A
+// change to the elements that can't be converted, like from []int to
+// []string, or that would require converting the elements of nested
+// elements, like from [][]int64 to [][]int32, is an error when the field is
+// decoded.
+//
+// The top-level value is converted by Decoder.Decode using reflection.
+
+// Layout of a field map entry. The low bits hold the generated field number.
+// If the field's value or its elements must be converted, the high bits hold
+// one more than the index of the conversions in Decoder.conversions.
+const (
+	fieldNumBits = 16
+	fieldNumMask = 1<<fieldNumBits - 1
+)
+
+// A Conversion converts a numeric value from the kind it was encoded with to
+// the kind it is decoded as. The zero Conversion does nothing.
+type Conversion struct {
+	from, to reflect.Kind
+}
+
+// fieldConversions holds the conversions for the value of a struct field.
+type fieldConversions struct {
+	value     Conversion // of a numeric value
+	key, elem Conversion // of the keys and elements of a map, slice or array
+	err       error      // if the elements can't be converted
+}
+
+// addConversions adds conversions to fieldMap, which maps the encoded fields
+// of the struct with type number num to generated ones, for fields whose
+// values must be converted. The encoded fields have kinds encodedKinds and
+// element kinds encodedElemKinds, either of which may be nil.
+func (d *Decoder) addConversions(num int, fieldMap []int, encodedKinds []reflect.Kind, encodedElemKinds [][]reflect.Kind) {
+	tc := d.typeCodecs[num]
+	fk, ok := tc.(FieldKinder)
+	if !ok {
+		return
+	}
+	generatedKinds := fk.FieldKinds()
+	var generatedElemKinds [][]reflect.Kind
+	if fek, ok := tc.(FieldElemKinder); ok {
+		generatedElemKinds = fek.FieldElemKinds()
+	}
+	for i, f := range fieldMap {
+		if f < 0 || i >= len(encodedKinds) || f >= len(generatedKinds) {
+			continue
+		}
+		var fc fieldConversions
+		from, to := encodedKinds[i], generatedKinds[f]
+		if needsConversion(from, to) {
+			fc.value = Conversion{from, to}
+		}
+		if (from == to || isList(from) && isList(to)) && i < len(encodedElemKinds) && f < len(generatedElemKinds) {
+			var err error
+			fc.key, fc.elem, err = convertElems(to, encodedElemKinds[i], generatedElemKinds[f])
+			if err != nil {
+				fc.err = fmt.Errorf("%s.%s: %v", d.types[num], tc.Fields()[f], err)
+			}
+		}
+		if fc == (fieldConversions{}) {
+			continue
+		}
+		if f > fieldNumMask {
+			Failf("too many fields to convert field number %d", f)
+		}
+		d.conversions = append(d.conversions, fc)
+		fieldMap[i] = f | len(d.conversions)<<fieldNumBits
+	}
+}
+
+// convertElems returns the conversions for the keys and elements of a field of
+// kind k, whose element kinds, in the form that FieldElemKinder describes,
+// were encoded as from and are generated as to. Only the keys and elements of
+// a map, or the elements of a slice or array, are converted. Changes to other
+// element types must not need conversion.
+func convertElems(k reflect.Kind, from, to []reflect.Kind) (key, elem Conversion, err error) {
+	if len(from) == 0 || len(to) == 0 {
+		// Nothing is known about the elements.
+		return Conversion{}, Conversion{}, nil
+	}
+	convs, err := compareElems(k, from, to, k != reflect.Ptr)
+	switch {
+	case err != nil:
+		return Conversion{}, Conversion{}, err
+	case k == reflect.Map:
+		return convs[0], convs[1], nil
+	case isList(k):
+		return Conversion{}, convs[0], nil
+	default:
+		return Conversion{}, Conversion{}, nil
+	}
+}
+
+// compareElems compares the element kinds of a type of kind k, as encoded and
+// as generated. If convert is true, it returns the conversion for each of the
+// type's element types. Otherwise it requires that none needs one.
+func compareElems(k reflect.Kind, from, to []reflect.Kind, convert bool) ([]Conversion, error) {
+	if isList(k) && len(from) > 0 && len(to) > 0 && (from[0] == reflect.Uint8) != (to[0] == reflect.Uint8) {
+		// A list of uint8s is encoded as bytes, not as a list.
+		return nil, fmt.Errorf("cannot convert encoded %s elements to %s", from[0], to[0])
+	}
+	var convs []Conversion
+	for i := 0; i < elemArity(k); i++ {
+		fn, tn := kindsLen(from), kindsLen(to)
+		if fn < 0 || tn < 0 {
+			return nil, errors.New("bad element kinds")
+		}
+		c, err := compareKinds(from[:fn], to[:tn], convert)
+		if err != nil {
+			return nil, err
+		}
+		convs = append(convs, c)
+		from, to = from[fn:], to[tn:]
+	}
+	return convs, nil
+}
+
+// compareKinds compares the kinds of a single type and its elements, as
+// encoded and as generated. If the type must be converted, it returns the
+// conversion if convert is true, and fails otherwise.
+func compareKinds(from, to []reflect.Kind, convert bool) (Conversion, error) {
+	f, t := from[0], to[0]
+	switch {
+	case f == reflect.Invalid || t == reflect.Invalid:
+		// A type that recurs within itself. Its elements are unknown.
+		return Conversion{}, nil
+	case f == t || isList(f) && isList(t):
+		_, err := compareElems(t, from[1:], to[1:], false)
+		return Conversion{}, err
+	}
+	if ok, _ := CanConvertField(f, t); !ok {
+		return Conversion{}, fmt.Errorf("cannot convert encoded %s elements to %s", f, t)
+	}
+	if !needsConversion(f, t) {
+		return Conversion{}, nil
+	}
+	if !convert {
+		return Conversion{}, fmt.Errorf("cannot convert nested %s elements to %s", f, t)
+	}
+	return Conversion{f, t}, nil
+}
+
+// elemArity returns the number of element types of a type of kind k: one for a
+// slice, array or pointer, two for a map, and zero otherwise.
+func elemArity(k reflect.Kind) int {
+	switch k {
+	case reflect.Slice, reflect.Array, reflect.Ptr:
+		return 1
+	case reflect.Map:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// kindsLen returns the length of the prefix of kinds that describes a single
+// type: the type's kind followed by its element kinds. It returns -1 if kinds
+// is too short.
+func kindsLen(kinds []reflect.Kind) int {
+	if len(kinds) == 0 {
+		return -1
+	}
+	n := 1
+	for i := 0; i < elemArity(kinds[0]); i++ {
+		m := kindsLen(kinds[n:])
+		if m < 0 {
+			return -1
+		}
+		n += m
+	}
+	return n
+}
+
+func isList(k reflect.Kind) bool {
+	return k == reflect.Slice || k == reflect.Array
+}
+
+// Families of numeric kinds. Kinds in the same family are encoded the same
+// way. The one-byte kinds are each in their own family, because they are
+// encoded as raw bytes.
+const (
+	notNumeric = iota
+	int8Family
+	uint8Family
+	intFamily
+	uintFamily
+	floatFamily
+	complexFamily
+)
+
+func kindFamily(k reflect.Kind) int {
+	switch k {
+	case reflect.Int8:
+		return int8Family
+	case reflect.Uint8:
+		return uint8Family
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intFamily
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return uintFamily
+	case reflect.Float32, reflect.Float64:
+		return floatFamily
+	case reflect.Complex64, reflect.Complex128:
+		return complexFamily
+	default:
+		return notNumeric
+	}
+}
+
+// kindBits returns the size in bits of a numeric kind.
+func kindBits(k reflect.Kind) int {
+	switch k {
+	case reflect.Int8, reflect.Uint8:
+		return 8
+	case reflect.Int16, reflect.Uint16:
+		return 16
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 32
+	case reflect.Int, reflect.Uint, reflect.Uintptr:
+		return bits.UintSize
+	case reflect.Complex128:
+		return 128
+	default:
+		return 64
+	}
+}
+
+// needsConversion reports whether a value encoded with kind from must be
+// converted to decode it as kind to. That is the case for numeric kinds that
+// are encoded differently, and for narrowing conversions, which must be
+// checked. Non-numeric kinds are never converted.
+func needsConversion(from, to reflect.Kind) bool {
+	if from == to {
+		return false
+	}
+	ff, tf := kindFamily(from), kindFamily(to)
+	if ff == notNumeric || tf == notNumeric {
+		return false
+	}
+	return ff != tf || kindBits(to) < kindBits(from)
+}
+
+// CanConvertField reports whether a struct field encoded with kind from can be
+// decoded into a field of kind to, and if so, whether every encoded value can
+// be. For example, an int64 field can be decoded into an int32 field, but only
+// if the value fits.
+func CanConvertField(from, to reflect.Kind) (ok, exact bool) {
+	if from == to {
+		return true, true
+	}
+	ff, tf := kindFamily(from), kindFamily(to)
+	if ff == notNumeric || tf == notNumeric {
+		// Slices and arrays are encoded alike, but an array may be too short.
+		return isList(from) && isList(to), false
+	}
+	fbits, tbits := kindBits(from), kindBits(to)
+	switch tf {
+	case complexFamily:
+		tf = floatFamily
+		tbits /= 2
+		if ff == complexFamily {
+			return true, fbits/2 <= tbits
+		}
+	case int8Family:
+		tf = intFamily
+	case uint8Family:
+		tf = uintFamily
+	}
+	switch ff {
+	case int8Family, intFamily:
+		switch tf {
+		case intFamily:
+			return true, fbits <= tbits
+		case floatFamily:
+			return true, fbits <= mantissaBits(tbits)
+		}
+	case uint8Family, uintFamily:
+		switch tf {
+		case intFamily:
+			return true, fbits < tbits
+		case uintFamily:
+			return true, fbits <= tbits
+		case floatFamily:
+			return true, fbits <= mantissaBits(tbits)
+		}
+	case floatFamily:
+		return true, tf == floatFamily && fbits <= tbits
+	}
+	return true, false
+}
+
+// CanConvertElems reports whether the elements of a struct field of kind k,
+// whose element kinds were encoded as from, can be decoded into elements with
+// element kinds to, and if so, whether every encoded value can be. The element
+// kinds are those that FieldElemKinder reports.
+func CanConvertElems(k reflect.Kind, from, to []reflect.Kind) (ok, exact bool) {
+	key, elem, err := convertElems(k, from, to)
+	if err != nil {
+		return false, false
+	}
+	for _, c := range []Conversion{key, elem} {
+		if c != (Conversion{}) {
+			if _, e := CanConvertField(c.from, c.to); !e {
+				return true, false
+			}
+		}
+	}
+	return true, true
+}
+
+// mantissaBits returns the number of bits of integer precision of a
+// floating-point number of the given size.
+func mantissaBits(size int) int {
+	if size == 32 {
+		return 24
+	}
+	return 53
+}
+
+// A number is a decoded numeric value.
+type number struct {
+	family int // intFamily, uintFamily, floatFamily or complexFamily
+	i      int64
+	u      uint64
+	c      complex128 // for floats, the imaginary part is zero
+}
+
+func (n number) String() string {
+	switch n.family {
+	case intFamily:
+		return fmt.Sprint(n.i)
+	case uintFamily:
+		return fmt.Sprint(n.u)
+	case floatFamily:
+		return fmt.Sprint(real(n.c))
+	default:
+		return fmt.Sprint(n.c)
+	}
+}
+
+// takeConversion returns the pending conversion and clears it.
+func (d *Decoder) takeConversion() (from, to reflect.Kind) {
+	from, to = d.convFrom, d.convTo
+	d.convFrom, d.convTo = reflect.Invalid, reflect.Invalid
+	return from, to
+}
+
+// decodeNumber decodes a value that was encoded with the given kind.
+func (d *Decoder) decodeNumber(k reflect.Kind) number {
+	switch kindFamily(k) {
+	case int8Family:
+		return number{family: intFamily, i: int64(int8(d.readByte()))}
+	case uint8Family:
+		return number{family: uintFamily, u: uint64(d.readByte())}
+	case intFamily:
+		return number{family: intFamily, i: d.DecodeInt()}
+	case uintFamily:
+		return number{family: uintFamily, u: d.DecodeUint()}
+	case floatFamily:
+		return number{family: floatFamily, c: complex(d.DecodeFloat(), 0)}
+	case complexFamily:
+		return number{family: complexFamily, c: d.DecodeComplex()}
+	default:
+		Failf("cannot convert encoded %s", k)
+		return number{}
+	}
+}
+
+func (d *Decoder) convertInt() int64 {
+	from, to := d.takeConversion()
+	n := d.decodeNumber(from)
+	i, ok := n.toInt(kindBits(to))
+	if !ok {
+		conversionFailed(n, from, to)
+	}
+	return i
+}
+
+func (d *Decoder) convertUint() uint64 {
+	from, to := d.takeConversion()
+	n := d.decodeNumber(from)
+	u, ok := n.toUint(kindBits(to))
+	if !ok {
+		conversionFailed(n, from, to)
+	}
+	return u
+}
+
+func (d *Decoder) convertByte() byte {
+	from, to := d.takeConversion()
+	n := d.decodeNumber(from)
+	if to == reflect.Int8 {
+		i, ok := n.toInt(8)
+		if !ok {
+			conversionFailed(n, from, to)
+		}
+		return byte(i)
+	}
+	u, ok := n.toUint(8)
+	if !ok {
+		conversionFailed(n, from, to)
+	}
+	return byte(u)
+}
+
+func (d *Decoder) convertFloat() float64 {
+	from, to := d.takeConversion()
+	n := d.decodeNumber(from)
+	f, ok := n.toFloat(kindBits(to))
+	if !ok {
+		conversionFailed(n, from, to)
+	}
+	return f
+}
+
+func (d *Decoder) convertComplex() complex128 {
+	from, to := d.takeConversion()
+	n := d.decodeNumber(from)
+	c, ok := n.toComplex(kindBits(to))
+	if !ok {
+		conversionFailed(n, from, to)
+	}
+	return c
+}
+
+func conversionFailed(n number, from, to reflect.Kind) {
+	Failf("cannot convert encoded %s %s to %s without loss", from, n, to)
+}
+
+// toInt converts n to a signed integer of the given size, reporting whether
+// the conversion is exact.
+func (n number) toInt(size int) (int64, bool) {
+	var i int64
+	switch n.family {
+	case intFamily:
+		i = n.i
+	case uintFamily:
+		if n.u > math.MaxInt64 {
+			return 0, false
+		}
+		i = int64(n.u)
+	default:
+		f := real(n.c)
+		if imag(n.c) != 0 || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		i = int64(f)
+	}
+	if size < 64 && (i < -1<<(size-1) || i >= 1<<(size-1)) {
+		return 0, false
+	}
+	return i, true
+}
+
+// toUint converts n to an unsigned integer of the given size, reporting whether
+// the conversion is exact.
+func (n number) toUint(size int) (uint64, bool) {
+	var u uint64
+	switch n.family {
+	case intFamily:
+		if n.i < 0 {
+			return 0, false
+		}
+		u = uint64(n.i)
+	case uintFamily:
+		u = n.u
+	default:
+		f := real(n.c)
+		if imag(n.c) != 0 || f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+			return 0, false
+		}
+		u = uint64(f)
+	}
+	if size < 64 && u >= 1<<size {
+		return 0, false
+	}
+	return u, true
+}
+
+// toFloat converts n to a floating-point number of the given size. Integers
+// must be represented exactly. Floating-point values may be rounded, but must
+// not overflow.
+func (n number) toFloat(size int) (float64, bool) {
+	switch n.family {
+	case intFamily:
+		if size == 32 {
+			f := float32(n.i)
+			return float64(f), float64(f) < math.MaxInt64 && int64(f) == n.i
+		}
+		f := float64(n.i)
+		return f, f < math.MaxInt64 && int64(f) == n.i
+	case uintFamily:
+		if size == 32 {
+			f := float32(n.u)
+			return float64(f), float64(f) < math.MaxUint64 && uint64(f) == n.u
+		}
+		f := float64(n.u)
+		return f, f < math.MaxUint64 && uint64(f) == n.u
+	default:
+		if imag(n.c) != 0 {
+			return 0, false
+		}
+		f := real(n.c)
+		return f, size == 64 || fitsFloat32(f)
+	}
+}
+
+// toComplex converts n to a complex number of the given size.
+func (n number) toComplex(size int) (complex128, bool) {
+	if n.family != complexFamily {
+		f, ok := n.toFloat(size / 2)
+		return complex(f, 0), ok
+	}
+	c := n.c
+	return c, size == 128 || (fitsFloat32(real(c)) && fitsFloat32(imag(c)))
+}
+
+// fitsFloat32 reports whether f can be converted to a float32 without
+// overflowing.
+func fitsFloat32(f float64) bool {
+	return math.IsInf(f, 0) || math.IsNaN(f) || math.Abs(f) <= math.MaxFloat32
+}
+
+// convertValue converts v to a value of type t. It allows
+//   - conversions between numeric types that don't lose information,
+//   - conversions between types with the same kind that Go allows, like from
+//     a defined type to its underlying type, and
+//   - conversions between slices and arrays whose elements can be converted,
+//     provided that no elements are lost.
+func convertValue(v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	vt := v.Type()
+	switch {
+	case vt.AssignableTo(t):
+		return v, nil
+
+	case kindFamily(vt.Kind()) != notNumeric && kindFamily(t.Kind()) != notNumeric:
+		n := numberFromValue(v)
+		size := kindBits(t.Kind())
+		var (
+			r  interface{}
+			ok bool
+		)
+		switch kindFamily(t.Kind()) {
+		case intFamily, int8Family:
+			r, ok = n.toInt(size)
+		case uintFamily, uint8Family:
+			r, ok = n.toUint(size)
+		case floatFamily:
+			r, ok = n.toFloat(size)
+		default:
+			r, ok = n.toComplex(size)
+		}
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("cannot convert %s to %s without loss", n, t)
+		}
+		return reflect.ValueOf(r).Convert(t), nil
+
+	case vt.Kind() == t.Kind() && vt.ConvertibleTo(t):
+		return v.Convert(t), nil
+
+	case (vt.Kind() == reflect.Slice || vt.Kind() == reflect.Array) &&
+		(t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		n := v.Len()
+		var r reflect.Value
+		if t.Kind() == reflect.Slice {
+			if vt.Kind() == reflect.Slice && v.IsNil() {
+				return reflect.Zero(t), nil
+			}
+			r = reflect.MakeSlice(t, n, n)
+		} else {
+			if n > t.Len() {
+				return reflect.Value{}, fmt.Errorf("length %d is too long for %s", n, t)
+			}
+			r = reflect.New(t).Elem()
+		}
+		for i := 0; i < n; i++ {
+			e, err := convertValue(v.Index(i), t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			r.Index(i).Set(e)
+		}
+		return r, nil
+
+	default:
+		return reflect.Value{}, errors.New("no conversion")
+	}
+}
+
+// numberFromValue returns the number held by v, which must be numeric.
+func numberFromValue(v reflect.Value) number {
+	switch kindFamily(v.Kind()) {
+	case intFamily, int8Family:
+		return number{family: intFamily, i: v.Int()}
+	case uintFamily, uint8Family:
+		return number{family: uintFamily, u: v.Uint()}
+	case floatFamily:
+		return number{family: floatFamily, c: complex(v.Float(), 0)}
+	default:
+		return number{family: complexFamily, c: v.Complex()}
+	}
+}
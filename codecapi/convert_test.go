@@ -0,0 +1,274 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codecapi
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNeedsConversion(t *testing.T) {
+	for _, test := range []struct {
+		from, to reflect.Kind
+		want     bool
+	}{
+		{reflect.Int, reflect.Int, false},
+		{reflect.Int32, reflect.Int64, false},
+		{reflect.Int64, reflect.Int32, true},
+		{reflect.Int8, reflect.Int16, true}, // int8 is encoded as a byte
+		{reflect.Int8, reflect.Uint8, true},
+		{reflect.Uint16, reflect.Uint64, false},
+		{reflect.Int, reflect.Uint, true},
+		{reflect.Float32, reflect.Float64, false},
+		{reflect.Float64, reflect.Float32, true},
+		{reflect.Int, reflect.Float64, true},
+		{reflect.Float64, reflect.Complex128, true},
+		{reflect.Slice, reflect.Array, false},
+		{reflect.String, reflect.Int, false},
+	} {
+		if got := needsConversion(test.from, test.to); got != test.want {
+			t.Errorf("%s => %s: got %t, want %t", test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func TestCanConvertField(t *testing.T) {
+	for _, test := range []struct {
+		from, to  reflect.Kind
+		ok, exact bool
+	}{
+		{reflect.Int, reflect.Int, true, true},
+		{reflect.Int32, reflect.Int64, true, true},
+		{reflect.Int64, reflect.Int32, true, false},
+		{reflect.Int8, reflect.Int16, true, true},
+		{reflect.Int8, reflect.Uint8, true, false},
+		{reflect.Uint8, reflect.Int16, true, true},
+		{reflect.Uint16, reflect.Int16, true, false},
+		{reflect.Int16, reflect.Float32, true, true},
+		{reflect.Int64, reflect.Float64, true, false},
+		{reflect.Float32, reflect.Float64, true, true},
+		{reflect.Float64, reflect.Int64, true, false},
+		{reflect.Float64, reflect.Complex128, true, true},
+		{reflect.Float64, reflect.Complex64, true, false},
+		{reflect.Complex64, reflect.Complex128, true, true},
+		{reflect.Complex64, reflect.Float64, true, false},
+		{reflect.Slice, reflect.Array, true, false},
+		{reflect.String, reflect.Int, false, false},
+		{reflect.Ptr, reflect.Struct, false, false},
+	} {
+		ok, exact := CanConvertField(test.from, test.to)
+		if ok != test.ok || exact != test.exact {
+			t.Errorf("%s => %s: got (%t, %t), want (%t, %t)", test.from, test.to, ok, exact, test.ok, test.exact)
+		}
+	}
+}
+
+func TestConvertElems(t *testing.T) {
+	const (
+		i8   = reflect.Int8
+		i32  = reflect.Int32
+		i64  = reflect.Int64
+		u8   = reflect.Uint8
+		f64  = reflect.Float64
+		str  = reflect.String
+		sl   = reflect.Slice
+		arr  = reflect.Array
+		mp   = reflect.Map
+		ptr  = reflect.Ptr
+		strc = reflect.Struct
+	)
+	type ks = []reflect.Kind
+	for _, test := range []struct {
+		kind      reflect.Kind
+		from, to  ks
+		key, elem Conversion
+		wantErr   string
+	}{
+		{kind: sl, from: ks{i64}, to: ks{i64}},
+		{kind: sl, from: ks{i64}, to: ks{i32}, elem: Conversion{i64, i32}}, // narrowing
+		{kind: sl, from: ks{i32}, to: ks{i64}},                             // widening
+		{kind: arr, from: ks{i64}, to: ks{i8}, elem: Conversion{i64, i8}},
+		{kind: sl, from: ks{strc}, to: ks{strc}},
+		{kind: mp, from: ks{str, i64}, to: ks{str, f64}, elem: Conversion{i64, f64}},
+		{kind: mp, from: ks{i64, str}, to: ks{i32, str}, key: Conversion{i64, i32}},
+		{kind: mp, from: ks{str, sl, i32}, to: ks{str, arr, i64}},
+		{kind: sl, from: ks{i64}, to: ks{str}, wantErr: "cannot convert encoded int64 elements to string"},
+		{kind: sl, from: ks{u8}, to: ks{i32}, wantErr: "cannot convert encoded uint8 elements to int32"},
+		{kind: sl, from: ks{sl, i64}, to: ks{sl, i32}, wantErr: "cannot convert nested int64 elements to int32"},
+		{kind: mp, from: ks{str, sl, i64}, to: ks{str, sl, i32}, wantErr: "cannot convert nested"},
+		{kind: ptr, from: ks{i64}, to: ks{i32}, wantErr: "cannot convert nested"},
+		{kind: sl, from: ks{reflect.Invalid}, to: ks{sl, i64}},
+		{kind: sl, from: nil, to: ks{i32}},
+		{kind: sl, from: ks{sl}, to: ks{sl, i32}, wantErr: "bad element kinds"},
+	} {
+		key, elem, err := convertElems(test.kind, test.from, test.to)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s %v => %v: got error %v, want %q", test.kind, test.from, test.to, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %v => %v: %v", test.kind, test.from, test.to, err)
+		} else if key != test.key || elem != test.elem {
+			t.Errorf("%s %v => %v: got (%v, %v), want (%v, %v)", test.kind, test.from, test.to, key, elem, test.key, test.elem)
+		}
+	}
+}
+
+func TestCanConvertElems(t *testing.T) {
+	type ks = []reflect.Kind
+	for _, test := range []struct {
+		kind      reflect.Kind
+		from, to  ks
+		ok, exact bool
+	}{
+		{reflect.Slice, ks{reflect.Int32}, ks{reflect.Int64}, true, true},
+		{reflect.Slice, ks{reflect.Int64}, ks{reflect.Int32}, true, false},
+		{reflect.Map, ks{reflect.String, reflect.Int}, ks{reflect.String, reflect.Float32}, true, false},
+		{reflect.Map, ks{reflect.String, reflect.Int}, ks{reflect.String, reflect.String}, false, false},
+		{reflect.Slice, ks{reflect.Slice, reflect.Int64}, ks{reflect.Slice, reflect.Int32}, false, false},
+	} {
+		ok, exact := CanConvertElems(test.kind, test.from, test.to)
+		if ok != test.ok || exact != test.exact {
+			t.Errorf("%s %v => %v: got (%t, %t), want (%t, %t)", test.kind, test.from, test.to, ok, exact, test.ok, test.exact)
+		}
+	}
+}
+
+func TestConvertNumber(t *testing.T) {
+	// encode encodes x without type information, as a struct field would be.
+	encode := func(x interface{}) []byte {
+		e := NewEncoder(nil, EncodeOptions{})
+		switch x := x.(type) {
+		case int8:
+			e.EncodeByte(byte(x))
+		case uint8:
+			e.EncodeByte(x)
+		case int, int16, int32, int64:
+			e.EncodeInt(reflect.ValueOf(x).Int())
+		case uint, uint16, uint32, uint64:
+			e.EncodeUint(reflect.ValueOf(x).Uint())
+		case float32:
+			e.EncodeFloat(float64(x))
+		case float64:
+			e.EncodeFloat(x)
+		case complex128:
+			e.EncodeComplex(x)
+		default:
+			t.Fatalf("bad type %T", x)
+		}
+		return e.buf
+	}
+
+	for _, test := range []struct {
+		in   interface{}
+		to   reflect.Kind
+		want interface{} // nil means error
+	}{
+		{int64(-3), reflect.Int8, byte(0xfd)},
+		{int64(300), reflect.Int8, nil},
+		{int8(-1), reflect.Uint8, nil},
+		{int8(-1), reflect.Int64, int64(-1)},
+		{uint8(200), reflect.Int16, int64(200)},
+		{int64(1 << 40), reflect.Int32, nil},
+		{-1, reflect.Uint, nil},
+		{7, reflect.Uint32, uint64(7)},
+		{uint64(math.MaxUint64), reflect.Int64, nil},
+		{3.0, reflect.Int, int64(3)},
+		{3.5, reflect.Int, nil},
+		{1e20, reflect.Int64, nil},
+		{2.0, reflect.Uint16, uint64(2)},
+		{-2.0, reflect.Uint16, nil},
+		{1 << 53, reflect.Float64, float64(1 << 53)},
+		{1<<53 + 1, reflect.Float64, nil},
+		{1 << 24, reflect.Float32, float64(1 << 24)},
+		{1<<24 + 1, reflect.Float32, nil},
+		{0.1, reflect.Float32, 0.1}, // the caller rounds
+		{1e300, reflect.Float32, nil},
+		{math.Inf(1), reflect.Float32, math.Inf(1)},
+		{complex(1, 0), reflect.Float64, 1.0},
+		{complex(1, 1), reflect.Float64, nil},
+		{2, reflect.Complex128, complex(2, 0)},
+		{1.5, reflect.Complex64, complex(1.5, 0)},
+		{complex(1e300, 0), reflect.Complex64, nil},
+	} {
+		from := reflect.TypeOf(test.in).Kind()
+		d := NewDecoder(bytes.NewReader(nil), DecodeOptions{})
+		d.buf = encode(test.in)
+		d.convFrom, d.convTo = from, test.to
+		var got interface{}
+		err := func() (err error) {
+			defer handlePanic(&err)
+			switch kindFamily(test.to) {
+			case int8Family, uint8Family:
+				got = d.DecodeByte()
+			case intFamily:
+				got = d.DecodeInt()
+			case uintFamily:
+				got = d.DecodeUint()
+			case floatFamily:
+				got = d.DecodeFloat()
+			default:
+				got = d.DecodeComplex()
+			}
+			return nil
+		}()
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%v (%[1]T) => %s: got %v, want error", test.in, test.to, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v (%[1]T) => %s: %v", test.in, test.to, err)
+		} else if got != test.want {
+			t.Errorf("%v (%[1]T) => %s: got %v (%[3]T), want %v (%[4]T)", test.in, test.to, got, test.want)
+		}
+		if d.convTo != reflect.Invalid {
+			t.Errorf("%v (%[1]T) => %s: conversion not cleared", test.in, test.to)
+		}
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	type myInt int
+	type myInts []myInt
+	for _, test := range []struct {
+		in      interface{}
+		want    interface{} // if wantErr, only the type matters
+		wantErr bool
+	}{
+		{1, myInt(1), false},
+		{myInt(1), 1, false},
+		{int64(5), int8(5), false},
+		{int64(500), int8(0), true},
+		{2.0, uint(2), false},
+		{[]int{1, 2}, myInts{1, 2}, false},
+		{[]int{1, 2}, [3]int64{1, 2, 0}, false},
+		{[]int{1, 2, 3}, [2]int{}, true},
+		{[2]int{1, 2}, []float64{1, 2}, false},
+		{[]int(nil), []int64(nil), false},
+		{"x", 0, true},
+	} {
+		got, err := convertValue(reflect.ValueOf(test.in), reflect.TypeOf(test.want))
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%v (%[1]T): got %v, want error", test.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v (%[1]T): %v", test.in, err)
+		} else if !cmp.Equal(got.Interface(), test.want) {
+			t.Errorf("%v (%[1]T): got %v (%[2]T), want %v (%[3]T)", test.in, got.Interface(), test.want)
+		}
+	}
+}
@@ -0,0 +1,656 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codecapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file converts frames to JSON and back, so that encoded values can be
+// read and edited by hand.
+//
+// The JSON for a frame is an object holding the frame's metadata and its value:
+//
+//	{
+//	  "types": ["example.com/p.T", "string"],
+//	  "fields": {"example.com/p.T": [{"name": "A", "kind": "int"},
+//	                                 {"name": "B", "kind": "slice", "elemKinds": ["int"]}, ...]},
+//	  "custom": {"example.com/p.Money": "money/v1"},
+//	  "fingerprint": "...",
+//	  "value": {"$type": "example.com/p.T", "$value": {"A": 1}}
+//	}
+//
+// Like the parser in raw.go, the conversion follows the types named in the
+// metadata. Where the type of a value is known, the value is represented
+// naturally: numbers, booleans and strings as themselves, and structs as
+// objects keyed by field name. Elsewhere the JSON follows the encoding: unsigned
+// integers are numbers, byte sequences are strings, lists are arrays and
+// structs are objects keyed by field number. Maps are arrays of alternating
+// keys and values. Objects whose keys begin with "$" stand for the parts of the
+// encoding that have no natural JSON form:
+//
+//	{"$type": name, "$value": v}  a non-nil interface value (nil is null)
+//	{"$ptr": v}                    a pointer, where one isn't expected
+//	{"$id": n, "$ptr": v}          a pointer that is referred to later
+//	{"$ref": n}                    a reference to the pointer with "$id" n
+//	{"$bytes": base64}             bytes that aren't valid UTF-8
+//	{"$nil": true}                 a nil where an interface value is expected
+//
+// A float that is not a number or is infinite is written as the string "NaN",
+// "+Inf" or "-Inf".
+
+// FrameToJSON returns the JSON representation of a frame read by
+// Decoder.ReadFrame. It does not use any registered types.
+func FrameToJSON(f Frame) ([]byte, error) {
+	md, v, err := ParseFrame(f)
+	if err != nil {
+		return nil, err
+	}
+	w := &jsonWriter{md: md, ids: map[int]int{}}
+	b := &w.buf
+	b.WriteString(`{"types":`)
+	w.marshal(md.TypeNames)
+	if len(md.Fields) > 0 {
+		b.WriteString(`,"fields":{`)
+		first := true
+		for num, name := range md.TypeNames {
+			fields, ok := md.Fields[num]
+			if !ok {
+				continue
+			}
+			if !first {
+				b.WriteByte(',')
+			}
+			first = false
+			w.marshal(name)
+			b.WriteString(":[")
+			kinds := md.FieldKinds[num]
+			elemKinds := md.FieldElemKinds[num]
+			for i, f := range fields {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(`{"name":`)
+				w.marshal(f)
+				if i < len(kinds) {
+					b.WriteString(`,"kind":`)
+					w.marshal(kinds[i].String())
+				}
+				if i < len(elemKinds) && len(elemKinds[i]) > 0 {
+					names := make([]string, len(elemKinds[i]))
+					for j, k := range elemKinds[i] {
+						names[j] = k.String()
+					}
+					b.WriteString(`,"elemKinds":`)
+					w.marshal(names)
+				}
+				b.WriteByte('}')
+			}
+			b.WriteByte(']')
+		}
+		b.WriteByte('}')
+	}
+	if len(md.CustomNames) > 0 {
+		b.WriteString(`,"custom":{`)
+		first := true
+		for num, name := range md.TypeNames {
+			if c, ok := md.CustomNames[num]; ok {
+				if !first {
+					b.WriteByte(',')
+				}
+				first = false
+				w.marshal(name)
+				b.WriteByte(':')
+				w.marshal(c)
+			}
+		}
+		b.WriteByte('}')
+	}
+	if md.Fingerprint != "" {
+		b.WriteString(`,"fingerprint":`)
+		w.marshal(md.Fingerprint)
+	}
+	b.WriteString(`,"value":`)
+	w.value(v)
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+type jsonWriter struct {
+	md  *Metadata
+	buf bytes.Buffer
+	ids map[int]int // from the offset of a shared pointer to its "$id"
+}
+
+// marshal writes x as JSON, without escaping HTML characters.
+func (w *jsonWriter) marshal(x interface{}) {
+	enc := json.NewEncoder(&w.buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(x); err != nil {
+		// Only strings and slices of them are marshaled.
+		panic(err)
+	}
+	w.buf.Truncate(w.buf.Len() - 1) // remove the newline
+}
+
+// value writes the JSON for v.
+func (w *jsonWriter) value(v *RawValue) {
+	b := &w.buf
+	switch s := v.Scalar().(type) {
+	case bool:
+		b.WriteString(strconv.FormatBool(s))
+		return
+	case int64:
+		b.WriteString(strconv.FormatInt(s, 10))
+		return
+	case uint64:
+		b.WriteString(strconv.FormatUint(s, 10))
+		return
+	case float64:
+		w.float(s)
+		return
+	case string:
+		w.bytes(v.Bytes, false)
+		return
+	}
+	// A complex number is a list of two floats, like any other.
+	switch v.Kind {
+	case RawUint, RawByte:
+		b.WriteString(strconv.FormatUint(v.Uint, 10))
+	case RawBytes:
+		w.bytes(v.Bytes, isFloatKind(v.GoKind))
+	case RawNil:
+		if v.GoKind == reflect.Interface {
+			b.WriteString(`{"$nil":true}`)
+		} else {
+			b.WriteString("null")
+		}
+	case RawList:
+		b.WriteByte('[')
+		for i, e := range v.Elems {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			w.value(e)
+		}
+		b.WriteByte(']')
+	case RawStruct:
+		b.WriteByte('{')
+		for i, f := range v.Fields {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if f.Name != "" {
+				w.marshal(f.Name)
+			} else {
+				fmt.Fprintf(b, `"%d"`, f.Num)
+			}
+			b.WriteByte(':')
+			w.value(f.Value)
+		}
+		b.WriteByte('}')
+	case RawPtr:
+		if implicitPtr(v) {
+			w.value(v.Elem)
+			return
+		}
+		b.WriteByte('{')
+		if v.Shared {
+			id := len(w.ids) + 1
+			w.ids[v.Offset] = id
+			fmt.Fprintf(b, `"$id":%d,`, id)
+		}
+		b.WriteString(`"$ptr":`)
+		w.value(v.Elem)
+		b.WriteByte('}')
+	case RawRef:
+		fmt.Fprintf(b, `{"$ref":%d}`, w.ids[v.Target])
+	case RawAny:
+		if v.Elem == nil {
+			b.WriteString("null")
+			return
+		}
+		b.WriteString(`{"$type":`)
+		w.marshal(w.md.TypeNames[v.Uint])
+		b.WriteString(`,"$value":`)
+		w.value(v.Elem)
+		b.WriteByte('}')
+	}
+}
+
+// implicitPtr reports whether the pointer v can be written as the value it
+// points to: whether a pointer is expected, and the JSON for the value can't be
+// mistaken for a nil pointer or another pointer.
+func implicitPtr(v *RawValue) bool {
+	if v.GoKind != reflect.Ptr || v.Shared {
+		return false
+	}
+	switch e := v.Elem; e.Kind {
+	case RawNil, RawRef:
+		return false
+	case RawAny:
+		return e.Elem != nil
+	case RawPtr:
+		return implicitPtr(e)
+	default:
+		return true
+	}
+}
+
+// float writes f, or a string for a NaN or infinity.
+func (w *jsonWriter) float(f float64) {
+	switch {
+	case math.IsNaN(f):
+		w.buf.WriteString(`"NaN"`)
+	case math.IsInf(f, 1):
+		w.buf.WriteString(`"+Inf"`)
+	case math.IsInf(f, -1):
+		w.buf.WriteString(`"-Inf"`)
+	default:
+		w.buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+}
+
+// bytes writes b as a string, or as base64 if it isn't valid UTF-8 or if
+// asBase64 is true.
+func (w *jsonWriter) bytes(b []byte, asBase64 bool) {
+	if asBase64 || !utf8.Valid(b) {
+		w.buf.WriteString(`{"$bytes":"`)
+		w.buf.WriteString(base64.StdEncoding.EncodeToString(b))
+		w.buf.WriteString(`"}`)
+		return
+	}
+	w.marshal(string(b))
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// FrameFromJSON converts JSON in the form produced by FrameToJSON, possibly
+// edited, back to a frame. The type names and field lists of the JSON's
+// metadata determine how its value is encoded, as they do for FrameToJSON.
+func FrameFromJSON(data []byte) (f Frame, err error) {
+	defer handlePanic(&err)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	top, ok := readJSON(dec).(jsonObject)
+	if !ok {
+		Failf("frame is not a JSON object")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		Failf("data after frame")
+	}
+	md := &Metadata{
+		Fields:         map[int][]string{},
+		FieldKinds:     map[int][]reflect.Kind{},
+		FieldElemKinds: map[int][][]reflect.Kind{},
+		CustomNames:    map[int]string{},
+	}
+	r := &jsonReader{
+		p:   &rawParser{md: md, typeNums: map[string]int{}},
+		e:   NewEncoder(nil, EncodeOptions{}),
+		ids: map[int]int{},
+	}
+	types, _ := top.get("types").([]interface{})
+	for _, t := range types {
+		name, ok := t.(string)
+		if !ok {
+			Failf("types: %v is not a string", t)
+		}
+		r.p.typeNums[name] = len(md.TypeNames)
+		md.TypeNames = append(md.TypeNames, name)
+	}
+	fields, _ := top.get("fields").(jsonObject)
+	for _, m := range fields {
+		num := r.typeNum(m.key)
+		list, ok := m.value.([]interface{})
+		if !ok {
+			Failf("fields of %s: not a list", m.key)
+		}
+		names := []string{}
+		var (
+			kinds     []reflect.Kind
+			elemKinds [][]reflect.Kind
+			hasElems  bool
+		)
+		for _, f := range list {
+			o, _ := f.(jsonObject)
+			name, ok := o.get("name").(string)
+			if !ok {
+				Failf("fields of %s: missing name", m.key)
+			}
+			names = append(names, name)
+			if k, ok := o.get("kind").(string); ok {
+				kind, ok := KindByName(k)
+				if !ok {
+					Failf("fields of %s: unknown kind %q", m.key, k)
+				}
+				kinds = append(kinds, kind)
+			}
+			var eks []reflect.Kind
+			if list, ok := o.get("elemKinds").([]interface{}); ok {
+				for _, e := range list {
+					k, _ := e.(string)
+					kind, ok := KindByName(k)
+					if !ok {
+						Failf("fields of %s: unknown element kind %v", m.key, e)
+					}
+					eks = append(eks, kind)
+				}
+				hasElems = true
+			}
+			elemKinds = append(elemKinds, eks)
+		}
+		md.Fields[num] = names
+		if kinds != nil {
+			if len(kinds) != len(names) {
+				Failf("fields of %s: some fields lack kinds", m.key)
+			}
+			md.FieldKinds[num] = kinds
+		}
+		if hasElems {
+			md.FieldElemKinds[num] = elemKinds
+		}
+	}
+	custom, _ := top.get("custom").(jsonObject)
+	for _, m := range custom {
+		name, ok := m.value.(string)
+		if !ok {
+			Failf("custom codec of %s: not a string", m.key)
+		}
+		md.CustomNames[r.typeNum(m.key)] = name
+	}
+	md.Fingerprint, _ = top.get("fingerprint").(string)
+
+	// Encode the value first, since the encoder's buffer holds it during
+	// encoding, then prepend the metadata.
+	r.value(top.get("value"), rawHint{name: "interface{}", kind: reflect.Interface})
+	value := r.e.buf
+	r.e.buf = nil
+	r.e.encodeMetadata(md)
+	return Frame{Version: 2, Data: append(r.e.buf, value...)}, nil
+}
+
+// A jsonObject is a JSON object, with its members in order.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+// get returns the value of the member with the given key, or nil.
+func (o jsonObject) get(key string) interface{} {
+	for _, m := range o {
+		if m.key == key {
+			return m.value
+		}
+	}
+	return nil
+}
+
+// readJSON reads a JSON value from dec. Objects are read as jsonObjects,
+// arrays as []interface{} and numbers as json.Numbers.
+func readJSON(dec *json.Decoder) interface{} {
+	tok, err := dec.Token()
+	if err != nil {
+		Fail(err)
+	}
+	switch tok {
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			a = append(a, readJSON(dec))
+		}
+		dec.Token() // ']'
+		return a
+	case json.Delim('{'):
+		o := jsonObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				Fail(err)
+			}
+			o = append(o, jsonMember{key.(string), readJSON(dec)})
+		}
+		dec.Token() // '}'
+		return o
+	default:
+		return tok
+	}
+}
+
+type jsonReader struct {
+	p   *rawParser // for its metadata and hintForName
+	e   *Encoder
+	ids map[int]int // from "$id" to the offset of the pointer
+}
+
+func (r *jsonReader) typeNum(name string) int {
+	num, ok := r.p.typeNums[name]
+	if !ok {
+		Failf("type %q is not in the list of types", name)
+	}
+	return num
+}
+
+// value encodes x, the JSON for a value whose type is described by h. It
+// follows the same hints that rawParser.parse does.
+func (r *jsonReader) value(x interface{}, h rawHint) {
+	e := r.e
+	o, _ := x.(jsonObject)
+	special := len(o) > 0 && strings.HasPrefix(o[0].key, "$")
+	if h.kind == reflect.Ptr && x != nil && !(special && isPtrKey(o[0].key)) {
+		// A pointer written as the value it points to.
+		e.writeByte(ptrCode)
+		r.value(x, r.elemHint(h))
+		return
+	}
+	if special {
+		r.special(o, h)
+		return
+	}
+	switch x := x.(type) {
+	case nil:
+		if h.kind == reflect.Interface {
+			e.writeByte(0) // a nil interface value
+		} else {
+			e.EncodeNil()
+		}
+	case bool:
+		e.EncodeBool(x)
+	case json.Number:
+		r.number(x, h)
+	case string:
+		if isFloatKind(h.kind) {
+			r.number(json.Number(x), h)
+		} else {
+			e.EncodeString(x)
+		}
+	case []interface{}:
+		var key, elem rawHint
+		switch h.kind {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			kn, en := elemNames(h.name)
+			if kn != "" {
+				key = r.p.hintForName(kn)
+			}
+			if en != "" {
+				elem = r.p.hintForName(en)
+			}
+		case reflect.Complex64, reflect.Complex128:
+			elem = rawHint{kind: reflect.Float64}
+		}
+		e.StartList(len(x))
+		for i, v := range x {
+			eh := elem
+			if h.kind == reflect.Map && i%2 == 0 {
+				eh = key
+			}
+			r.value(v, eh)
+		}
+	case jsonObject:
+		var (
+			names []string
+			kinds []reflect.Kind
+		)
+		if h.kind == reflect.Struct {
+			num := r.p.typeNums[h.name]
+			names = r.p.md.Fields[num]
+			kinds = r.p.md.FieldKinds[num]
+		}
+		e.StartStruct()
+		for _, m := range x {
+			n := fieldNum(m.key, names)
+			if n < 0 {
+				Failf("%s has no field %q", h.name, m.key)
+			}
+			var fh rawHint
+			if n < len(kinds) {
+				fh.kind = kinds[n]
+			}
+			e.EncodeUint(uint64(n))
+			r.value(m.value, fh)
+		}
+		e.EndStruct()
+	}
+}
+
+// fieldNum returns the number of the field with the given key, which is
+// either one of names or a number, or -1 if there is none.
+func fieldNum(key string, names []string) int {
+	for i, n := range names {
+		if n == key {
+			return i
+		}
+	}
+	n, err := strconv.Atoi(key)
+	if err != nil || n < 0 || (names != nil && n >= len(names)) {
+		return -1
+	}
+	return n
+}
+
+func isPtrKey(key string) bool {
+	return key == "$ptr" || key == "$id" || key == "$ref"
+}
+
+// elemHint returns the hint for the value that a pointer of type h points to.
+func (r *jsonReader) elemHint(h rawHint) rawHint {
+	if h.kind == reflect.Ptr {
+		if _, en := elemNames(h.name); en != "" {
+			return r.p.hintForName(en)
+		}
+	}
+	return rawHint{}
+}
+
+// special encodes an object whose keys begin with "$".
+func (r *jsonReader) special(o jsonObject, h rawHint) {
+	e := r.e
+	switch o[0].key {
+	case "$type", "$value":
+		name, ok := o.get("$type").(string)
+		if !ok {
+			Failf("$type is not a string")
+		}
+		e.StartList(2)
+		e.EncodeUint(uint64(r.typeNum(name)))
+		r.value(o.get("$value"), r.p.hintForName(name))
+	case "$ptr", "$id":
+		if id := o.get("$id"); id != nil {
+			n, err := strconv.Atoi(fmt.Sprint(id))
+			if err != nil {
+				Failf("bad $id %v", id)
+			}
+			if _, ok := r.ids[n]; ok {
+				Failf("duplicate $id %d", n)
+			}
+			r.ids[n] = len(e.buf)
+		}
+		e.writeByte(ptrCode)
+		r.value(o.get("$ptr"), r.elemHint(h))
+	case "$ref":
+		n, err := strconv.Atoi(fmt.Sprint(o.get("$ref")))
+		if err != nil {
+			Failf("bad $ref %v", o.get("$ref"))
+		}
+		u, ok := r.ids[n]
+		if !ok {
+			Failf("$ref %d does not refer to an earlier $id", n)
+		}
+		e.writeByte(refCode)
+		e.EncodeUint(uint64(len(e.buf) - u))
+		e.buf[u] = refPtrCode
+	case "$bytes":
+		s, _ := o.get("$bytes").(string)
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			Failf("$bytes: %v", err)
+		}
+		e.EncodeBytes(b)
+	case "$nil":
+		e.EncodeNil()
+	default:
+		Failf("unknown key %q", o[0].key)
+	}
+}
+
+// number encodes a JSON number, or one of the strings for a special float.
+func (r *jsonReader) number(n json.Number, h rawHint) {
+	e := r.e
+	var err error
+	switch k := h.kind; {
+	case k == reflect.Int8:
+		var i int64
+		if i, err = strconv.ParseInt(string(n), 10, 8); err == nil {
+			e.EncodeByte(byte(i))
+		}
+	case k == reflect.Uint8:
+		var u uint64
+		if u, err = strconv.ParseUint(string(n), 10, 8); err == nil {
+			e.EncodeByte(byte(u))
+		}
+	case kindFamily(k) == intFamily:
+		var i int64
+		if i, err = strconv.ParseInt(string(n), 10, 64); err == nil {
+			e.EncodeInt(i)
+		}
+	case isFloatKind(k):
+		var f float64
+		if f, err = strconv.ParseFloat(string(n), 64); err == nil {
+			e.EncodeFloat(f)
+		}
+	default:
+		var u uint64
+		if u, err = strconv.ParseUint(string(n), 10, 64); err == nil {
+			e.EncodeUint(u)
+		}
+	}
+	if err != nil {
+		Failf("bad number %s for %s: %v", n, hintString(h), err)
+	}
+}
+
+func hintString(h rawHint) string {
+	if h.name != "" {
+		return h.name
+	}
+	if h.kind != reflect.Invalid {
+		return h.kind.String()
+	}
+	return "unsigned integer"
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codecapi
+
+import (
+	"reflect"
+	"sync"
+)
+
+// This file implements parallel encoding of large top-level slices and maps.
+//
+// The value's TypeCodec, a Splitter, divides it into parts. Each part is
+// encoded by its own worker Encoder into a separate buffer, and the buffers
+// are appended in order to the main Encoder's buffer. The result is the same
+// list the TypeCodec's Encode method would produce.
+//
+// Type numbers appear in the encoded data, so they must be the same for all
+// workers. A worker Encoder records types in its parent, the main Encoder,
+// and keeps a copy of the parent's number locally so it only needs to lock the
+// parent the first time it sees a type.
+//
+// Parallel encoding is not possible when tracking pointers, because a
+// reference is relative to the position of the value it refers to, which may
+// be in another part.
+
+// minParallelLen is the smallest length of a slice or map that is encoded in
+// parallel. Smaller values aren't worth the overhead.
+const minParallelLen = 1024
+
+// partsPerWorker is the number of parts each worker encodes, on average.
+// Using more than one part per worker balances the load when some elements
+// take longer to encode than others.
+const partsPerWorker = 4
+
+// encodeParallel encodes x in parallel, if that is possible and worthwhile,
+// and reports whether it did.
+func (e *Encoder) encodeParallel(x interface{}) bool {
+	if e.opts.Parallelism <= 1 || e.opts.TrackPointers || x == nil {
+		return false
+	}
+	v := reflect.ValueOf(x)
+	if (v.Kind() != reflect.Slice && v.Kind() != reflect.Map) || v.Len() < minParallelLen {
+		return false
+	}
+	tc, tnum := e.recordType(v.Type())
+	sp, ok := tc.(Splitter)
+	if !ok {
+		return false
+	}
+	listLen, parts := sp.Split(x, e.opts.Parallelism*partsPerWorker)
+	workers := e.workerEncoders(len(parts))
+
+	// Encode the parts.
+	var (
+		wg     sync.WaitGroup
+		next   = make(chan int, len(parts))
+		panics = make([]interface{}, len(parts))
+	)
+	for i := range parts {
+		next <- i
+	}
+	close(next)
+	nWorkers := e.opts.Parallelism
+	if nWorkers > len(parts) {
+		nWorkers = len(parts)
+	}
+	for w := 0; w < nWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				panics[i] = workers[i].encodePart(parts[i])
+			}
+		}()
+	}
+	wg.Wait()
+	for _, p := range panics {
+		if p != nil {
+			// Let encodeFrame turn the panic into an error, or re-panic.
+			panic(p)
+		}
+	}
+
+	// Assemble the parts as EncodeAny would.
+	e.StartList(2)
+	e.EncodeUint(uint64(tnum))
+	start := len(e.buf)
+	e.StartList(listLen)
+	for _, w := range workers {
+		e.buf = append(e.buf, w.buf...)
+	}
+	if e.stats != nil {
+		for _, w := range workers {
+			e.stats.add(w.stats)
+		}
+		e.StatsEnd(v.Type(), start)
+	}
+	return true
+}
+
+// workerEncoders returns n Encoders that record types in e.
+// They are reused by later calls.
+func (e *Encoder) workerEncoders(n int) []*Encoder {
+	for len(e.workers) < n {
+		e.workers = append(e.workers, &Encoder{
+			opts:      e.opts,
+			parent:    e,
+			typeInfos: map[reflect.Type]typeInfo{},
+		})
+	}
+	ws := e.workers[:n]
+	for _, w := range ws {
+		w.buf = w.buf[:0]
+		if e.stats != nil {
+			// Each worker collects its own statistics, to be added to e's.
+			if w.stats == nil {
+				w.stats = &EncodeStats{}
+			}
+			w.stats.reset()
+		}
+		for t := range w.typeInfos {
+			delete(w.typeInfos, t)
+		}
+	}
+	return ws
+}
+
+// encodePart encodes a part of a value into e's buffer. It returns the value
+// of a panic during encoding, so it can be raised again on the goroutine that
+// called Encode.
+func (e *Encoder) encodePart(part func(*Encoder)) (p interface{}) {
+	defer func() { p = recover() }()
+	part(e)
+	return nil
+}
+
+// recordParentType records t in e's parent, and returns its TypeCodec and
+// number.
+func (e *Encoder) recordParentType(t reflect.Type) (TypeCodec, int) {
+	e.parent.mu.Lock()
+	defer e.parent.mu.Unlock()
+	tc, num := e.parent.recordType(t)
+	e.typeInfos[t] = typeInfo{tc, num}
+	return tc, num
+}
@@ -0,0 +1,476 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codecapi
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// This file parses frames without the types they were encoded from, for tools
+// that inspect encoded data.
+//
+// The encoding is self-delimiting, so the structure of any value can be
+// recovered: lists, structs, pointers and byte sequences. The metadata at the
+// start of each frame names the types in it, and gives the names and kinds of
+// struct fields, so the parser can often do better. It follows the type names
+// and field kinds down from the top-level value, which is always of interface
+// type, and annotates the values it finds with their types and field names.
+// Where it can't tell the type of a value, such as the elements of a field of
+// kind slice, it falls back to the structure alone.
+
+// Metadata is the initial metadata of a frame, which describes the types of
+// the values in it.
+type Metadata struct {
+	TypeNames      []string                 // by type number
+	Fields         map[int][]string         // names of struct fields, by type number
+	FieldKinds     map[int][]reflect.Kind   // kinds of struct fields, by type number
+	FieldElemKinds map[int][][]reflect.Kind // kinds of the elements of struct fields; see FieldElemKinder
+	CustomNames    map[int]string           // names of custom codecs, by type number
+	Fingerprint    string                   // from EncodeOptions.SchemaFingerprint
+	Len            int                      // length of the metadata, in bytes
+}
+
+// A RawKind is the kind of a RawValue.
+type RawKind int
+
+const (
+	RawUint   RawKind = iota // an unsigned integer, or a number or bool encoded as one
+	RawByte                  // a single byte: an int8 or uint8
+	RawBytes                 // a sequence of bytes, like a string
+	RawNil                   // a nil pointer, slice or map
+	RawList                  // a list of values: a slice, array, map or complex number
+	RawStruct                // a struct's fields
+	RawPtr                   // a pointer to a value
+	RawRef                   // a reference to an earlier pointer
+	RawAny                   // a value of interface type: a type number and a value, or nil
+)
+
+var rawKindNames = []string{"uint", "byte", "bytes", "nil", "list", "struct", "ptr", "ref", "any"}
+
+func (k RawKind) String() string {
+	if k >= 0 && int(k) < len(rawKindNames) {
+		return rawKindNames[k]
+	}
+	return "RawKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A RawValue is an encoded value, parsed without its type.
+type RawValue struct {
+	Kind   RawKind
+	Offset int          // offset of the value in the frame
+	End    int          // offset just past the value
+	Type   string       // name of the value's type, if known
+	GoKind reflect.Kind // kind of the value's type, if known
+
+	Uint   uint64      // for RawUint and RawByte; the type number for RawAny
+	Bytes  []byte      // for RawBytes; refers to the frame's data
+	Elems  []*RawValue // for RawList
+	Fields []*RawField // for RawStruct
+	Elem   *RawValue   // for RawPtr, and RawAny unless it is nil
+	Shared bool        // for RawPtr: whether a RawRef refers to it
+	Target int         // for RawRef: the offset of the RawPtr it refers to
+}
+
+// A RawField is a field of a RawStruct.
+type RawField struct {
+	Num    int    // the field's number in the struct's metadata
+	Name   string // the field's name, if the struct's type is known
+	Offset int    // offset of the field number in the frame
+	Value  *RawValue
+}
+
+// Scalar returns the Go value of v if its GoKind is a boolean, numeric or
+// string kind, and nil otherwise. Integers are returned as int64 or uint64,
+// floating-point numbers as float64 and complex numbers as complex128.
+func (v *RawValue) Scalar() interface{} {
+	switch k := v.GoKind; {
+	case k == reflect.Bool && v.Kind == RawUint:
+		return v.Uint != 0
+	case k == reflect.Int8 && v.Kind == RawByte:
+		return int64(int8(v.Uint))
+	case k == reflect.Uint8 && v.Kind == RawByte:
+		return v.Uint
+	case kindFamily(k) == intFamily && v.Kind == RawUint:
+		return zigzag(v.Uint)
+	case kindFamily(k) == uintFamily && v.Kind == RawUint:
+		return v.Uint
+	case kindFamily(k) == floatFamily && v.Kind == RawUint:
+		return uintToFloat(v.Uint)
+	case kindFamily(k) == complexFamily && v.Kind == RawList && len(v.Elems) == 2:
+		return complex(uintToFloat(v.Elems[0].Uint), uintToFloat(v.Elems[1].Uint))
+	case k == reflect.String && v.Kind == RawBytes:
+		return string(v.Bytes)
+	default:
+		return nil
+	}
+}
+
+// zigzag decodes a signed integer encoded by EncodeInt.
+func zigzag(u uint64) int64 {
+	if u&1 == 1 {
+		return int64(^(u >> 1))
+	}
+	return int64(u >> 1)
+}
+
+// uintToFloat decodes a float encoded by EncodeFloat.
+func uintToFloat(u uint64) float64 {
+	return math.Float64frombits(bits.ReverseBytes64(u))
+}
+
+// ParseFrame parses a frame read by Decoder.ReadFrame without using any
+// registered types. It returns the frame's metadata and its value.
+func ParseFrame(f Frame) (md *Metadata, v *RawValue, err error) {
+	p := &rawParser{d: &Decoder{buf: f.Data, version: f.Version}}
+	defer p.handlePanic(&err)
+	md = p.parseMetadata()
+	p.md = md
+	p.typeNums = make(map[string]int, len(md.TypeNames))
+	for num, name := range md.TypeNames {
+		p.typeNums[name] = num
+	}
+	v = p.parse(rawHint{name: "interface{}", kind: reflect.Interface})
+	if p.d.i != len(f.Data) {
+		Failf("%d bytes after value", len(f.Data)-p.d.i)
+	}
+	return md, v, nil
+}
+
+type rawParser struct {
+	d        *Decoder
+	md       *Metadata
+	typeNums map[string]int // from type name to number
+}
+
+// handlePanic turns a panic from Fail into an error.
+func (p *rawParser) handlePanic(errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if cerr, ok := r.(codecError); ok {
+		*errp = fmt.Errorf("offset %d: %v", p.d.i, cerr.err)
+		return
+	}
+	panic(r)
+}
+
+// startList is like Decoder.StartList, but fails if the list can't be
+// non-nil and fit in the rest of the frame. It is for lists whose elements
+// take at least one byte each.
+func (p *rawParser) startList() int {
+	n := p.d.StartList()
+	if n < 0 || n > len(p.d.buf)-p.d.i {
+		Failf("bad list length %d", n)
+	}
+	return n
+}
+
+// parseMetadata parses the initial metadata, as decodeInitial does, but without
+// looking up the types.
+func (p *rawParser) parseMetadata() *Metadata {
+	d := p.d
+	md := &Metadata{
+		TypeNames:      d.decodeStringSlice(),
+		Fields:         map[int][]string{},
+		FieldKinds:     map[int][]reflect.Kind{},
+		FieldElemKinds: map[int][][]reflect.Kind{},
+		CustomNames:    map[int]string{},
+	}
+	checkNum := func(num uint64) int {
+		if num >= uint64(len(md.TypeNames)) {
+			Failf("bad type number: %d", num)
+		}
+		return int(num)
+	}
+	n := p.startList()
+	for i := 0; i < n; i++ {
+		num := checkNum(d.DecodeUint())
+		md.Fields[num] = d.decodeStringSlice()
+	}
+	if d.version >= 2 {
+		n := p.startList()
+		for i := 0; i < n; i++ {
+			switch d.DecodeString() {
+			case fieldKindsSection:
+				m := p.startList()
+				for j := 0; j < m; j += 2 {
+					num := checkNum(d.DecodeUint())
+					kinds := make([]reflect.Kind, p.startList())
+					for k := range kinds {
+						kinds[k] = reflect.Kind(d.DecodeUint())
+					}
+					md.FieldKinds[num] = kinds
+				}
+			case fieldElemKindsSection:
+				m := p.startList()
+				for j := 0; j < m; j += 2 {
+					num := checkNum(d.DecodeUint())
+					eks := make([][]reflect.Kind, p.startList())
+					for k := range eks {
+						eks[k] = make([]reflect.Kind, p.startList())
+						for l := range eks[k] {
+							eks[k][l] = reflect.Kind(d.DecodeUint())
+						}
+					}
+					md.FieldElemKinds[num] = eks
+				}
+			case customCodecsSection:
+				m := p.startList()
+				for j := 0; j < m; j += 2 {
+					num := checkNum(d.DecodeUint())
+					md.CustomNames[num] = d.DecodeString()
+				}
+			case schemaFingerprintSection:
+				md.Fingerprint = d.DecodeString()
+			default:
+				d.skip()
+			}
+		}
+	}
+	md.Len = d.i
+	return md
+}
+
+// A rawHint is what the parser knows about the type of the value it is about to
+// parse.
+type rawHint struct {
+	name string       // the type's name, or ""
+	kind reflect.Kind // the kind it is encoded as, or Invalid if unknown
+}
+
+// hintForName returns a hint for the type with the given name.
+func (p *rawParser) hintForName(name string) rawHint {
+	h := rawHint{name: name}
+	switch {
+	case name == "interface{}":
+		h.kind = reflect.Interface
+	case strings.HasPrefix(name, "*"):
+		h.kind = reflect.Ptr
+	case strings.HasPrefix(name, "[]"):
+		h.kind = reflect.Slice
+	case strings.HasPrefix(name, "["):
+		h.kind = reflect.Array
+	case strings.HasPrefix(name, "map["):
+		h.kind = reflect.Map
+	default:
+		if k, ok := builtinKinds[name]; ok {
+			h.kind = k
+		} else if num, ok := p.typeNums[name]; ok {
+			if _, ok := p.md.CustomNames[num]; !ok && p.md.Fields[num] != nil {
+				h.kind = reflect.Struct
+			}
+		}
+	}
+	return h
+}
+
+// builtinKinds maps the names of the builtin types to their kinds.
+var builtinKinds = map[string]reflect.Kind{}
+
+func init() {
+	for k := reflect.Bool; k <= reflect.Complex128; k++ {
+		builtinKinds[k.String()] = k
+	}
+	builtinKinds["string"] = reflect.String
+}
+
+// elemNames returns the names of the key and element types of a composite
+// type name, and for an array, its length. The key is empty except for maps.
+func elemNames(name string) (key, elem string) {
+	switch {
+	case strings.HasPrefix(name, "*"):
+		return "", name[1:]
+	case strings.HasPrefix(name, "map["):
+		// Find the bracket that ends the key.
+		depth := 0
+		for i := 3; i < len(name); i++ {
+			switch name[i] {
+			case '[':
+				depth++
+			case ']':
+				depth--
+				if depth == 0 {
+					return name[4:i], name[i+1:]
+				}
+			}
+		}
+	case strings.HasPrefix(name, "["):
+		if i := strings.IndexByte(name, ']'); i > 0 {
+			return "", name[i+1:]
+		}
+	}
+	return "", ""
+}
+
+// parse parses a value.
+func (p *rawParser) parse(h rawHint) *RawValue {
+	d := p.d
+	v := &RawValue{Offset: d.i, Type: h.name, GoKind: h.kind}
+	defer func() { v.End = d.i }()
+
+	b := d.curByte()
+	switch h.kind {
+	case reflect.Interface:
+		if p.parseAny(v, h.name != "") {
+			return v
+		}
+	case reflect.Int8, reflect.Uint8:
+		if h.name == "" || builtinKinds[h.name] != 0 {
+			// A struct field or builtin type, encoded as a raw byte.
+			v.Kind = RawByte
+			v.Uint = uint64(d.readByte())
+			return v
+		}
+	case reflect.Bool, reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if b < endCode || b == bytes1Code || b == bytes2Code || b == bytes4Code || b == nBytesCode {
+			v.Kind = RawUint
+			v.Uint = d.DecodeUint()
+			return v
+		}
+	}
+
+	switch {
+	case b < endCode:
+		d.readByte()
+		v.Kind = RawUint
+		v.Uint = uint64(b)
+	case b >= bytes4Code && b <= bytes0Code || b == nBytesCode:
+		v.Kind = RawBytes
+		v.Bytes = d.readBytes(d.decodeLen())
+	case b == nilCode:
+		d.readByte()
+		v.Kind = RawNil
+	case b == nValuesCode:
+		d.readByte()
+		v.Kind = RawList
+		n := d.DecodeUint()
+		if n > uint64(len(d.buf)-d.i) {
+			Failf("list length %d is too large", n)
+		}
+		v.Elems = make([]*RawValue, n)
+		var key, elem rawHint
+		switch h.kind {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			kn, en := elemNames(h.name)
+			if kn != "" {
+				key = p.hintForName(kn)
+			}
+			if en != "" {
+				elem = p.hintForName(en)
+			}
+		case reflect.Complex64, reflect.Complex128:
+			elem = rawHint{kind: reflect.Float64}
+		}
+		for i := range v.Elems {
+			eh := elem
+			if h.kind == reflect.Map && i%2 == 0 {
+				eh = key
+			}
+			v.Elems[i] = p.parse(eh)
+		}
+	case b == ptrCode || b == refPtrCode:
+		d.readByte()
+		v.Kind = RawPtr
+		v.Shared = b == refPtrCode
+		var elem rawHint
+		if h.kind == reflect.Ptr {
+			if _, en := elemNames(h.name); en != "" {
+				elem = p.hintForName(en)
+			}
+		}
+		v.Elem = p.parse(elem)
+	case b == refCode:
+		d.readByte()
+		v.Kind = RawRef
+		i := d.i
+		u := d.DecodeUint()
+		if u > uint64(i) {
+			Failf("reference offset %d out of range", u)
+		}
+		v.Target = i - int(u)
+	case b == startCode:
+		d.readByte()
+		v.Kind = RawStruct
+		p.parseFields(v, h)
+	default:
+		d.readByte()
+		d.badcode(b)
+	}
+	return v
+}
+
+// parseAny parses a value encoded by EncodeAny into v, and reports whether it
+// did. If strict is false, the value may have been encoded some other way, as
+// by a custom codec, and parseAny only parses it if it looks like one encoded
+// by EncodeAny.
+func (p *rawParser) parseAny(v *RawValue, strict bool) bool {
+	d := p.d
+	if d.curByte() == 0 {
+		d.readByte()
+		v.Kind = RawAny
+		return true
+	}
+	if !strict {
+		// Look for a list of length 2 beginning with a type number.
+		save := d.i
+		ok := d.readByte() == nValuesCode && d.i < len(d.buf) && d.DecodeUint() == 2 &&
+			d.i < len(d.buf) && d.curByte() < endCode && int(d.curByte()) < len(p.md.TypeNames)
+		d.i = save
+		if !ok {
+			return false
+		}
+	}
+	if n := d.StartList(); n != 2 {
+		Failf("interface value: bad list length %d", n)
+	}
+	num := d.DecodeUint()
+	if num >= uint64(len(p.md.TypeNames)) {
+		Failf("type number %d out of range", num)
+	}
+	v.Kind = RawAny
+	v.Uint = num
+	v.Elem = p.parse(p.hintForName(p.md.TypeNames[num]))
+	return true
+}
+
+// parseFields parses the fields of a struct, after its startCode.
+func (p *rawParser) parseFields(v *RawValue, h rawHint) {
+	d := p.d
+	var (
+		names []string
+		kinds []reflect.Kind
+	)
+	if h.kind == reflect.Struct {
+		num := p.typeNums[h.name]
+		names = p.md.Fields[num]
+		kinds = p.md.FieldKinds[num]
+	}
+	for d.curByte() != endCode {
+		f := &RawField{Offset: d.i}
+		n := d.DecodeUint()
+		if names != nil && n >= uint64(len(names)) {
+			Failf("field number %d out of range for %s", n, h.name)
+		}
+		f.Num = int(n)
+		var fh rawHint
+		if names != nil {
+			f.Name = names[n]
+			if int(n) < len(kinds) {
+				fh.kind = kinds[n]
+			}
+		}
+		f.Value = p.parse(fh)
+		v.Fields = append(v.Fields, f)
+	}
+	d.readByte() // endCode
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codecapi
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseFrame(t *testing.T) {
+	for _, test := range []struct {
+		in   interface{}
+		want interface{} // from Scalar
+		kind RawKind
+	}{
+		{"hello", "hello", RawBytes},
+		{true, true, RawUint},
+		{-5, int64(-5), RawUint},
+		{int8(-11), int64(-11), RawByte},
+		{uint8(255), uint64(255), RawByte},
+		{uint64(1 << 63), uint64(1 << 63), RawUint},
+		{1.5, 1.5, RawUint},
+		{float32(-2), -2.0, RawUint},
+		{complex(3, 4), complex(3, 4), RawList},
+		{[]byte{1, 2}, nil, RawBytes},
+	} {
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf, EncodeOptions{}).Encode(test.in); err != nil {
+			t.Fatal(err)
+		}
+		f, err := NewDecoder(&buf, DecodeOptions{}).ReadFrame(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		md, v, err := ParseFrame(f)
+		if err != nil {
+			t.Fatalf("%#v: %v", test.in, err)
+		}
+		if v.Kind != RawAny || v.Elem == nil {
+			t.Fatalf("%#v: got %s, want a non-nil interface", test.in, v.Kind)
+		}
+		tname := TypeString(reflect.TypeOf(test.in), nil)
+		if got := md.TypeNames[v.Uint]; got != tname {
+			t.Errorf("%#v: type name: got %q, want %q", test.in, got, tname)
+		}
+		e := v.Elem
+		if e.Kind != test.kind {
+			t.Errorf("%#v: kind: got %s, want %s", test.in, e.Kind, test.kind)
+		}
+		if got := e.Scalar(); got != test.want {
+			t.Errorf("%#v: got %#v, want %#v", test.in, got, test.want)
+		}
+		if e.End != len(f.Data) {
+			t.Errorf("%#v: End: got %d, want %d", test.in, e.End, len(f.Data))
+		}
+	}
+}
+
+func TestParseFrameErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, EncodeOptions{}).Encode(math.MaxInt64); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewDecoder(&buf, DecodeOptions{}).ReadFrame(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range [][]byte{
+		f.Data[:len(f.Data)-1],                      // truncated
+		f.Data[:len(f.Data)/2],                      // truncated metadata
+		append(f.Data[:len(f.Data):len(f.Data)], 0), // extra byte
+		append([]byte{reserved1}, f.Data[1:]...),    // bad code
+		// Type name lists that are too long, and of negative length.
+		{nValuesCode, nBytesCode, 8, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		{nValuesCode, nBytesCode, 8, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		if _, _, err := ParseFrame(Frame{Version: f.Version, Data: data}); err == nil {
+			t.Errorf("%x: got nil, want error", data)
+		}
+	}
+	// Every truncation is an error.
+	for n := 0; n < len(f.Data); n++ {
+		if _, _, err := ParseFrame(Frame{Version: f.Version, Data: f.Data[:n]}); err == nil {
+			t.Errorf("truncated to %d bytes: got nil, want error", n)
+		}
+	}
+}
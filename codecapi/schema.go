@@ -0,0 +1,188 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codecapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// A Schema describes registered types and how they are encoded. It can be
+// marshaled to JSON.
+type Schema struct {
+	Types []*TypeSchema `json:"types"` // sorted by name
+}
+
+// A TypeSchema describes a registered type.
+type TypeSchema struct {
+	Name    string         `json:"name"`              // as returned by TypeString(t, nil)
+	Kind    string         `json:"kind"`              // the type's reflect.Kind
+	Elem    string         `json:"elem,omitempty"`    // element type of a slice, array, map or pointer
+	Key     string         `json:"key,omitempty"`     // key type of a map
+	Len     int            `json:"len,omitempty"`     // length of an array
+	Fields  []*FieldSchema `json:"fields,omitempty"`  // fields of a struct that is encoded field by field
+	Custom  string         `json:"custom,omitempty"`  // name of the type's custom codec
+	Aliases []string       `json:"aliases,omitempty"` // former names, from RegisterAlias
+}
+
+// A FieldSchema describes a struct field, in the order it is encoded.
+type FieldSchema struct {
+	Name     string   `json:"name"`              // the name recorded in encoded data
+	Type     string   `json:"type,omitempty"`    // the field's type, if known
+	WireKind string   `json:"wireKind"`          // the kind the field is encoded as
+	Aliases  []string `json:"aliases,omitempty"` // former names of the field
+}
+
+// RegisteredSchema returns a Schema describing all types registered in
+// DefaultRegistry. If roots are provided, it describes only the roots and the
+// registered types reachable from them, like the types of struct fields.
+// Unregistered roots are ignored.
+func RegisteredSchema(roots ...reflect.Type) *Schema {
+	return DefaultRegistry.Schema(roots...)
+}
+
+// Schema returns a Schema describing the types registered in r. See
+// RegisteredSchema for details.
+func (r *Registry) Schema(roots ...reflect.Type) *Schema {
+	regs := r.load()
+	var types []reflect.Type
+	if len(roots) == 0 {
+		for _, t := range regs.nameToType {
+			types = append(types, t)
+		}
+	} else {
+		types = regs.reachableTypes(roots)
+	}
+	aliases := map[reflect.Type][]string{}
+	for name, t := range regs.aliases {
+		aliases[t] = append(aliases[t], name)
+	}
+	s := &Schema{}
+	for _, t := range types {
+		ts := typeSchema(t, regs.buildersByType[t]())
+		ts.Aliases = aliases[t]
+		sort.Strings(ts.Aliases)
+		s.Types = append(s.Types, ts)
+	}
+	sort.Slice(s.Types, func(i, j int) bool { return s.Types[i].Name < s.Types[j].Name })
+	return s
+}
+
+// reachableTypes returns the registered types reachable from roots.
+func (regs *registrations) reachableTypes(roots []reflect.Type) []reflect.Type {
+	seen := map[reflect.Type]bool{}
+	var types []reflect.Type
+	var visit func(reflect.Type)
+	visit = func(t reflect.Type) {
+		if t == nil || seen[t] {
+			return
+		}
+		seen[t] = true
+		tcb := regs.buildersByType[t]
+		if tcb == nil {
+			return
+		}
+		types = append(types, t)
+		tc := tcb()
+		for _, u := range tc.TypesUsed() {
+			visit(u)
+		}
+		if ft, ok := tc.(FieldTyper); ok {
+			for _, u := range ft.FieldTypes() {
+				visit(u)
+			}
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			visit(t.Key())
+			visit(t.Elem())
+		case reflect.Slice, reflect.Array, reflect.Ptr:
+			visit(t.Elem())
+		}
+	}
+	for _, t := range roots {
+		visit(t)
+	}
+	return types
+}
+
+func typeSchema(t reflect.Type, tc TypeCodec) *TypeSchema {
+	ts := &TypeSchema{
+		Name: TypeString(t, nil),
+		Kind: t.Kind().String(),
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		ts.Key = TypeString(t.Key(), nil)
+		ts.Elem = TypeString(t.Elem(), nil)
+	case reflect.Array:
+		ts.Len = t.Len()
+		ts.Elem = TypeString(t.Elem(), nil)
+	case reflect.Slice, reflect.Ptr:
+		ts.Elem = TypeString(t.Elem(), nil)
+	}
+	if cn, ok := tc.(CustomNamer); ok {
+		ts.Custom = cn.CustomName()
+	}
+	fields := tc.Fields()
+	if fields == nil {
+		return ts
+	}
+	var (
+		kinds   []reflect.Kind
+		types   []reflect.Type
+		aliases = map[string][]string{}
+	)
+	if fk, ok := tc.(FieldKinder); ok {
+		kinds = fk.FieldKinds()
+	}
+	if ft, ok := tc.(FieldTyper); ok {
+		types = ft.FieldTypes()
+	}
+	if fa, ok := tc.(FieldAliaser); ok {
+		for old, cur := range fa.FieldAliases() {
+			aliases[cur] = append(aliases[cur], old)
+		}
+	}
+	ts.Fields = []*FieldSchema{}
+	for i, name := range fields {
+		fs := &FieldSchema{Name: name, Aliases: aliases[name]}
+		sort.Strings(fs.Aliases)
+		if i < len(types) && types[i] != nil {
+			fs.Type = TypeString(types[i], nil)
+		}
+		if i < len(kinds) {
+			fs.WireKind = kinds[i].String()
+		}
+		ts.Fields = append(ts.Fields, fs)
+	}
+	return ts
+}
+
+// Lookup returns the TypeSchema for the type with the given name, or nil if
+// there is none.
+func (s *Schema) Lookup(name string) *TypeSchema {
+	i := sort.Search(len(s.Types), func(i int) bool { return s.Types[i].Name >= name })
+	if i < len(s.Types) && s.Types[i].Name == name {
+		return s.Types[i]
+	}
+	return nil
+}
+
+// Fingerprint returns a short string that identifies the schema: equal
+// schemas have equal fingerprints, and different schemas almost certainly
+// have different ones.
+func (s *Schema) Fingerprint() string {
+	data, err := json.Marshal(s)
+	if err != nil {
+		// A Schema consists only of strings, ints and slices.
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
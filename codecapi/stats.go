@@ -0,0 +1,98 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codecapi
+
+import (
+	"reflect"
+	"sync"
+)
+
+// EncodeStats holds statistics about encoded values. An Encoder whose
+// EncodeOptions.Stats is set adds to them when each call to Encode succeeds.
+// Encoders that share an EncodeStats may run concurrently, but its fields
+// should be read only when none of them is encoding.
+type EncodeStats struct {
+	Values   int // number of values encoded
+	Metadata int // bytes of metadata recorded with the values
+	Data     int // bytes of the values themselves
+	Refs     int // references to shared pointers, with TrackPointers
+
+	// Types holds the statistics for each type. The bytes of a value include
+	// those of the values it contains, so a pointer's bytes include those of
+	// the value it points to, and a struct's include those of its fields.
+	// Values are counted for generated types and for builtin types encoded as
+	// interface values; a builtin value in a struct field or slice is part of
+	// the struct or slice.
+	Types map[reflect.Type]*TypeStats
+}
+
+// TypeStats holds the statistics for the values of one type.
+type TypeStats struct {
+	Count int // number of values
+	Bytes int // total encoded size
+}
+
+// statsMu is held while an Encoder adds the statistics of a value to
+// EncodeOptions.Stats. Encoders add only once per value, so a single mutex
+// suffices.
+var statsMu sync.Mutex
+
+// reset clears s, keeping its map.
+func (s *EncodeStats) reset() {
+	s.Values, s.Metadata, s.Data, s.Refs = 0, 0, 0, 0
+	for t := range s.Types {
+		delete(s.Types, t)
+	}
+}
+
+// add adds the statistics in s2 to s.
+func (s *EncodeStats) add(s2 *EncodeStats) {
+	s.Values += s2.Values
+	s.Metadata += s2.Metadata
+	s.Data += s2.Data
+	s.Refs += s2.Refs
+	for t, ts2 := range s2.Types {
+		ts := s.typeStats(t)
+		ts.Count += ts2.Count
+		ts.Bytes += ts2.Bytes
+	}
+}
+
+func (s *EncodeStats) typeStats(t reflect.Type) *TypeStats {
+	ts := s.Types[t]
+	if ts == nil {
+		if s.Types == nil {
+			s.Types = map[reflect.Type]*TypeStats{}
+		}
+		ts = &TypeStats{}
+		s.Types[t] = ts
+	}
+	return ts
+}
+
+// StatsStart returns the current offset in the encoder's buffer if the
+// encoder is collecting statistics, and -1 if it isn't. Generated code calls
+// it at the start of each encode method, and if it returns a non-negative
+// offset, calls StatsEnd after encoding the value:
+//
+//	start := e.StatsStart()
+//	// encode the value
+//	if start >= 0 {
+//		e.StatsEnd(T_type, start)
+//	}
+func (e *Encoder) StatsStart() int {
+	if e.stats == nil {
+		return -1
+	}
+	return len(e.buf)
+}
+
+// StatsEnd records a value of type t, whose encoding began at the offset
+// start returned by StatsStart.
+func (e *Encoder) StatsEnd(t reflect.Type, start int) {
+	ts := e.stats.typeStats(t)
+	ts.Count++
+	ts.Bytes += len(e.buf) - start
+}
@@ -5,12 +5,18 @@
 package codecapi
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-// A TypeCodec handles encoding and decoding of a particular type.
+// A TypeCodec handles encoding and decoding of a particular type. The slices
+// and maps returned by its methods, and by the optional methods of the
+// interfaces below, may be shared by all codecs of the type and must not be
+// modified.
 type TypeCodec interface {
 	Fields() []string           // the names of all the struct fields, if this is a struct
 	TypesUsed() []reflect.Type  // all the types this codec uses, not including itself
@@ -20,12 +26,181 @@ type TypeCodec interface {
 	Decode(*Decoder) interface{}
 }
 
-var (
-	typeCodecBuildersByName = map[string]func() TypeCodec{}
-	typeCodecBuildersByType = map[reflect.Type]func() TypeCodec{}
+// An IntoDecoder is a TypeCodec that can decode directly into a variable of its
+// type, avoiding the allocation of Decode. Unless the Decoder is merging,
+// DecodeInto replaces the variable's value, and leaves it unchanged if decoding
+// fails. The exceptions are types with an UnmarshalCodec method or a custom
+// decoding function: DecodeInto sets the variable to its zero value and
+// decodes into it, so a failure can leave it partly decoded.
+// All generated TypeCodecs and the TypeCodecs for builtin types are
+// IntoDecoders.
+type IntoDecoder interface {
+	DecodeInto(d *Decoder, p interface{}) // p is a pointer to the codec's type
+}
 
-	nameToType = map[string]reflect.Type{}
-)
+// A FieldAliaser is a TypeCodec for a struct whose fields have former names.
+// Data encoded with a former name is decoded into the field.
+type FieldAliaser interface {
+	FieldAliases() map[string]string // from former name to current name
+}
+
+// A FieldKinder is a TypeCodec for a struct that reports the kinds of its
+// fields. A decoder uses the kinds of the encoded fields and the kinds of the
+// generated ones to convert between compatible numeric types.
+type FieldKinder interface {
+	FieldKinds() []reflect.Kind // in the same order as Fields
+}
+
+// KindByName returns the reflect.Kind whose String method returns name.
+func KindByName(name string) (reflect.Kind, bool) {
+	k, ok := kindsByName[name]
+	return k, ok
+}
+
+var kindsByName = map[string]reflect.Kind{}
+
+func init() {
+	for k := reflect.Invalid; k <= reflect.UnsafePointer; k++ {
+		kindsByName[k.String()] = k
+	}
+}
+
+// A FieldElemKinder is a TypeCodec for a struct that reports the kinds of the
+// elements of its fields. For each field, FieldElemKinds returns the kinds of
+// the types that the field's type is built from, in the order a traversal
+// visits them: a slice, array or pointer is followed by its element type, and
+// a map by its key type and then its element type, each with its own element
+// kinds. For example, a field of type map[string][]int has element kinds
+// String, Slice, Int. A decoder uses them to convert the numeric elements of a
+// field of slice, array or map type. The kinds are wire kinds, like those of
+// FieldKinds, and a type that recurs within itself is given as
+// reflect.Invalid, without its elements.
+type FieldElemKinder interface {
+	FieldElemKinds() [][]reflect.Kind // in the same order as Fields
+}
+
+// A FieldTyper is a TypeCodec for a struct that reports the types of its
+// fields. It is used to describe the struct in a Schema.
+type FieldTyper interface {
+	FieldTypes() []reflect.Type // in the same order as Fields; nil for an unknown type
+}
+
+// A CustomNamer is a TypeCodec that encodes and decodes its type with custom
+// functions. CustomName identifies the encoding; it is recorded with encoded
+// data, and a decoder checks that it matches.
+type CustomNamer interface {
+	CustomName() string
+}
+
+// A Splitter is a TypeCodec for a slice or map type that can divide a value
+// into parts that are encoded independently, so that large values can be
+// encoded in parallel.
+type Splitter interface {
+	// Split returns the length of the list that x, which must not be nil, is
+	// encoded as, and at most n functions that each encode consecutive
+	// elements of that list.
+	Split(x interface{}, n int) (listLen int, parts []func(*Encoder))
+}
+
+// A Registry holds the types that Encoders and Decoders can handle, with a
+// TypeCodec builder for each. Registering is safe to do concurrently with
+// encoding and decoding: lookups read an immutable snapshot of the
+// registrations without locking, and the snapshot is rebuilt after a change.
+//
+// The zero Registry is empty. Use NewRegistry to get one with the builtin
+// types.
+type Registry struct {
+	mu   sync.Mutex    // held while registering
+	regs registrations // guarded by mu
+	snap atomic.Value  // *registrations, a copy of regs; nil after a change
+}
+
+// registrations holds what has been registered in a Registry.
+type registrations struct {
+	buildersByName map[string]func() TypeCodec
+	buildersByType map[reflect.Type]func() TypeCodec
+	decodeBuilders map[reflect.Type]func() TypeCodec // where different from buildersByType
+	nameToType     map[string]reflect.Type
+	aliases        map[string]reflect.Type // former type names, from RegisterAlias
+}
+
+// DefaultRegistry is the Registry used by Register, RegisterAlias,
+// LookupTypeCodec and RegisteredSchema, and by Encoders and Decoders whose
+// options don't name one. Generated code registers its types here.
+var DefaultRegistry = &Registry{}
+
+// builtinBuilders holds the TypeCodec builders for builtin types, which every
+// Registry from NewRegistry starts with.
+var builtinBuilders = map[reflect.Type]func() TypeCodec{}
+
+// NewRegistry returns a Registry holding only the builtin types.
+func NewRegistry() *Registry {
+	r := &Registry{}
+	for t, tcb := range builtinBuilders {
+		r.Register(t, tcb)
+	}
+	return r
+}
+
+// registryOr returns r, or DefaultRegistry if r is nil.
+func registryOr(r *Registry) *Registry {
+	if r == nil {
+		return DefaultRegistry
+	}
+	return r
+}
+
+// load returns the current registrations of r. The caller must not modify
+// them.
+func (r *Registry) load() *registrations {
+	if s, _ := r.snap.Load().(*registrations); s != nil {
+		return s
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, _ := r.snap.Load().(*registrations); s != nil {
+		return s
+	}
+	s := &registrations{
+		buildersByName: make(map[string]func() TypeCodec, len(r.regs.buildersByName)),
+		buildersByType: make(map[reflect.Type]func() TypeCodec, len(r.regs.buildersByType)),
+		decodeBuilders: make(map[reflect.Type]func() TypeCodec, len(r.regs.decodeBuilders)),
+		nameToType:     make(map[string]reflect.Type, len(r.regs.nameToType)),
+		aliases:        make(map[string]reflect.Type, len(r.regs.aliases)),
+	}
+	for k, v := range r.regs.buildersByName {
+		s.buildersByName[k] = v
+	}
+	for k, v := range r.regs.buildersByType {
+		s.buildersByType[k] = v
+	}
+	for k, v := range r.regs.decodeBuilders {
+		s.decodeBuilders[k] = v
+	}
+	for k, v := range r.regs.nameToType {
+		s.nameToType[k] = v
+	}
+	for k, v := range r.regs.aliases {
+		s.aliases[k] = v
+	}
+	r.snap.Store(s)
+	return s
+}
+
+// changed prepares r for a change to its registrations. It must be called
+// with r.mu held.
+func (r *Registry) changed() {
+	if r.regs.buildersByName == nil {
+		r.regs = registrations{
+			buildersByName: map[string]func() TypeCodec{},
+			buildersByType: map[reflect.Type]func() TypeCodec{},
+			decodeBuilders: map[reflect.Type]func() TypeCodec{},
+			nameToType:     map[string]reflect.Type{},
+			aliases:        map[string]reflect.Type{},
+		}
+	}
+	r.snap.Store((*registrations)(nil))
+}
 
 // TypeString constructs a string from a reflect.Type.
 //
@@ -88,17 +263,234 @@ func TypeString(t reflect.Type, pkgPaths map[string]string) string {
 	}
 }
 
-// Register records t for use by Encoders and Decoders.
+// Register records t in DefaultRegistry for use by Encoders and Decoders.
 // All types subject to encoding must be registered, even
 // builtin types.
+//
+// A type may be registered more than once, as when two packages that both
+// generate code for []string or for a type from another package are linked
+// into the same program, provided the codecs are interchangeable: they must
+// have the same fields, field kinds, former field names and custom codec name.
+// The first codec is kept, unless it was generated without an encoder or
+// decoder and a later one wasn't. If one codec was generated with only an
+// encoder and another with only a decoder, each is used for what it does.
+// Registering a type with a codec that isn't interchangeable with an earlier
+// one panics.
 func Register(t reflect.Type, tcb func() TypeCodec) {
+	DefaultRegistry.Register(t, tcb)
+}
+
+// Register records t in r. See the Register function for details.
+func (r *Registry) Register(t reflect.Type, tcb func() TypeCodec) {
 	tn := TypeString(t, nil) // create a unique name
-	if _, ok := typeCodecBuildersByName[tn]; ok {
-		panic(fmt.Sprintf("codec.Register: duplicate type %s (TypeString=%q)", t, tn))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if prev, ok := r.regs.buildersByName[tn]; ok {
+		if r.regs.nameToType[tn] != t {
+			panic(fmt.Sprintf("codec.Register: %s and %s have the same name %q", r.regs.nameToType[tn], t, tn))
+		}
+		enc, dec, err := chooseBuilders(prev, r.regs.decodeBuilders[t], tcb)
+		if err != nil {
+			panic(fmt.Sprintf("codec.Register: duplicate type %s (TypeString=%q): %v", t, tn, err))
+		}
+		r.changed()
+		r.regs.buildersByName[tn] = enc
+		r.regs.buildersByType[t] = enc
+		if dec != nil {
+			r.regs.decodeBuilders[t] = dec
+		} else {
+			delete(r.regs.decodeBuilders, t)
+		}
+		return
+	}
+	r.changed()
+	r.regs.buildersByName[tn] = tcb
+	r.regs.buildersByType[t] = tcb
+	r.regs.nameToType[tn] = t
+}
+
+// chooseBuilders chooses among the codecs registered for the same type. The
+// builders enc and dec were chosen before, for encoding and decoding; if dec
+// is nil, enc is used for both. It returns an error if the codec built by tcb
+// isn't interchangeable with enc's. Otherwise, it returns the builders to use
+// from now on, in the same form. A codec that lacks an encoder or decoder is
+// replaced, for that direction, by one that has it.
+func chooseBuilders(enc, dec, tcb func() TypeCodec) (newEnc, newDec func() TypeCodec, err error) {
+	prev, tc := enc(), tcb()
+	if !reflect.DeepEqual(summarize(prev), summarize(tc)) {
+		return nil, nil, errors.New("codecs encode it differently")
+	}
+	canEncode := !hasNoEncoder(prev)
+	canDecode := !hasNoDecoder(prev)
+	if dec != nil {
+		canDecode = !hasNoDecoder(dec())
+	}
+	switch {
+	case canEncode && canDecode:
+		return enc, dec, nil
+	case !hasNoEncoder(tc) && !hasNoDecoder(tc):
+		return tcb, nil, nil
+	case !canEncode && !hasNoEncoder(tc):
+		// prev has only a decoder, and tc only an encoder.
+		return tcb, enc, nil
+	case !canDecode && !hasNoDecoder(tc):
+		return enc, tcb, nil
+	default:
+		return enc, dec, nil
+	}
+}
+
+// decodeBuilder returns the builder of the TypeCodec to decode t with, or nil
+// if t isn't registered.
+func (s *registrations) decodeBuilder(t reflect.Type) func() TypeCodec {
+	if b := s.decodeBuilders[t]; b != nil {
+		return b
+	}
+	return s.buildersByType[t]
+}
+
+// A codecSummary holds what determines how a TypeCodec encodes its type.
+type codecSummary struct {
+	fields     []string
+	kinds      []reflect.Kind
+	elemKinds  [][]reflect.Kind
+	aliases    map[string]string
+	customName string
+	typesUsed  []reflect.Type
+}
+
+func summarize(tc TypeCodec) codecSummary {
+	s := codecSummary{
+		fields:    tc.Fields(),
+		typesUsed: tc.TypesUsed(),
+	}
+	if fk, ok := tc.(FieldKinder); ok {
+		s.kinds = fk.FieldKinds()
 	}
-	typeCodecBuildersByName[tn] = tcb
-	typeCodecBuildersByType[t] = tcb
-	nameToType[tn] = t
+	if fek, ok := tc.(FieldElemKinder); ok {
+		s.elemKinds = fek.FieldElemKinds()
+	}
+	if fa, ok := tc.(FieldAliaser); ok {
+		s.aliases = fa.FieldAliases()
+	}
+	if cn, ok := tc.(CustomNamer); ok {
+		s.customName = cn.CustomName()
+	}
+	return s
+}
+
+// RegisterAlias records in DefaultRegistry that oldName is a former name of t.
+// Use it when a type is moved to another package or renamed, so that data
+// encoded under the old name can still be decoded. The oldName argument should
+// be the result of calling TypeString(t, nil) when t had its old name; for
+// example, "example.com/a/v1.T".
+//
+// Composite types are handled as well: data encoded as "[]example.com/a/v1.T"
+// will be decoded as a slice of t, provided that slice type is registered.
+func RegisterAlias(oldName string, t reflect.Type) {
+	DefaultRegistry.RegisterAlias(oldName, t)
+}
+
+// RegisterAlias records in r that oldName is a former name of t. See the
+// RegisterAlias function for details.
+func (r *Registry) RegisterAlias(oldName string, t reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.regs.nameToType[oldName]; ok {
+		panic(fmt.Sprintf("codec.RegisterAlias: %q is the name of a registered type", oldName))
+	}
+	if t2, ok := r.regs.aliases[oldName]; ok && t2 != t {
+		panic(fmt.Sprintf("codec.RegisterAlias: %q is already an alias for %s", oldName, t2))
+	}
+	r.changed()
+	r.regs.aliases[oldName] = t
+}
+
+// RegisterFrom registers in r the given types, and the registered types
+// reachable from them, with the codecs and former names that src has for
+// them. It panics if one of the given types isn't registered in src. Use it to
+// build a Registry from the types that generated code registered in
+// DefaultRegistry:
+//
+//	r := codecapi.NewRegistry()
+//	r.RegisterFrom(codecapi.DefaultRegistry, reflect.TypeOf(T{}))
+func (r *Registry) RegisterFrom(src *Registry, types ...reflect.Type) {
+	regs := src.load()
+	for _, t := range types {
+		if regs.buildersByType[t] == nil {
+			panic(fmt.Sprintf("codec.RegisterFrom: %s is not registered", t))
+		}
+	}
+	reachable := map[reflect.Type]bool{}
+	for _, t := range regs.reachableTypes(types) {
+		reachable[t] = true
+		r.Register(t, regs.buildersByType[t])
+		if dec := regs.decodeBuilders[t]; dec != nil {
+			r.Register(t, dec)
+		}
+	}
+	for name, t := range regs.aliases {
+		if reachable[t] {
+			r.RegisterAlias(name, t)
+		}
+	}
+}
+
+// lookupType returns the registered type with the given name, or nil if there
+// is none. Former names are resolved to current ones, even when they are part
+// of a composite type name.
+func (s *registrations) lookupType(name string) reflect.Type {
+	if t := s.nameToType[name]; t != nil {
+		return t
+	}
+	if t := s.aliases[name]; t != nil {
+		return t
+	}
+	if len(s.aliases) == 0 {
+		return nil
+	}
+	return s.nameToType[s.replaceAliases(name)]
+}
+
+// LookupTypeCodec returns the type registered in DefaultRegistry with the
+// given name, as returned by TypeString(t, nil), and a new TypeCodec for it.
+// Former names registered with RegisterAlias are resolved to current ones. If
+// there is no such type, LookupTypeCodec returns nil, nil.
+func LookupTypeCodec(name string) (reflect.Type, TypeCodec) {
+	return DefaultRegistry.LookupTypeCodec(name)
+}
+
+// LookupTypeCodec returns the type registered in r with the given name, and a
+// new TypeCodec for it. See the LookupTypeCodec function for details.
+func (r *Registry) LookupTypeCodec(name string) (reflect.Type, TypeCodec) {
+	s := r.load()
+	t := s.lookupType(name)
+	if t == nil {
+		return nil, nil
+	}
+	return t, s.buildersByType[t]()
+}
+
+// replaceAliases replaces each former type name in the type string name with
+// the type's current name.
+func (s *registrations) replaceAliases(name string) string {
+	var b strings.Builder
+	start := 0 // start of the current name
+	for i := 0; i <= len(name); i++ {
+		if i < len(name) && !strings.ContainsRune("[]*{}; ", rune(name[i])) {
+			continue
+		}
+		n := name[start:i]
+		if t, ok := s.aliases[n]; ok {
+			n = TypeString(t, nil)
+		}
+		b.WriteString(n)
+		if i < len(name) {
+			b.WriteByte(name[i])
+		}
+		start = i + 1
+	}
+	return b.String()
 }
 
 // NonStruct defines TypeCodec methods that don't apply to non-struct types.
@@ -108,104 +500,176 @@ type NonStruct struct{}
 func (NonStruct) Fields() []string  { return nil }
 func (NonStruct) SetFieldMap([]int) {}
 
+// NoEncoder is embedded in TypeCodecs that were generated without an encoder.
+// Its Encode method fails.
+type NoEncoder struct{}
+
+func (NoEncoder) Encode(*Encoder, interface{}) { Failf("no encoder") }
+func (NoEncoder) noEncoder()                   {}
+
+// NoDecoder is embedded in TypeCodecs that were generated without a decoder.
+// Its Decode method fails.
+type NoDecoder struct{}
+
+func (NoDecoder) Decode(*Decoder) interface{} { Failf("no decoder"); return nil }
+func (NoDecoder) noDecoder()                  {}
+
+// hasNoEncoder reports whether tc was generated without an encoder.
+// An unregisteredCodec stands in for a type in encoded data that isn't
+// registered. Decoding a value of the type fails.
+type unregisteredCodec struct {
+	NonStruct
+	NoEncoder
+	name string
+}
+
+func (unregisteredCodec) TypesUsed() []reflect.Type { return nil }
+func (unregisteredCodec) SetCodecs([]TypeCodec)     {}
+
+func (c unregisteredCodec) Decode(*Decoder) interface{} {
+	Failf("unregistered type: %s", c.name)
+	return nil
+}
+
+func hasNoEncoder(tc TypeCodec) bool {
+	_, ok := tc.(interface{ noEncoder() })
+	return ok
+}
+
+// hasNoDecoder reports whether tc was generated without a decoder.
+func hasNoDecoder(tc TypeCodec) bool {
+	_, ok := tc.(interface{ noDecoder() })
+	return ok
+}
+
 type prim struct {
 	NonStruct
 }
 
 func (prim) TypesUsed() []reflect.Type { return nil }
 func (prim) SetCodecs([]TypeCodec)     {}
+func (prim) builtin()                  {}
+
+// builtinCodec is implemented by the TypeCodecs of the builtin types.
+type builtinCodec interface {
+	builtin()
+}
 
 type boolCodec struct{ prim }
 
-func (boolCodec) Encode(e *Encoder, x interface{}) { e.EncodeBool(x.(bool)) }
-func (boolCodec) Decode(d *Decoder) interface{}    { return d.DecodeBool() }
+func (boolCodec) Encode(e *Encoder, x interface{})     { e.EncodeBool(x.(bool)) }
+func (boolCodec) Decode(d *Decoder) interface{}        { return d.DecodeBool() }
+func (boolCodec) DecodeInto(d *Decoder, p interface{}) { *p.(*bool) = d.DecodeBool() }
 
 type bytesCodec struct{ prim }
 
-func (bytesCodec) Encode(e *Encoder, x interface{}) { e.EncodeBytes(x.([]byte)) }
-func (bytesCodec) Decode(d *Decoder) interface{}    { return d.DecodeBytes() }
+func (bytesCodec) Encode(e *Encoder, x interface{})     { e.EncodeBytes(x.([]byte)) }
+func (bytesCodec) Decode(d *Decoder) interface{}        { return d.DecodeBytes() }
+func (bytesCodec) DecodeInto(d *Decoder, p interface{}) { *p.(*[]byte) = d.DecodeBytes() }
 
 type stringCodec struct{ prim }
 
-func (stringCodec) Encode(e *Encoder, x interface{}) { e.EncodeString(x.(string)) }
-func (stringCodec) Decode(d *Decoder) interface{}    { return d.DecodeString() }
+func (stringCodec) Encode(e *Encoder, x interface{})     { e.EncodeString(x.(string)) }
+func (stringCodec) Decode(d *Decoder) interface{}        { return d.DecodeString() }
+func (stringCodec) DecodeInto(d *Decoder, p interface{}) { *p.(*string) = d.DecodeString() }
 
 type intCodec struct{ prim }
 
-func (intCodec) Encode(e *Encoder, x interface{}) { e.EncodeInt(int64(x.(int))) }
-func (intCodec) Decode(d *Decoder) interface{}    { return int(d.DecodeInt()) }
+func (intCodec) Encode(e *Encoder, x interface{})     { e.EncodeInt(int64(x.(int))) }
+func (intCodec) Decode(d *Decoder) interface{}        { return int(d.DecodeInt()) }
+func (intCodec) DecodeInto(d *Decoder, p interface{}) { *p.(*int) = int(d.DecodeInt()) }
 
 type int8Codec struct{ prim }
 
-func (int8Codec) Encode(e *Encoder, x interface{}) { e.writeByte(byte(x.(int8))) }
-func (int8Codec) Decode(d *Decoder) interface{}    { return int8(d.readByte()) }
+func (int8Codec) Encode(e *Encoder, x interface{})     { e.writeByte(byte(x.(int8))) }
+func (int8Codec) Decode(d *Decoder) interface{}        { return int8(d.readByte()) }
+func (int8Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*int8) = int8(d.readByte()) }
 
 type int16Codec struct{ prim }
 
-func (int16Codec) Encode(e *Encoder, x interface{}) { e.EncodeInt(int64(x.(int16))) }
-func (int16Codec) Decode(d *Decoder) interface{}    { return int16(d.DecodeInt()) }
+func (int16Codec) Encode(e *Encoder, x interface{})     { e.EncodeInt(int64(x.(int16))) }
+func (int16Codec) Decode(d *Decoder) interface{}        { return int16(d.DecodeInt()) }
+func (int16Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*int16) = int16(d.DecodeInt()) }
 
 type int32Codec struct{ prim }
 
-func (int32Codec) Encode(e *Encoder, x interface{}) { e.EncodeInt(int64(x.(int32))) }
-func (int32Codec) Decode(d *Decoder) interface{}    { return int32(d.DecodeInt()) }
+func (int32Codec) Encode(e *Encoder, x interface{})     { e.EncodeInt(int64(x.(int32))) }
+func (int32Codec) Decode(d *Decoder) interface{}        { return int32(d.DecodeInt()) }
+func (int32Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*int32) = int32(d.DecodeInt()) }
 
 type int64Codec struct{ prim }
 
-func (int64Codec) Encode(e *Encoder, x interface{}) { e.EncodeInt(x.(int64)) }
-func (int64Codec) Decode(d *Decoder) interface{}    { return d.DecodeInt() }
+func (int64Codec) Encode(e *Encoder, x interface{})     { e.EncodeInt(x.(int64)) }
+func (int64Codec) Decode(d *Decoder) interface{}        { return d.DecodeInt() }
+func (int64Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*int64) = d.DecodeInt() }
 
 type float32Codec struct{ prim }
 
-func (float32Codec) Encode(e *Encoder, x interface{}) { e.EncodeFloat(float64(x.(float32))) }
-func (float32Codec) Decode(d *Decoder) interface{}    { return float32(d.DecodeFloat()) }
+func (float32Codec) Encode(e *Encoder, x interface{})     { e.EncodeFloat(float64(x.(float32))) }
+func (float32Codec) Decode(d *Decoder) interface{}        { return float32(d.DecodeFloat()) }
+func (float32Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*float32) = float32(d.DecodeFloat()) }
 
 type float64Codec struct{ prim }
 
-func (float64Codec) Encode(e *Encoder, x interface{}) { e.EncodeFloat(x.(float64)) }
-func (float64Codec) Decode(d *Decoder) interface{}    { return d.DecodeFloat() }
+func (float64Codec) Encode(e *Encoder, x interface{})     { e.EncodeFloat(x.(float64)) }
+func (float64Codec) Decode(d *Decoder) interface{}        { return d.DecodeFloat() }
+func (float64Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*float64) = d.DecodeFloat() }
 
 type uintCodec struct{ prim }
 
-func (uintCodec) Encode(e *Encoder, x interface{}) { e.EncodeUint(uint64(x.(uint))) }
-func (uintCodec) Decode(d *Decoder) interface{}    { return uint(d.DecodeUint()) }
+func (uintCodec) Encode(e *Encoder, x interface{})     { e.EncodeUint(uint64(x.(uint))) }
+func (uintCodec) Decode(d *Decoder) interface{}        { return uint(d.DecodeUint()) }
+func (uintCodec) DecodeInto(d *Decoder, p interface{}) { *p.(*uint) = uint(d.DecodeUint()) }
 
 type uintptrCodec struct{ prim }
 
-func (uintptrCodec) Encode(e *Encoder, x interface{}) { e.EncodeUint(uint64(x.(uintptr))) }
-func (uintptrCodec) Decode(d *Decoder) interface{}    { return uintptr(d.DecodeUint()) }
+func (uintptrCodec) Encode(e *Encoder, x interface{})     { e.EncodeUint(uint64(x.(uintptr))) }
+func (uintptrCodec) Decode(d *Decoder) interface{}        { return uintptr(d.DecodeUint()) }
+func (uintptrCodec) DecodeInto(d *Decoder, p interface{}) { *p.(*uintptr) = uintptr(d.DecodeUint()) }
 
 type uint8Codec struct{ prim }
 
-func (uint8Codec) Encode(e *Encoder, x interface{}) { e.writeByte(byte(x.(uint8))) }
-func (uint8Codec) Decode(d *Decoder) interface{}    { return d.readByte() }
+func (uint8Codec) Encode(e *Encoder, x interface{})     { e.writeByte(byte(x.(uint8))) }
+func (uint8Codec) Decode(d *Decoder) interface{}        { return d.readByte() }
+func (uint8Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*uint8) = d.readByte() }
 
 type uint16Codec struct{ prim }
 
-func (uint16Codec) Encode(e *Encoder, x interface{}) { e.EncodeUint(uint64(x.(uint16))) }
-func (uint16Codec) Decode(d *Decoder) interface{}    { return uint16(d.DecodeUint()) }
+func (uint16Codec) Encode(e *Encoder, x interface{})     { e.EncodeUint(uint64(x.(uint16))) }
+func (uint16Codec) Decode(d *Decoder) interface{}        { return uint16(d.DecodeUint()) }
+func (uint16Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*uint16) = uint16(d.DecodeUint()) }
 
 type uint32Codec struct{ prim }
 
-func (uint32Codec) Encode(e *Encoder, x interface{}) { e.EncodeUint(uint64(x.(uint32))) }
-func (uint32Codec) Decode(d *Decoder) interface{}    { return uint32(d.DecodeUint()) }
+func (uint32Codec) Encode(e *Encoder, x interface{})     { e.EncodeUint(uint64(x.(uint32))) }
+func (uint32Codec) Decode(d *Decoder) interface{}        { return uint32(d.DecodeUint()) }
+func (uint32Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*uint32) = uint32(d.DecodeUint()) }
 
 type uint64Codec struct{ prim }
 
-func (uint64Codec) Encode(e *Encoder, x interface{}) { e.EncodeUint(x.(uint64)) }
-func (uint64Codec) Decode(d *Decoder) interface{}    { return d.DecodeUint() }
+func (uint64Codec) Encode(e *Encoder, x interface{})     { e.EncodeUint(x.(uint64)) }
+func (uint64Codec) Decode(d *Decoder) interface{}        { return d.DecodeUint() }
+func (uint64Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*uint64) = d.DecodeUint() }
 
 type complex64Codec struct{ prim }
 
 func (complex64Codec) Encode(e *Encoder, x interface{}) { e.EncodeComplex(complex128(x.(complex64))) }
 func (complex64Codec) Decode(d *Decoder) interface{}    { return complex64(d.DecodeComplex()) }
+func (complex64Codec) DecodeInto(d *Decoder, p interface{}) {
+	*p.(*complex64) = complex64(d.DecodeComplex())
+}
 
 type complex128Codec struct{ prim }
 
-func (complex128Codec) Encode(e *Encoder, x interface{}) { e.EncodeComplex(x.(complex128)) }
-func (complex128Codec) Decode(d *Decoder) interface{}    { return d.DecodeComplex() }
+func (complex128Codec) Encode(e *Encoder, x interface{})     { e.EncodeComplex(x.(complex128)) }
+func (complex128Codec) Decode(d *Decoder) interface{}        { return d.DecodeComplex() }
+func (complex128Codec) DecodeInto(d *Decoder, p interface{}) { *p.(*complex128) = d.DecodeComplex() }
 
-func reg(x interface{}, tcb func() TypeCodec) { Register(reflect.TypeOf(x), tcb) }
+func reg(x interface{}, tcb func() TypeCodec) {
+	t := reflect.TypeOf(x)
+	builtinBuilders[t] = tcb
+	Register(t, tcb)
+}
 
 func init() {
 	reg(false, func() TypeCodec { return boolCodec{} })
@@ -231,7 +695,7 @@ func init() {
 var BuiltinTypes []reflect.Type
 
 func init() {
-	for t := range typeCodecBuildersByType {
+	for t := range builtinBuilders {
 		BuiltinTypes = append(BuiltinTypes, t)
 	}
 }
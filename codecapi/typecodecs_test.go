@@ -6,6 +6,7 @@ package codecapi
 
 import (
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -86,3 +87,164 @@ func TestTypeStringUnique(t *testing.T) {
 		check(test.in, test.wantMapped, pkgPaths)
 	}
 }
+
+func TestLookupTypeAlias(t *testing.T) {
+	r := NewRegistry()
+	intType := reflect.TypeOf(0)
+	r.RegisterAlias("example.com/old.Int", intType)
+
+	regs := r.load()
+	if got := regs.lookupType("example.com/old.Int"); got != intType {
+		t.Errorf("got %v, want %v", got, intType)
+	}
+	if got := regs.lookupType("example.com/old.Other"); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"example.com/old.Int", "int"},
+		{"[]example.com/old.Int", "[]int"},
+		{"map[example.com/old.Int]*example.com/old.Int", "map[int]*int"},
+		{"struct { A example.com/old.Int; example.com/old.Intx }", "struct { A int; example.com/old.Intx }"},
+		{"[3]example.com/old.Int2", "[3]example.com/old.Int2"},
+	} {
+		if got := regs.replaceAliases(test.in); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+// TypeCodecs for testing duplicate registration.
+type (
+	dupCodec struct {
+		prim
+		fields []string
+	}
+	dupEncoder struct {
+		prim
+		NoDecoder
+	}
+	dupDecoder struct {
+		prim
+		NoEncoder
+	}
+)
+
+func (c dupCodec) Fields() []string              { return c.fields }
+func (dupCodec) Encode(*Encoder, interface{})    {}
+func (dupCodec) Decode(*Decoder) interface{}     { return nil }
+func (dupEncoder) Encode(*Encoder, interface{})  {}
+func (dupDecoder) Decode(d *Decoder) interface{} { return nil }
+
+func TestRegisterDuplicate(t *testing.T) {
+	type dup struct{ A int }
+	typ := reflect.TypeOf(dup{})
+	full := dupCodec{}
+	for _, test := range []struct {
+		first, second TypeCodec
+		enc, dec      TypeCodec // the codecs used to encode and decode; nil means panic
+	}{
+		{full, full, full, full},
+		{dupCodec{fields: []string{"A"}}, dupCodec{fields: []string{"A"}}, dupCodec{fields: []string{"A"}}, dupCodec{fields: []string{"A"}}},
+		{dupEncoder{}, full, full, full},
+		{full, dupDecoder{}, full, full},
+		{dupEncoder{}, dupEncoder{}, dupEncoder{}, dupEncoder{}},
+		{dupEncoder{}, dupDecoder{}, dupEncoder{}, dupDecoder{}},
+		{dupDecoder{}, dupEncoder{}, dupEncoder{}, dupDecoder{}},
+		{full, dupCodec{fields: []string{"A"}}, nil, nil},
+	} {
+		r := &Registry{}
+		first, second := test.first, test.second
+		r.Register(typ, func() TypeCodec { return first })
+		var enc, dec TypeCodec
+		func() {
+			defer func() { _ = recover() }()
+			r.Register(typ, func() TypeCodec { return second })
+			enc = r.load().buildersByType[typ]()
+			dec = r.load().decodeBuilder(typ)()
+		}()
+		if !reflect.DeepEqual(enc, test.enc) || !reflect.DeepEqual(dec, test.dec) {
+			t.Errorf("%#v, then %#v: got %#v and %#v, want %#v and %#v", first, second, enc, dec, test.enc, test.dec)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	type private struct{ A int }
+	typ := reflect.TypeOf(private{})
+	r := NewRegistry()
+	r.Register(typ, func() TypeCodec { return dupCodec{fields: []string{"A"}} })
+	if got, _ := r.LookupTypeCodec("int"); got != reflect.TypeOf(0) {
+		t.Errorf("builtin int: got %v", got)
+	}
+	name := TypeString(typ, nil)
+	if got, _ := r.LookupTypeCodec(name); got != typ {
+		t.Errorf("got %v, want %v", got, typ)
+	}
+	if got, _ := LookupTypeCodec(name); got != nil {
+		t.Errorf("DefaultRegistry has %v", got)
+	}
+
+	// Register types while looking them up.
+	var wg sync.WaitGroup
+	types := []reflect.Type{
+		reflect.TypeOf([]private(nil)),
+		reflect.TypeOf([1]private{}),
+		reflect.TypeOf(&private{}),
+		reflect.TypeOf(map[int]private{}),
+	}
+	for _, typ := range types {
+		typ := typ
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.Register(typ, func() TypeCodec { return dupCodec{} })
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				r.LookupTypeCodec(name)
+				r.Schema()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, typ := range types {
+		if got, _ := r.LookupTypeCodec(TypeString(typ, nil)); got != typ {
+			t.Errorf("got %v, want %v", got, typ)
+		}
+	}
+}
+
+func TestRegisterFrom(t *testing.T) {
+	type (
+		elem  struct{ A int }
+		other struct{ B int }
+	)
+	sliceType := reflect.TypeOf([]elem(nil))
+	elemType := reflect.TypeOf(elem{})
+	src := &Registry{}
+	src.Register(sliceType, func() TypeCodec { return dupCodec{} })
+	src.Register(elemType, func() TypeCodec { return dupCodec{fields: []string{"A"}} })
+	src.Register(reflect.TypeOf(other{}), func() TypeCodec { return dupCodec{fields: []string{"B"}} })
+	src.RegisterAlias("example.com/old.Elem", elemType)
+
+	r := &Registry{}
+	r.RegisterFrom(src, sliceType)
+	for _, name := range []string{TypeString(sliceType, nil), TypeString(elemType, nil), "example.com/old.Elem"} {
+		if got, _ := r.LookupTypeCodec(name); got == nil {
+			t.Errorf("%s is not registered", name)
+		}
+	}
+	if got, _ := r.LookupTypeCodec(TypeString(reflect.TypeOf(other{}), nil)); got != nil {
+		t.Errorf("unreachable type %s is registered", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("unregistered type: got no panic")
+		}
+	}()
+	r.RegisterFrom(src, reflect.TypeOf(0))
+}
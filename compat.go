@@ -0,0 +1,809 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jba/codec/codecapi"
+)
+
+// This file implements a check that data encoded with one version of
+// generated code can be decoded by another.
+//
+// Encoded data records the names of struct fields and, since version 2, their
+// kinds and the kinds of their elements. A decoder matches fields by name and
+// converts between compatible numeric kinds, so adding, removing and
+// reordering fields are all compatible changes. Changing a field's kind
+// usually isn't. The check compares the field names, kinds, element kinds and
+// types of each struct type, as they appear in the _fields, _kinds,
+// _elemKinds and _fieldTypes variables of a generated file, or as reported by
+// the registered TypeCodecs. The kinds tell it that a []int field has become a
+// []string, and the types that a []A field has become a []B, for different
+// structs A and B.
+
+// A Change is a difference between two versions of a type that affects
+// decoding.
+type Change struct {
+	Type  string // the type's full name, as returned by codecapi.TypeString(t, nil)
+	Field string // the encoded name of the field, or "" for a change to the type
+
+	// Breaking reports whether some data encoded with the old version can't be
+	// decoded, or decodes incorrectly, with the new one.
+	Breaking bool
+
+	Description string
+}
+
+func (c Change) String() string {
+	name := c.Type
+	if c.Field != "" {
+		name += "." + c.Field
+	}
+	s := name + ": " + c.Description
+	if c.Breaking {
+		s += " (breaking)"
+	}
+	return s
+}
+
+// CompareGeneratedFiles compares the types in two files generated by
+// GenerateFile for the package with the given path, and returns the changes
+// between them. Types are matched by name, and by the former names in the new
+// file from GenerateOptions.PreviousNames.
+func CompareGeneratedFiles(oldFile, newFile, packagePath string) ([]Change, error) {
+	old, err := readLayouts(oldFile, packagePath)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := readLayouts(newFile, packagePath)
+	if err != nil {
+		return nil, err
+	}
+	lookup := func(name string) (string, *typeLayout) {
+		if a, ok := cur.aliases[name]; ok {
+			name = a
+		}
+		return name, cur.types[name]
+	}
+	changes := compareLayouts(old.types, lookup)
+	for _, name := range sortedNames(cur.types) {
+		if _, ok := old.types[name]; !ok && !cur.renamed[name] {
+			changes = append(changes, Change{Type: name, Description: "added"})
+		}
+	}
+	return changes, nil
+}
+
+// CompareRegistered compares the types in a file generated by GenerateFile for
+// the package with the given path with the types registered in this program,
+// and returns the changes between them. Types are matched by name, and by the
+// former names registered with codecapi.RegisterAlias.
+func CompareRegistered(oldFile, packagePath string) ([]Change, error) {
+	old, err := readLayouts(oldFile, packagePath)
+	if err != nil {
+		return nil, err
+	}
+	lookup := func(name string) (string, *typeLayout) {
+		t, tc := codecapi.LookupTypeCodec(name)
+		if t == nil {
+			return "", nil
+		}
+		return codecapi.TypeString(t, nil), layoutOf(tc)
+	}
+	return compareLayouts(old.types, lookup), nil
+}
+
+// CheckCompatible reports whether data encoded with the code in oldFile, a file
+// generated by GenerateFile for the package with the given path, can be
+// decoded by this program. It returns an error describing the breaking changes
+// if there are any, and nil otherwise. A test can call it with a copy of a
+// previously released generated file to catch incompatible changes:
+//
+//	func TestCompatible(t *testing.T) {
+//		if err := codec.CheckCompatible("testdata/v1.gen.go", "example.com/mypkg"); err != nil {
+//			t.Error(err)
+//		}
+//	}
+func CheckCompatible(oldFile, packagePath string) error {
+	changes, err := CompareRegistered(oldFile, packagePath)
+	if err != nil {
+		return err
+	}
+	var msgs []string
+	for _, c := range changes {
+		if c.Breaking {
+			msgs = append(msgs, c.String())
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s has incompatible changes:\n%s", oldFile, strings.Join(msgs, "\n"))
+}
+
+// A typeLayout describes the aspects of a type's encoding that must stay the
+// same for its encoded data to remain decodable.
+type typeLayout struct {
+	isStruct  bool
+	fields    []string          // encoded field names
+	kinds     []reflect.Kind    // field kinds, in the same order; nil if unknown
+	elemKinds [][]reflect.Kind  // element kinds of the fields; nil if unknown
+	types     []*typeTree       // field types; nil if unknown, or for an unknown type
+	aliases   map[string]string // from former field name to current name
+	custom    string            // name of the custom codec, if any
+}
+
+// A typeTree describes a type by name, along with the element types of an
+// unnamed slice, array, pointer or map type.
+type typeTree struct {
+	name  string      // as returned by codecapi.TypeString
+	elems []*typeTree // the key type first, for a map
+}
+
+func reflectTypeTree(t reflect.Type) *typeTree {
+	tt := &typeTree{name: codecapi.TypeString(t, nil)}
+	if t.Name() == "" {
+		switch t.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Ptr:
+			tt.elems = []*typeTree{reflectTypeTree(t.Elem())}
+		case reflect.Map:
+			tt.elems = []*typeTree{reflectTypeTree(t.Key()), reflectTypeTree(t.Elem())}
+		}
+	}
+	return tt
+}
+
+func layoutOf(tc codecapi.TypeCodec) *typeLayout {
+	// The TypeCodec for a struct encoded some other way, like with a
+	// Marshaler or a proxy, has no fields.
+	l := &typeLayout{fields: tc.Fields()}
+	l.isStruct = l.fields != nil
+	if l.isStruct {
+		if fk, ok := tc.(codecapi.FieldKinder); ok {
+			l.kinds = fk.FieldKinds()
+		}
+		if fek, ok := tc.(codecapi.FieldElemKinder); ok {
+			l.elemKinds = fek.FieldElemKinds()
+		}
+		if ft, ok := tc.(codecapi.FieldTyper); ok {
+			for _, t := range ft.FieldTypes() {
+				var tt *typeTree
+				if t != nil {
+					tt = reflectTypeTree(t)
+				}
+				l.types = append(l.types, tt)
+			}
+		}
+		if fa, ok := tc.(codecapi.FieldAliaser); ok {
+			l.aliases = fa.FieldAliases()
+		}
+	}
+	if cn, ok := tc.(codecapi.CustomNamer); ok {
+		l.custom = cn.CustomName()
+	}
+	return l
+}
+
+// compareLayouts compares each of the old types with the new type returned by
+// lookup, which returns the current name of the type and its layout, or a nil
+// layout if there is no such type.
+func compareLayouts(old map[string]*typeLayout, lookup func(string) (string, *typeLayout)) []Change {
+	otherStruct := func(o, n string) bool {
+		if ol, ok := old[o]; !ok || !ol.isStruct {
+			return false
+		}
+		if cur, _ := lookup(o); cur == n {
+			return false
+		}
+		_, nl := lookup(n)
+		return nl != nil && nl.isStruct
+	}
+	var changes []Change
+	for _, name := range sortedNames(old) {
+		ol := old[name]
+		newName, nl := lookup(name)
+		if nl == nil {
+			changes = append(changes, Change{Type: name, Breaking: true, Description: "removed"})
+			continue
+		}
+		if newName != name {
+			changes = append(changes, Change{Type: name, Description: "renamed to " + newName})
+		}
+		changes = append(changes, compareLayout(name, ol, nl, otherStruct)...)
+	}
+	return changes
+}
+
+// compareLayout compares the old and new layouts of the type with the given
+// name. The function otherStruct reports whether the old struct type named o
+// has been replaced by the different struct type named n.
+func compareLayout(name string, ol, nl *typeLayout, otherStruct func(o, n string) bool) []Change {
+	change := func(field string, breaking bool, format string, args ...interface{}) Change {
+		return Change{Type: name, Field: field, Breaking: breaking, Description: fmt.Sprintf(format, args...)}
+	}
+	switch {
+	case ol.custom != nl.custom:
+		switch {
+		case nl.custom == "":
+			return []Change{change("", true, "no longer has custom codec %q", ol.custom)}
+		case ol.custom == "":
+			return []Change{change("", true, "now has custom codec %q", nl.custom)}
+		default:
+			return []Change{change("", true, "custom codec changed from %q to %q", ol.custom, nl.custom)}
+		}
+	case ol.isStruct && !nl.isStruct:
+		return []Change{change("", true, "no longer a struct")}
+	case !ol.isStruct && nl.isStruct:
+		return []Change{change("", true, "now a struct")}
+	case !ol.isStruct:
+		return nil
+	}
+
+	newIndex := map[string]int{}
+	for i, f := range nl.fields {
+		newIndex[f] = i
+	}
+	var changes []Change
+	matched := make([]bool, len(nl.fields))
+	for i, f := range ol.fields {
+		j, ok := newIndex[f]
+		if !ok {
+			if a, isAlias := nl.aliases[f]; isAlias {
+				j, ok = newIndex[a]
+			}
+		}
+		if !ok {
+			changes = append(changes, change(f, false, "removed; its encoded values are ignored"))
+			continue
+		}
+		matched[j] = true
+		if nl.fields[j] != f {
+			changes = append(changes, change(f, false, "renamed to %s", nl.fields[j]))
+		}
+		if i >= len(ol.kinds) || j >= len(nl.kinds) {
+			continue
+		}
+		from, to := ol.kinds[i], nl.kinds[j]
+		if from != to {
+			ok, exact := codecapi.CanConvertField(from, to)
+			switch {
+			case !ok:
+				changes = append(changes, change(f, true, "kind changed from %s to %s", from, to))
+			case !exact:
+				changes = append(changes, change(f, true, "kind changed from %s to %s; values that don't fit fail to decode", from, to))
+			default:
+				changes = append(changes, change(f, false, "kind changed from %s to %s", from, to))
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		// Compare the types of the field's elements.
+		var ot, nt *typeTree
+		if i < len(ol.types) && j < len(nl.types) {
+			ot, nt = ol.types[i], nl.types[j]
+		}
+		changed := func(ek string) string {
+			if ot != nil && nt != nil {
+				return fmt.Sprintf("type changed from %s to %s", ot.name, nt.name)
+			}
+			return ek
+		}
+		if i < len(ol.elemKinds) && j < len(nl.elemKinds) {
+			oek, nek := ol.elemKinds[i], nl.elemKinds[j]
+			ek := fmt.Sprintf("element kinds changed from %v to %v", oek, nek)
+			switch ok, exact := codecapi.CanConvertElems(to, oek, nek); {
+			case !ok:
+				changes = append(changes, change(f, true, "%s", changed(ek)))
+				continue
+			case !exact:
+				changes = append(changes, change(f, true, "%s; values that don't fit fail to decode", changed(ek)))
+			case !kindsEqual(oek, nek):
+				changes = append(changes, change(f, false, "%s", changed(ek)))
+			}
+		}
+		if replacedStruct(ot, nt, otherStruct) {
+			changes = append(changes, change(f, true, "type changed from %s to %s", ot.name, nt.name))
+		}
+	}
+	for j, f := range nl.fields {
+		if !matched[j] {
+			changes = append(changes, change(f, false, "added"))
+		}
+	}
+	return changes
+}
+
+// replacedStruct reports whether a different struct type has replaced a struct
+// type in old, in the same place in new.
+func replacedStruct(old, new *typeTree, otherStruct func(o, n string) bool) bool {
+	if old == nil || new == nil {
+		return false
+	}
+	if len(old.elems) == 0 || len(new.elems) == 0 {
+		return otherStruct(old.name, new.name)
+	}
+	if len(old.elems) != len(new.elems) {
+		return false
+	}
+	for i := range old.elems {
+		if replacedStruct(old.elems[i], new.elems[i], otherStruct) {
+			return true
+		}
+	}
+	return false
+}
+
+func kindsEqual(a, b []reflect.Kind) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedNames(m map[string]*typeLayout) []string {
+	var names []string
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A fileLayouts holds the information about types in a generated file.
+type fileLayouts struct {
+	types   map[string]*typeLayout // by full type name
+	aliases map[string]string      // from former type name to current name
+	renamed map[string]bool        // current names of types with former names
+}
+
+// readLayouts reads the layouts of the types in a file generated for the
+// package with the given path.
+func readLayouts(filename, packagePath string) (*fileLayouts, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	imports := map[string]string{} // from import identifier to path
+	for _, spec := range file.Imports {
+		ppath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Name != nil {
+			imports[spec.Name.Name] = ppath
+		} else {
+			imports[path.Base(ppath)] = ppath
+		}
+	}
+
+	// Collect the values of the variables and CustomName methods, by the type
+	// identifier that prefixes their names.
+	var (
+		typeNames   = map[string]string{} // from type ID to full name
+		fields      = map[string][]string{}
+		kinds       = map[string][]reflect.Kind{}
+		elemKinds   = map[string][][]reflect.Kind{}
+		fieldTypes  = map[string][]*typeTree{}
+		aliases     = map[string]map[string]string{}
+		customNames = map[string]string{}
+		aliasCalls  []*ast.CallExpr
+	)
+	bad := func(n ast.Node, format string, args ...interface{}) error {
+		return fmt.Errorf("%s: %s", fset.Position(n.Pos()), fmt.Sprintf(format, args...))
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Names) != 1 || len(vs.Values) != 1 {
+					continue
+				}
+				name, value := vs.Names[0].Name, vs.Values[0]
+				switch {
+				case strings.HasSuffix(name, "_type"):
+					expr := typeOfExpr(value)
+					if expr == nil {
+						return nil, bad(value, "unexpected value for %s", name)
+					}
+					tn, err := typeName(expr, packagePath, imports)
+					if err != nil {
+						return nil, bad(expr, "%v", err)
+					}
+					typeNames[strings.TrimSuffix(name, "_type")] = tn
+				case strings.HasSuffix(name, "_fields"):
+					ss, err := stringElements(value)
+					if err != nil {
+						return nil, bad(value, "%s: %v", name, err)
+					}
+					fields[strings.TrimSuffix(name, "_fields")] = ss
+				case strings.HasSuffix(name, "_kinds"):
+					ks, err := kindElements(value)
+					if err != nil {
+						return nil, bad(value, "%s: %v", name, err)
+					}
+					kinds[strings.TrimSuffix(name, "_kinds")] = ks
+				case strings.HasSuffix(name, "_elemKinds"):
+					eks, err := elemKindElements(value)
+					if err != nil {
+						return nil, bad(value, "%s: %v", name, err)
+					}
+					elemKinds[strings.TrimSuffix(name, "_elemKinds")] = eks
+				case strings.HasSuffix(name, "_fieldTypes"):
+					elts, err := compositeElements(value)
+					if err != nil {
+						return nil, bad(value, "%s: %v", name, err)
+					}
+					var tts []*typeTree
+					for _, el := range elts {
+						var tt *typeTree
+						if expr := typeOfExpr(el); expr != nil {
+							tt, err = astTypeTree(expr, packagePath, imports)
+							if err != nil {
+								return nil, bad(expr, "%v", err)
+							}
+						}
+						tts = append(tts, tt)
+					}
+					fieldTypes[strings.TrimSuffix(name, "_fieldTypes")] = tts
+				case strings.HasSuffix(name, "_aliases"):
+					m, err := stringMap(value)
+					if err != nil {
+						return nil, bad(value, "%s: %v", name, err)
+					}
+					aliases[strings.TrimSuffix(name, "_aliases")] = m
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Name.Name == "CustomName" && decl.Recv != nil {
+				id, s, ok := customName(decl)
+				if !ok {
+					return nil, bad(decl, "unexpected CustomName method")
+				}
+				customNames[id] = s
+			}
+			if decl.Name.Name == "init" && decl.Recv == nil {
+				for _, stmt := range decl.Body.List {
+					if es, ok := stmt.(*ast.ExprStmt); ok {
+						if call, ok := es.X.(*ast.CallExpr); ok && isSelector(call.Fun, "codecapi", "RegisterAlias") {
+							aliasCalls = append(aliasCalls, call)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	fl := &fileLayouts{
+		types:   map[string]*typeLayout{},
+		aliases: map[string]string{},
+		renamed: map[string]bool{},
+	}
+	for id, tn := range typeNames {
+		l := &typeLayout{custom: customNames[id]}
+		if fs, ok := fields[id]; ok {
+			l.isStruct = true
+			l.fields = fs
+			l.kinds = kinds[id]
+			l.elemKinds = elemKinds[id]
+			l.types = fieldTypes[id]
+			l.aliases = aliases[id]
+			if l.kinds != nil && len(l.kinds) != len(l.fields) {
+				return nil, fmt.Errorf("%s: %s has %d fields but %d kinds", filename, tn, len(l.fields), len(l.kinds))
+			}
+			if l.elemKinds != nil && len(l.elemKinds) != len(l.fields) {
+				return nil, fmt.Errorf("%s: %s has %d fields but %d element kinds", filename, tn, len(l.fields), len(l.elemKinds))
+			}
+			if l.types != nil && len(l.types) != len(l.fields) {
+				return nil, fmt.Errorf("%s: %s has %d fields but %d field types", filename, tn, len(l.fields), len(l.types))
+			}
+		}
+		fl.types[tn] = l
+	}
+	for _, call := range aliasCalls {
+		if len(call.Args) != 2 {
+			return nil, bad(call, "wrong number of arguments to RegisterAlias")
+		}
+		oldName, err := stringValue(call.Args[0])
+		if err != nil {
+			return nil, bad(call, "%v", err)
+		}
+		id, ok := call.Args[1].(*ast.Ident)
+		if !ok || typeNames[strings.TrimSuffix(id.Name, "_type")] == "" {
+			return nil, bad(call, "unknown type in RegisterAlias")
+		}
+		tn := typeNames[strings.TrimSuffix(id.Name, "_type")]
+		fl.aliases[oldName] = tn
+		fl.renamed[tn] = true
+	}
+	return fl, nil
+}
+
+// typeOfExpr returns the type expression T from an expression of the form
+// reflect.TypeOf((*T)(nil)).Elem(), or *T from reflect.TypeOf((*T)(nil)), or
+// nil if e has neither form.
+func typeOfExpr(e ast.Expr) ast.Expr {
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	elem := false
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Elem" {
+		if call, ok = sel.X.(*ast.CallExpr); !ok {
+			return nil
+		}
+		elem = true
+	}
+	if !isSelector(call.Fun, "reflect", "TypeOf") || len(call.Args) != 1 {
+		return nil
+	}
+	conv, ok := call.Args[0].(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	paren, ok := conv.Fun.(*ast.ParenExpr)
+	if !ok {
+		return nil
+	}
+	star, ok := paren.X.(*ast.StarExpr)
+	if !ok {
+		return nil
+	}
+	if elem {
+		return star.X
+	}
+	return star
+}
+
+// typeName returns the full name of the type expression e, as
+// codecapi.TypeString would, given the path of the package it occurs in and a
+// map from import identifiers to paths.
+func typeName(e ast.Expr, packagePath string, imports map[string]string) (string, error) {
+	elem := func(e ast.Expr) (string, error) { return typeName(e, packagePath, imports) }
+	switch e := e.(type) {
+	case *ast.Ident:
+		if _, ok := types.Universe.Lookup(e.Name).(*types.TypeName); ok || packagePath == "" {
+			return e.Name, nil
+		}
+		return packagePath + "." + e.Name, nil
+	case *ast.SelectorExpr:
+		id, ok := e.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		ppath, ok := imports[id.Name]
+		if !ok {
+			return "", fmt.Errorf("no import for %s", id.Name)
+		}
+		return ppath + "." + e.Sel.Name, nil
+	case *ast.StarExpr:
+		s, err := elem(e.X)
+		return "*" + s, err
+	case *ast.ArrayType:
+		s, err := elem(e.Elt)
+		if err != nil {
+			return "", err
+		}
+		if e.Len == nil {
+			return "[]" + s, nil
+		}
+		lit, ok := e.Len.(*ast.BasicLit)
+		if !ok {
+			break
+		}
+		return "[" + lit.Value + "]" + s, nil
+	case *ast.MapType:
+		k, err := elem(e.Key)
+		if err != nil {
+			return "", err
+		}
+		v, err := elem(e.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("map[%s]%s", k, v), nil
+	case *ast.InterfaceType:
+		return "interface{}", nil
+	case *ast.StructType:
+		var fields []string
+		for _, f := range e.Fields.List {
+			s, err := elem(f.Type)
+			if err != nil {
+				return "", err
+			}
+			if len(f.Names) == 0 {
+				fields = append(fields, s)
+			}
+			for _, n := range f.Names {
+				fields = append(fields, n.Name+" "+s)
+			}
+		}
+		return fmt.Sprintf("struct { %s }", strings.Join(fields, "; ")), nil
+	}
+	return "", fmt.Errorf("unexpected type expression %T", e)
+}
+
+// astTypeTree returns the typeTree for the type expression e, given the path
+// of the package it occurs in and a map from import identifiers to paths.
+func astTypeTree(e ast.Expr, packagePath string, imports map[string]string) (*typeTree, error) {
+	name, err := typeName(e, packagePath, imports)
+	if err != nil {
+		return nil, err
+	}
+	var elems []ast.Expr
+	switch e := e.(type) {
+	case *ast.StarExpr:
+		elems = []ast.Expr{e.X}
+	case *ast.ArrayType:
+		elems = []ast.Expr{e.Elt}
+	case *ast.MapType:
+		elems = []ast.Expr{e.Key, e.Value}
+	}
+	tt := &typeTree{name: name}
+	for _, el := range elems {
+		et, err := astTypeTree(el, packagePath, imports)
+		if err != nil {
+			return nil, err
+		}
+		tt.elems = append(tt.elems, et)
+	}
+	return tt, nil
+}
+
+// customName returns the type identifier and the result of a generated
+// CustomName method.
+func customName(decl *ast.FuncDecl) (id, name string, ok bool) {
+	if len(decl.Recv.List) != 1 || len(decl.Body.List) != 1 {
+		return "", "", false
+	}
+	star, ok := decl.Recv.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return "", "", false
+	}
+	recv, ok := star.X.(*ast.Ident)
+	if !ok || !strings.HasSuffix(recv.Name, "_codec") {
+		return "", "", false
+	}
+	ret, ok := decl.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return "", "", false
+	}
+	name, err := stringValue(ret.Results[0])
+	if err != nil {
+		return "", "", false
+	}
+	return strings.TrimSuffix(recv.Name, "_codec"), name, true
+}
+
+func isSelector(e ast.Expr, x, sel string) bool {
+	s, ok := e.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	id, ok := s.X.(*ast.Ident)
+	return ok && id.Name == x && s.Sel.Name == sel
+}
+
+func stringValue(e ast.Expr) (string, error) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", errors.New("not a string literal")
+	}
+	return strconv.Unquote(lit.Value)
+}
+
+func compositeElements(e ast.Expr) ([]ast.Expr, error) {
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok {
+		return nil, errors.New("not a composite literal")
+	}
+	return lit.Elts, nil
+}
+
+func stringElements(e ast.Expr) ([]string, error) {
+	elts, err := compositeElements(e)
+	if err != nil {
+		return nil, err
+	}
+	ss := []string{}
+	for _, el := range elts {
+		s, err := stringValue(el)
+		if err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+func stringMap(e ast.Expr) (map[string]string, error) {
+	elts, err := compositeElements(e)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]string{}
+	for _, el := range elts {
+		kv, ok := el.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, errors.New("not a key-value pair")
+		}
+		k, err := stringValue(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := stringValue(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+// elemKindElements returns the element kinds of the fields in a generated
+// _elemKinds variable.
+func elemKindElements(e ast.Expr) ([][]reflect.Kind, error) {
+	elts, err := compositeElements(e)
+	if err != nil {
+		return nil, err
+	}
+	eks := [][]reflect.Kind{}
+	for _, el := range elts {
+		if id, ok := el.(*ast.Ident); ok && id.Name == "nil" {
+			eks = append(eks, nil)
+			continue
+		}
+		ks, err := kindElements(el)
+		if err != nil {
+			return nil, err
+		}
+		eks = append(eks, ks)
+	}
+	return eks, nil
+}
+
+func kindElements(e ast.Expr) ([]reflect.Kind, error) {
+	elts, err := compositeElements(e)
+	if err != nil {
+		return nil, err
+	}
+	ks := []reflect.Kind{}
+	for _, el := range elts {
+		sel, ok := el.(*ast.SelectorExpr)
+		if !ok || !isSelector(el, "reflect", sel.Sel.Name) {
+			return nil, errors.New("not a reflect.Kind constant")
+		}
+		// The constants are the capitalized names of the kinds.
+		name := sel.Sel.Name
+		k, ok := codecapi.KindByName(strings.ToLower(name[:1]) + name[1:])
+		if !ok {
+			return nil, fmt.Errorf("unknown kind %s", sel.Sel.Name)
+		}
+		ks = append(ks, k)
+	}
+	return ks, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeGenerated writes the body of a generated file to a temporary directory
+// and returns its name.
+func writeGenerated(t *testing.T, name, body string) string {
+	t.Helper()
+	src := `package codec
+
+import (
+	"reflect"
+
+	"github.com/jba/codec/codecapi"
+	foo "github.com/jba/codec/internal/testpkg"
+)
+` + body
+	filename := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestCompareGeneratedFiles(t *testing.T) {
+	oldFile := writeGenerated(t, "old.go", `
+var S_type = reflect.TypeOf((*S)(nil)).Elem()
+var S_fields = []string{"A", "B", "C", "D", "E", "F"}
+var S_kinds = []reflect.Kind{reflect.Int, reflect.Int32, reflect.Int64, reflect.String, reflect.Int, reflect.Slice}
+
+var slice_S_type = reflect.TypeOf((*[]S)(nil)).Elem()
+var foo_T_type = reflect.TypeOf((*foo.T)(nil)).Elem()
+var Old_type = reflect.TypeOf((*Old)(nil)).Elem()
+
+var M_type = reflect.TypeOf((*M)(nil)).Elem()
+func (c *M_codec) CustomName() string { return "m/v1" }
+
+var A_type = reflect.TypeOf((*A)(nil)).Elem()
+var A_fields = []string{"X"}
+var B_type = reflect.TypeOf((*B)(nil)).Elem()
+var B_fields = []string{"X"}
+
+var E_type = reflect.TypeOf((*E)(nil)).Elem()
+var E_fields = []string{"L", "St", "M", "W"}
+var E_kinds = []reflect.Kind{reflect.Slice, reflect.Slice, reflect.Map, reflect.Slice}
+var E_elemKinds = [][]reflect.Kind{{reflect.Int}, {reflect.Struct}, {reflect.String, reflect.Int}, {reflect.Int32}}
+var E_fieldTypes = []reflect.Type{reflect.TypeOf((*[]int)(nil)).Elem(), reflect.TypeOf((*[]A)(nil)).Elem(), reflect.TypeOf((*map[string]int)(nil)).Elem(), reflect.TypeOf((*[]int32)(nil)).Elem()}
+`)
+	newFile := writeGenerated(t, "new.go", `
+var S_type = reflect.TypeOf((*S)(nil)).Elem()
+var S_fields = []string{"A", "B", "C", "D", "F", "G", "H"}
+var S_kinds = []reflect.Kind{reflect.Int, reflect.Int64, reflect.Int32, reflect.Int, reflect.Array, reflect.Bool, reflect.Int}
+var S_aliases = map[string]string{
+	"E": "H",
+}
+
+var foo_T_type = reflect.TypeOf((*foo.T)(nil)).Elem()
+var New_type = reflect.TypeOf((*New)(nil)).Elem()
+var Added_type = reflect.TypeOf((*Added)(nil)).Elem()
+
+var M_type = reflect.TypeOf((*M)(nil)).Elem()
+func (c *M_codec) CustomName() string { return "m/v2" }
+
+var A_type = reflect.TypeOf((*A)(nil)).Elem()
+var A_fields = []string{"X"}
+var B_type = reflect.TypeOf((*B)(nil)).Elem()
+var B_fields = []string{"X"}
+
+var E_type = reflect.TypeOf((*E)(nil)).Elem()
+var E_fields = []string{"L", "St", "M", "W"}
+var E_kinds = []reflect.Kind{reflect.Slice, reflect.Slice, reflect.Map, reflect.Slice}
+var E_elemKinds = [][]reflect.Kind{{reflect.String}, {reflect.Struct}, {reflect.String, reflect.String}, {reflect.Int64}}
+var E_fieldTypes = []reflect.Type{reflect.TypeOf((*[]string)(nil)).Elem(), reflect.TypeOf((*[]B)(nil)).Elem(), reflect.TypeOf((*map[string]string)(nil)).Elem(), reflect.TypeOf((*[]int64)(nil)).Elem()}
+
+func init() {
+	codecapi.RegisterAlias("github.com/jba/codec.Old", New_type)
+}
+`)
+	got, err := CompareGeneratedFiles(oldFile, newFile, "github.com/jba/codec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const p = "github.com/jba/codec."
+	want := []Change{
+		{Type: "[]" + p + "S", Breaking: true, Description: "removed"},
+		{Type: p + "E", Field: "L", Breaking: true, Description: "type changed from []int to []string"},
+		{Type: p + "E", Field: "St", Breaking: true, Description: "type changed from []" + p + "A to []" + p + "B"},
+		{Type: p + "E", Field: "M", Breaking: true, Description: "type changed from map[string]int to map[string]string"},
+		{Type: p + "E", Field: "W", Description: "type changed from []int32 to []int64"},
+		{Type: p + "M", Breaking: true, Description: `custom codec changed from "m/v1" to "m/v2"`},
+		{Type: p + "Old", Description: "renamed to " + p + "New"},
+		{Type: p + "S", Field: "B", Description: "kind changed from int32 to int64"},
+		{Type: p + "S", Field: "C", Breaking: true, Description: "kind changed from int64 to int32; values that don't fit fail to decode"},
+		{Type: p + "S", Field: "D", Breaking: true, Description: "kind changed from string to int"},
+		{Type: p + "S", Field: "E", Description: "renamed to H"},
+		{Type: p + "S", Field: "F", Breaking: true, Description: "kind changed from slice to array; values that don't fit fail to decode"},
+		{Type: p + "S", Field: "G", Description: "added"},
+		{Type: p + "Added", Description: "added"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	// The generated file for this package is compatible with itself.
+	if err := CheckCompatible("types.gen_test.go", "github.com/jba/codec"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An older version of some of the types in types.gen_test.go.
+	oldFile := writeGenerated(t, "old.go", `
+var node_type = reflect.TypeOf((*node)(nil)).Elem()
+var node_fields = []string{"Value", "Next"}
+var node_kinds = []reflect.Kind{reflect.String, reflect.Ptr}
+
+var convOld_type = reflect.TypeOf((*convOld)(nil)).Elem()
+var convOld_fields = []string{"I", "U", "F", "S", "D"}
+var convOld_kinds = []reflect.Kind{reflect.Int8, reflect.Int, reflect.Float64, reflect.Slice, reflect.Slice}
+
+var money_type = reflect.TypeOf((*money)(nil)).Elem()
+func (c *money_codec) CustomName() string { return "money/v0" }
+
+var removed_type = reflect.TypeOf((*removed)(nil)).Elem()
+`)
+	err := CheckCompatible(oldFile, "github.com/jba/codec")
+	if err == nil {
+		t.Fatal("got nil, want error")
+	}
+	for _, want := range []string{
+		"codec.node.Value: kind changed from string to int",
+		`codec.money: custom codec changed from "money/v0" to "money/v1"`,
+		"codec.removed: removed",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not contain %q:\n%v", want, err)
+		}
+	}
+	// convOld's fields were all converted compatibly.
+	if strings.Contains(err.Error(), "convOld") {
+		t.Errorf("error mentions convOld:\n%v", err)
+	}
+}
@@ -46,12 +46,66 @@ so if you like:
 
     //go:generate go run generate.go
 
+Because that program imports your package, it fails if the package doesn't
+compile, as when the generated file is missing or out of date. The codecgen
+command doesn't have that problem. It loads your package from source with
+GenerateFileFromSource, and generates the same code as GenerateFile for the
+types you name and all types they contain:
+
+    //go:generate go run github.com/jba/codec/cmd/codecgen -type Type1,Type2
+
+Since it takes type names rather than values, codecgen generates code for an
+unnamed type like []mypkg.Type1 only if a named type contains it. It doesn't
+support the Custom, Proxies and PreviousNames options.
+
+Several packages in a program may generate code for the same type, such as
+[]string or a type from another package. Each registers its codec when it is
+initialized, and that is fine as long as the codecs agree on how to encode the
+type. Codecs generated with options that change the encoding, like a
+different FieldTag or a custom codec, don't agree, and the program panics
+during initialization.
+
 On subsequent runs, the generator reads the generated file to get the names and
 order of all struct fields. It uses this information to generate correct code
 when fields are moved or added. Make sure the old generated files remain
 available to the generator, or changes to your structs may result in existing
 encoded data being decoded incorrectly.
 
+Types that implement Marshaler and Unmarshaler encode and decode themselves.
+For a type you can't add methods to, such as one from another module, provide
+functions to encode and decode it in GenerateOptions.Custom:
+
+	opts := &codec.GenerateOptions{
+		Custom: map[reflect.Type]codec.CustomCodec{
+			reflect.TypeOf(decimal.Decimal{}): {
+				Encode: mypkg.EncodeDecimal,
+				Decode: mypkg.DecodeDecimal,
+				Name:   "decimal/v1",
+			},
+		},
+	}
+
+The name is recorded with the encoded data, and decoding checks that it
+matches.
+
+A type whose fields shouldn't be encoded, like one holding a mutex or a cache,
+can be encoded as a simpler proxy type instead, by giving its pointer type
+ToCodec and FromCodec methods:
+
+	func (x *Index) ToCodec() IndexData { ... }
+	func (x *Index) FromCodec(d IndexData) error { ... }
+
+The generated code converts an Index to an IndexData before encoding it, and
+back after decoding. Since the proxy's fields are encoded like any other
+struct's, the proxy can change in all the ways described below. For types you
+can't add methods to, use GenerateOptions.Proxies.
+
+A program that only writes encoded data, or only reads it, can set
+GenerateOptions.EncodeOnly or DecodeOnly to generate half the code. Encoding a
+type whose codec was generated with DecodeOnly, or decoding one generated with
+EncodeOnly, fails with an error, unless another package linked into the
+program generated the missing half.
+
 
 Encoding and Decoding
 
@@ -73,6 +127,55 @@ To decode, pass an io.Reader to NewDecoder, and call Decode:
    err := d.Decode(&value)
    ...
 
+To encode a single value to a byte slice, use Marshal, or AppendEncode to
+append it to a slice you own. Decode the result with Unmarshal:
+
+   data, err := codec.Marshal(x)
+   ...
+   err = codec.Unmarshal(data, &value)
+
+To layer encoded data on top of an existing value, set DecodeOptions.Merge.
+Fields absent from the encoded data keep their values, map entries are added
+to the existing map, and existing pointers are followed. Slices are replaced,
+unless DecodeOptions.AppendSlices is also set. Existing values don't get
+defaults, but the values that decoding creates, like new map entries, do:
+
+   cfg := defaultConfig()
+   d := codec.NewDecoder(f, &codec.DecodeOptions{Merge: true})
+   err := d.Decode(&cfg)
+
+To encode a large top-level slice or map on several goroutines, set
+EncodeOptions.Parallelism. The result is the same encoded value, and it is
+decoded as usual.
+
+A stream of many values can be decoded on several goroutines with a
+ParallelDecoder, which delivers the values in the order they were encoded:
+
+   pd := codec.NewParallelDecoder(f, 0, nil)
+   err := pd.Decode(
+      func() interface{} { return new(Record) },
+      func(p interface{}) error { return process(p.(*Record)) })
+
+Encoders and Decoders can be reused with their Reset methods, which keep their
+buffers and caches. To reuse them across goroutines, for instance to encode one
+value per request, use an EncoderPool or DecoderPool:
+
+   var encoders = codec.NewEncoderPool(nil)
+   ...
+   e := encoders.Get(w)
+   defer encoders.Put(e)
+   err := e.Encode(resp)
+
+To find out what makes encoded data large, set EncodeOptions.Stats. The
+encoder adds to it the number of values of each type and their encoded size,
+the bytes of metadata and of data, and the number of shared-pointer
+references. The Encoders of an EncoderPool can share one EncodeStats.
+
+To examine or hand-edit encoded data, convert it to JSON with ToJSON, and back
+with FromJSON. Neither needs the types the data was encoded from: they use the
+type names and struct fields recorded with each value. The codec command in
+cmd/codec does the same from the command line.
+
 
 Sharing and Cycles
 
@@ -103,7 +206,80 @@ An example:
     }
 
 Here, field A will use the name "B" and field C will be omitted. There is no
-need for the omitempty option because the encoder always omits zero values.
+need for the omitempty option because the encoder omits zero values by default.
+
+
+Zero and Absent Fields
+
+Since zero values are not encoded, a decoder cannot distinguish a field that
+was set to its zero value from one that was never set. To encode a field even
+when it is zero, use the "always" option:
+
+    type Update struct {
+        Count int `codec:",always"`
+    }
+
+To find out which fields were present in the encoded data, add a field of type
+Presence. The decoder sets it to record the fields it saw:
+
+    type Update struct {
+        Count int `codec:",always"`
+        Name  string
+        Seen  codec.Presence
+    }
+
+    var u Update
+    if err := d.Decode(&u); err != nil { ... }
+    if u.Seen.Has("Count") { ... }
+
+
+Required Fields and Defaults
+
+A field with the "required" option must be present in the encoded data, or
+decoding fails with an error naming the struct and field. A field with the
+"default=<value>" option is set to value if it is absent. The value must be a
+valid literal for the field's type, which must be a boolean, numeric or string
+type; GenerateFile checks it. It cannot contain a comma. Required fields and
+fields with defaults are encoded even when they are zero, so that a zero value
+is not mistaken for an absent one.
+
+    type Config struct {
+        Name    string `codec:",required"`
+        Retries int    `codec:",default=3"`
+    }
+
+For defaults that can't be written in a tag, implement Defaulter.
+
+Embedded Structs
+
+As in encoding/json, the fields of an embedded struct are promoted: they are
+encoded as if they were fields of the outer struct. So are the fields of an
+embedded pointer to a struct. Giving an embedded struct a name in its tag makes
+it an ordinary field, and the "inline" option promotes the fields of a
+non-embedded struct field:
+
+    type T struct {
+        Inner                         // Inner's fields are promoted
+        Other Inner `codec:",inline"` // so are Other's
+    }
+
+If more than one field has the same name, the rules of encoding/json decide
+which one is encoded. Since promoted fields are recorded by name, a field can
+be moved into or out of an embedded struct without affecting the decoding of
+existing data.
+
+An embedded pointer is allocated on decoding only if one of its fields is
+present in the encoded data. A nil embedded pointer isn't encoded, so a required
+field promoted through one is required only when another field promoted
+through the same pointer is present.
+
+Unlike encoding/json, an embedded struct whose type implements Marshaler and
+Unmarshaler, or the BinaryMarshaler or TextMarshaler pairs of the encoding
+package, is not promoted. It is encoded as a single field, named after its
+type, with its own methods.
+
+
+Renaming Fields
 
 Since the encoding uses numbers for fields instead of names, renaming a field
 doesn't actually affect the encoding. It does matter if subsequent changes are
@@ -122,9 +298,111 @@ but you rename the field to "B":
 The generator will treat "B" as a new field. Data encoded with "A" will not be
 decoded into "B". So you should use a tag to express that it is a renaming:
 
+    type T struct {
+        B int `codec:",alias=A"`
+    }
+
+The field is encoded with its new name, "B", and data encoded with "A" is
+decoded into it. A field can have more than one alias, so a field that has been
+renamed several times can list all its former names:
+
+    type T struct {
+        C int `codec:",alias=B,alias=A"`
+    }
+
+An alias cannot be the name of another field, or an alias of another field.
+
+Alternatively, you can keep the old name in the encoding by using it as the
+field's name:
+
     type T struct {
         B int `codec:"A"`
     }
 
+Moving and Renaming Types
+
+Encoded data identifies each type by its full name, including its package path.
+If you move a type to another package or rename it, data encoded with the old
+name will not decode unless you record the old name. Use the PreviousNames field
+of GenerateOptions:
+
+    codec.GenerateFile("types.gen.go", "example.com/a/internal/model",
+        &codec.GenerateOptions{
+            PreviousNames: map[reflect.Type][]string{
+                reflect.TypeOf(model.T{}): {"example.com/a/v1.T"},
+            },
+        },
+        model.T{})
+
+You can also call codecapi.RegisterAlias directly.
+
+Changing Field Types
+
+The type of a struct field can be changed, and data encoded with the old type
+will be converted to the new one when it is decoded, provided the types are
+compatible. Compatible changes include
+
+  - from one numeric type to another, like int32 to int64 or int to float64;
+  - from a defined type to its underlying type, or the reverse;
+  - from a slice to an array, or the reverse;
+  - from one numeric element type of a slice or array to another, or one
+    numeric key or element type of a map to another, like []int64 to []int32
+    or map[string]int to map[string]float64.
+
+A conversion that would lose information fails: for example, decoding 300 into
+an int8, -1 into a uint, 1.5 into an int, 1<<40 into an element of a []int32,
+or a slice of length 4 into an array of length 3. Converting a float64 to a
+float32 may round the value, but must not overflow. An array decoded from a
+shorter slice is padded with zero values.
+
+Decoding a field fails if its element type changed in a way that can't be
+converted, like from []int to []string or []byte to []int, or in a way that
+would require converting elements nested more deeply, like from [][]int64 to
+[][]int32 or *int64 to *int32. Nested changes that need no conversion, like
+from [][]int32 to [][]int64, are allowed.
+
+The value passed to Decoder.Decode is converted in the same ways, except that
+maps are not converted. Other changes to a field's type are not detected during
+decoding, and will result in decoding errors or incorrect values.
+
+Recording the field kinds changed the header of encoded streams, which matters
+when readers and writers are upgraded separately; see the compatibility note at
+https://github.com/jba/codec#compatibility.
+
+To catch such changes before they are released, keep a copy of the file
+generated for the last release and compare it with the current types in a test:
+
+    if err := codec.CheckCompatible("testdata/v1.gen.go", "example.com/mypkg"); err != nil {
+        t.Error(err)
+    }
+
+CheckCompatible reports changes to the kinds of fields and their elements,
+like from int to string or []int to []string, fields whose struct types, or
+struct element types, were replaced by others, as well as removed types and
+changed custom codecs. To see all changes,
+breaking or not, use CompareRegistered or CompareGeneratedFiles.
+
+To describe the registered types, with their fields, field types and the kinds
+they are encoded as, call codecapi.RegisteredSchema. The Schema it returns can
+be marshaled to JSON. Its Fingerprint identifies it, and setting
+EncodeOptions.SchemaFingerprint records the fingerprint of the encoded types'
+schema with each value, where Decoder.SchemaFingerprint can retrieve it.
+
+Generated code registers its types in codecapi.DefaultRegistry. To encode or
+decode with a different set of types, as in a test or a plugin host, create a
+Registry with codecapi.NewRegistry, register types in it, and set the Registry
+field of EncodeOptions or DecodeOptions. Registry.RegisterFrom copies types,
+along with the types they use, from another Registry, such as the default one.
+Registering is safe while other goroutines encode and decode.
+
+When decoding fails, the error is a *codecapi.DecodeError. It holds the index
+of the failing value in the stream, the offset where decoding stopped, and the
+path to the part of the value that failed, like
+"ast.File.Decls[12].(*ast.FuncDecl).Body.List[3]". To find the path without
+slowing down decoding that succeeds, the Decoder decodes the failing value a
+second time, into a new value, so custom decoders and unmarshal methods may be
+called twice. Code generated before paths were added doesn't contribute to
+them until it is regenerated.
+
 */
 package codec
@@ -7,14 +7,20 @@ package codec
 import (
 	"bytes"
 	"encoding"
+	"errors"
 	"fmt"
 	"go/format"
+	"go/token"
 	"io"
 	"io/ioutil"
+	"math"
+	"math/cmplx"
 	"os"
 	"path"
 	"reflect"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -27,6 +33,94 @@ type GenerateOptions struct {
 	// FieldTag is the name that GenerateFile will use to look up
 	// field tag information. The default is "codec".
 	FieldTag string
+
+	// PreviousNames maps a type to names it was formerly known by, for types
+	// that have been moved to another package or renamed. Each name should be
+	// the full name of the type, as returned by codecapi.TypeString(t, nil);
+	// for example, "example.com/a/v1.T". The generated code registers the
+	// names so that data encoded with them can be decoded. Each type must be
+	// one for which a codec is generated.
+	PreviousNames map[reflect.Type][]string
+
+	// Custom maps a type to functions that encode and decode it in place of
+	// generated code. Use it for types that you can't add methods to, like
+	// types from other modules. The generated code calls the functions
+	// wherever the type occurs.
+	Custom map[reflect.Type]CustomCodec
+
+	// Proxies maps a type to functions that convert it to and from a proxy
+	// type, which is encoded in its place. It has the same effect as giving
+	// the type ToCodec and FromCodec methods; see Proxy.
+	Proxies map[reflect.Type]Proxy
+
+	// If EncodeOnly is true, only encoders are generated, making the
+	// generated file and the programs that use it smaller. Decoding a type
+	// whose codec was generated this way fails.
+	EncodeOnly bool
+
+	// If DecodeOnly is true, only decoders are generated. Encoding a type
+	// whose codec was generated this way fails.
+	DecodeOnly bool
+}
+
+// A Proxy describes a pair of functions that convert a type T to and from a
+// proxy type P, for types you can't add methods to. Like the functions of a
+// CustomCodec, they must be top-level functions.
+//
+// A type can instead name its proxy with methods on its pointer type:
+//
+//   func (*T) ToCodec() P
+//   func (*T) FromCodec(P) error
+//
+// Use a proxy for a type whose fields shouldn't or can't be encoded, like
+// caches, mutexes or indexes built from other fields. The generated codec for T
+// converts values to P and uses P's generated codec, so P's field names are
+// recorded with the encoded data, and P can change over time as described under
+// "Renaming Fields" and "Changing Field Types" in the package documentation.
+type Proxy struct {
+	// To is a function of type
+	//   func(*T) P
+	To interface{}
+
+	// From is a function of type
+	//   func(*T, P) error
+	// It sets its first argument from the proxy value.
+	From interface{}
+}
+
+// A CustomCodec describes a pair of functions that encode and decode a type T.
+// They must be top-level functions, not closures or methods, and they must be
+// exported if they are in a package other than the generated one.
+type CustomCodec struct {
+	// Encode is a function of type
+	//   func(*codecapi.Encoder, T) error
+	// It must encode exactly one value, as described for Marshaler.
+	Encode interface{}
+
+	// Decode is a function of type
+	//   func(*codecapi.Decoder, *T) error
+	// It must decode the value written by Encode.
+	Decode interface{}
+
+	// Name identifies the encoding, and is recorded with encoded data. Data
+	// encoded by a custom codec can only be decoded by a custom codec with the
+	// same name, so the name should change if the encoding does. If Name is
+	// empty, the full name of the Encode function is used.
+	Name string
+}
+
+// A proxyFuncs holds the resolved functions of a Proxy.
+type proxyFuncs struct {
+	proxyType        genType
+	toPkg, toFun     string
+	fromPkg, fromFun string
+}
+
+// A customFuncs holds the resolved functions of a CustomCodec.
+type customFuncs struct {
+	name                 string
+	encodePkg, encodeFun string
+	decodePkg, decodeFun string
 }
 
 // GenerateFile writes encoders and decoders to filename. It generates code for
@@ -40,28 +134,66 @@ func GenerateFile(filename, packagePath string, opts *GenerateOptions, values ..
 	if err != nil {
 		return err
 	}
-	fieldTag := "codec"
-	if opts != nil && opts.FieldTag != "" {
-		fieldTag = opts.FieldTag
-	}
-	if err := generate(f, packagePath, fieldTag, values...); err != nil {
+	if err := generate(f, packagePath, opts, values...); err != nil {
 		_ = f.Close()
 		return err
 	}
 	return f.Close()
 }
 
-func generate(w io.Writer, packagePath string, fieldTag string, vs ...interface{}) error {
+func generate(w io.Writer, packagePath string, opts *GenerateOptions, vs ...interface{}) error {
+	var types []genType
+	for _, v := range vs {
+		types = append(types, rtype{reflect.TypeOf(v)})
+	}
+	return generateTypes(w, packagePath, opts, reflectInterfaces, types)
+}
+
+// generateTypes writes code for types, which are described in the same way as
+// the interface types in ifaces.
+func generateTypes(w io.Writer, packagePath string, opts *GenerateOptions, ifaces interfaceTypes, types []genType) error {
 	g := &generator{
-		pkgPath:     packagePath,
-		fieldTagKey: fieldTag,
+		pkgPath:        packagePath,
+		fieldTagKey:    "codec",
+		encoders:       true,
+		decoders:       true,
+		interfaceTypes: ifaces,
+	}
+	if opts != nil {
+		if opts.EncodeOnly && opts.DecodeOnly {
+			return errors.New("EncodeOnly and DecodeOnly are both set")
+		}
+		g.encoders = !opts.DecodeOnly
+		g.decoders = !opts.EncodeOnly
+		if opts.FieldTag != "" {
+			g.fieldTagKey = opts.FieldTag
+		}
+		if len(opts.PreviousNames) > 0 {
+			g.previousNames = map[genType][]string{}
+			for t, names := range opts.PreviousNames {
+				g.previousNames[rtype{t}] = names
+			}
+		}
+		if err := g.resolveCustom(opts.Custom); err != nil {
+			return err
+		}
+		if err := g.resolveProxies(opts.Proxies); err != nil {
+			return err
+		}
 	}
 	funcs := template.FuncMap{
-		"typeID":     g.typeID,
-		"goName":     g.goName,
-		"encodeStmt": g.encodeStmt,
-		"decodeStmt": g.decodeStmt,
-		"encodeFunc": g.encodeFunc,
+		"typeID":      g.typeID,
+		"goName":      g.goName,
+		"encodeStmt":  g.encodeStmt,
+		"decodeStmt":  g.decodeStmt,
+		"encodeFunc":  g.encodeFunc,
+		"encodeCond":  g.encodeCond,
+		"wireKind":    g.wireKind,
+		"elemKinds":   g.elemKinds,
+		"numeric":     g.numeric,
+		"reflectType": g.reflectType,
+		"encoders":    func() bool { return g.encoders },
+		"decoders":    func() bool { return g.decoders },
 	}
 
 	newTemplate := func(name, body string) *template.Template {
@@ -75,8 +207,10 @@ func generate(w io.Writer, packagePath string, fieldTag string, vs ...interface{
 	g.ptrTemplate = newTemplate("ptr", ptrBody)
 	g.structTemplate = newTemplate("struct", structBody)
 	g.marshalTemplate = newTemplate("marshaler", marshalBody)
+	g.customTemplate = newTemplate("custom", customBody)
+	g.proxyTemplate = newTemplate("proxy", proxyBody)
 
-	src, err := g.generate(vs)
+	src, err := g.generate(types)
 	if err != nil {
 		return err
 	}
@@ -110,6 +244,11 @@ func writeTempFile(pattern string, contents []byte) (string, error) {
 type generator struct {
 	pkgPath         string
 	fieldTagKey     string
+	encoders        bool // whether to generate encoders
+	decoders        bool // whether to generate decoders
+	previousNames   map[genType][]string
+	custom          map[genType]customFuncs
+	proxies         map[genType]proxyFuncs
 	importMap       map[string]string // import path to import identifier
 	pkgPathMap      map[string]string //package path to qualifying identifier
 	initialTemplate *template.Template
@@ -119,16 +258,20 @@ type generator struct {
 	ptrTemplate     *template.Template
 	structTemplate  *template.Template
 	marshalTemplate *template.Template
+	customTemplate  *template.Template
+	proxyTemplate   *template.Template
+	interfaceTypes
 }
 
 type importSpec struct {
 	Path, ID string
 }
 
-func (g *generator) generate(typevals []interface{}) ([]byte, error) {
-	todo := g.referencedTypeList(typevals)
-	g.buildImportMap(todo)
+func (g *generator) generate(types []genType) ([]byte, error) {
+	todo := g.referencedTypeList(types)
+	g.buildImportMap(append(todo, g.embeddedPtrTypes(todo)...))
 	var code []byte
+	var generated []genType
 	for _, t := range todo {
 		piece, err := g.gen(t)
 		if err != nil {
@@ -138,8 +281,16 @@ func (g *generator) generate(typevals []interface{}) ([]byte, error) {
 			header := fmt.Sprintf("//// %s\n\n", t)
 			code = append(code, header...)
 			code = append(code, piece...)
+			generated = append(generated, t)
 		}
 	}
+	if len(g.previousNames) > 0 {
+		piece, err := g.genPreviousNames(generated)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, piece...)
+	}
 
 	var stdImports, otherImports []importSpec
 	for path, id := range g.importMap {
@@ -167,34 +318,91 @@ func (g *generator) generate(typevals []interface{}) ([]byte, error) {
 	return append(initial, code...), nil
 }
 
-// referencedTypeList returns a list of all types referenced from typevals.
-func (g *generator) referencedTypeList(typevals []interface{}) []reflect.Type {
+// genPreviousNames generates code to register the previous names of the
+// generated types.
+func (g *generator) genPreviousNames(generated []genType) ([]byte, error) {
+	isGenerated := map[genType]bool{}
+	for _, t := range generated {
+		isGenerated[t] = true
+	}
+	for t := range g.previousNames {
+		if !isGenerated[t] {
+			return nil, fmt.Errorf("PreviousNames: no codec generated for %s", t)
+		}
+		for _, name := range g.previousNames[t] {
+			if name == typeString(t, nil) {
+				return nil, fmt.Errorf("PreviousNames: %q is the current name of %s", name, t)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("//// previous names\n\nfunc init() {\n")
+	for _, t := range generated {
+		for _, name := range g.previousNames[t] {
+			fmt.Fprintf(&buf, "\tcodecapi.RegisterAlias(%q, %s_type)\n", name, g.typeID(t))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// embeddedPtrTypes returns the types of the structs pointed to by embedded
+// pointers in the given types. The generated code must allocate them.
+func (g *generator) embeddedPtrTypes(types []genType) []genType {
+	var pts []genType
+	for _, t := range types {
+		if t.Kind() != reflect.Struct {
+			continue
+		}
+		fields, _ := g.structFields(t)
+		for _, f := range fields {
+			for _, p := range f.Ptrs {
+				pts = append(pts, p.Type.Elem())
+			}
+		}
+	}
+	return pts
+}
+
+// referencedTypeList returns a list of all types referenced from roots.
+func (g *generator) referencedTypeList(roots []genType) []genType {
 	// Collect all the types referred to, except builtins. We will generate most
 	// of these (not defined types whose underlying type is builtin, for
 	// example), but we need them all to generate the right import statements.
-	types := map[reflect.Type]bool{}
-	for _, v := range typevals {
-		g.referencedTypes(reflect.TypeOf(v), types)
+	types := map[genType]bool{}
+	for _, t := range roots {
+		g.referencedTypes(t, types)
 	}
-	var typeList []reflect.Type
+	var typeList []genType
 	for t := range types {
 		typeList = append(typeList, t)
 	}
 	// Sort for determinism.
 	sort.Slice(typeList, func(i, j int) bool {
-		return codecapi.TypeString(typeList[i], nil) < codecapi.TypeString(typeList[j], nil)
+		return typeString(typeList[i], nil) < typeString(typeList[j], nil)
 	})
 	return typeList
 }
 
 // referencedTypes records in the set m all the types referenced from t.
-func (g *generator) referencedTypes(t reflect.Type, m map[reflect.Type]bool) {
+func (g *generator) referencedTypes(t genType, m map[genType]bool) {
 	if m[t] {
 		return
 	}
+	if _, ok := g.custom[t]; ok {
+		// The custom codec handles everything t refers to.
+		m[t] = true
+		return
+	}
+	if pt := g.proxyType(t); pt != nil {
+		// Only the proxy is encoded.
+		m[t] = true
+		g.referencedTypes(pt, m)
+		return
+	}
 	switch t.Kind() {
 	case reflect.Slice:
-		if t.Name() == "" && t.Elem() == byteType {
+		if t.Name() == "" && isByte(t.Elem()) {
 			return
 		}
 		m[t] = true
@@ -205,16 +413,17 @@ func (g *generator) referencedTypes(t reflect.Type, m map[reflect.Type]bool) {
 	case reflect.Array:
 		m[t] = true
 		g.referencedTypes(t.Elem(), m)
-		g.referencedTypes(reflect.SliceOf(t.Elem()), m)
+		g.referencedTypes(t.Elem().SliceOf(), m)
 	case reflect.Map:
 		m[t] = true
 		g.referencedTypes(t.Key(), m)
 		g.referencedTypes(t.Elem(), m)
 	case reflect.Struct:
 		m[t] = true
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if !g.ignoreField(t, f) {
+		// Errors are reported when the struct's code is generated.
+		fields, _ := g.structFields(t)
+		for _, f := range fields {
+			if !isPresence(f.Type) {
 				g.referencedTypes(f.Type, m)
 			}
 		}
@@ -225,7 +434,7 @@ func (g *generator) referencedTypes(t reflect.Type, m map[reflect.Type]bool) {
 	}
 }
 
-func packageName(t reflect.Type) string {
+func packageName(t genType) string {
 	if t.PkgPath() == "" {
 		return ""
 	}
@@ -237,7 +446,7 @@ func packageName(t reflect.Type) string {
 	return s[:i]
 }
 
-func (g *generator) ignoreField(structType reflect.Type, f reflect.StructField) bool {
+func (g *generator) ignoreField(structType genType, f structField) bool {
 	// Ignore unexported fields for structs in a different package. A field
 	// is exported if its PkgPath is empty.
 	if structType.PkgPath() != g.pkgPath && f.PkgPath != "" {
@@ -248,11 +457,11 @@ func (g *generator) ignoreField(structType reflect.Type, f reflect.StructField)
 		return true
 	}
 	// Ignore a field if it has a struct tag with "-", like encoding/json.
-	_, omit := parseTag(g.fieldTagKey, f.Tag)
+	_, omit, _ := parseTag(g.fieldTagKey, f.Tag)
 	return omit
 }
 
-func (g *generator) buildImportMap(types []reflect.Type) {
+func (g *generator) buildImportMap(types []genType) {
 	g.importMap = map[string]string{
 		"reflect":                       "",
 		"github.com/jba/codec/codecapi": "",
@@ -268,16 +477,15 @@ func (g *generator) buildImportMap(types []reflect.Type) {
 			prefixes[id] = true
 		}
 	}
-	for _, t := range types {
-		ppath := t.PkgPath()
+	addImport := func(ppath, pname string, explicit bool) {
 		if ppath == "" {
-			continue
+			return
 		}
 		if ppath == g.pkgPath {
-			continue
+			return
 		}
 		if _, ok := g.importMap[ppath]; ok {
-			continue
+			return
 		}
 		// Determine an import identifier for the path.
 		var id string
@@ -288,8 +496,7 @@ func (g *generator) buildImportMap(types []reflect.Type) {
 		// For package names that differ from their path's last component,
 		// provide the name as an import identifier, to simplify code
 		// generation.
-		pname := packageName(t)
-		if pname != prefix {
+		if pname != prefix || explicit {
 			prefix = pname
 			id = pname
 		}
@@ -303,6 +510,19 @@ func (g *generator) buildImportMap(types []reflect.Type) {
 		prefixes[prefix] = true
 		g.importMap[ppath] = id
 	}
+	for _, t := range types {
+		addImport(t.PkgPath(), packageName(t), false)
+	}
+	// The names of functions don't include their package names, so provide
+	// an identifier that is derived from the path.
+	for _, cf := range g.custom {
+		addImport(cf.encodePkg, identFromPath(cf.encodePkg), true)
+		addImport(cf.decodePkg, identFromPath(cf.decodePkg), true)
+	}
+	for _, pf := range g.proxies {
+		addImport(pf.toPkg, identFromPath(pf.toPkg), true)
+		addImport(pf.fromPkg, identFromPath(pf.fromPkg), true)
+	}
 	// The package path map is used to generate Go names for types. It is close
 	// to the import map, but not the same: first, it includes g.pkgPath.
 	// Second, a package mapping to an empty string in the import map,
@@ -317,16 +537,57 @@ func (g *generator) buildImportMap(types []reflect.Type) {
 	}
 }
 
-var (
-	binaryMarshalerType   = reflect.TypeOf(new(encoding.BinaryMarshaler)).Elem()
-	binaryUnmarshalerType = reflect.TypeOf(new(encoding.BinaryUnmarshaler)).Elem()
-	textMarshalerType     = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
-	textUnmarshalerType   = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
-	byteType              = reflect.TypeOf(byte(0))
-)
+// interfaceTypes holds the interface types that the generator checks
+// other types against.
+type interfaceTypes struct {
+	binaryMarshalerType   genType
+	binaryUnmarshalerType genType
+	textMarshalerType     genType
+	textUnmarshalerType   genType
+	codecMarshalerType    genType
+	codecUnmarshalerType  genType
+	defaulterType         genType
+}
 
-func (g *generator) gen(t reflect.Type) ([]byte, error) {
-	if m := implementsMarshaler(t); m != "" {
+var reflectInterfaces = interfaceTypes{
+	binaryMarshalerType:   rtype{reflect.TypeOf(new(encoding.BinaryMarshaler)).Elem()},
+	binaryUnmarshalerType: rtype{reflect.TypeOf(new(encoding.BinaryUnmarshaler)).Elem()},
+	textMarshalerType:     rtype{reflect.TypeOf(new(encoding.TextMarshaler)).Elem()},
+	textUnmarshalerType:   rtype{reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()},
+	codecMarshalerType:    rtype{reflect.TypeOf(new(Marshaler)).Elem()},
+	codecUnmarshalerType:  rtype{reflect.TypeOf(new(Unmarshaler)).Elem()},
+	defaulterType:         rtype{reflect.TypeOf(new(Defaulter)).Elem()},
+}
+
+// isPresence reports whether t is Presence.
+func isPresence(t genType) bool {
+	return t.Name() == "Presence" && t.PkgPath() == presencePkgPath
+}
+
+var presencePkgPath = reflect.TypeOf(Presence{}).PkgPath()
+
+// isByte reports whether t is byte.
+func isByte(t genType) bool {
+	return t.Kind() == reflect.Uint8 && t.PkgPath() == ""
+}
+
+// isError reports whether t is error.
+func isError(t genType) bool {
+	return t.Name() == "error" && t.PkgPath() == ""
+}
+
+func (g *generator) gen(t genType) ([]byte, error) {
+	if _, ok := g.proxies[t]; !ok {
+		if _, err := proxyMethods(t); err != nil {
+			return nil, err
+		}
+	}
+	if cf, ok := g.custom[t]; ok {
+		return g.genCustom(t, cf)
+	}
+	if m := g.implementsMarshaler(t); m == "Proxy" {
+		return g.genProxy(t)
+	} else if m != "" {
 		return g.genMarshaler(t, m)
 	}
 	switch t.Kind() {
@@ -345,13 +606,13 @@ func (g *generator) gen(t reflect.Type) ([]byte, error) {
 }
 
 // willGenerate reports whether a codec will be generated for t.
-func willGenerate(t reflect.Type) bool {
-	if implementsMarshaler(t) != "" {
+func (g *generator) willGenerate(t genType) bool {
+	if g.implementsMarshaler(t) != "" {
 		return true
 	}
 	switch t.Kind() {
 	case reflect.Slice:
-		return t.Elem() != byteType
+		return !isByte(t.Elem())
 	case reflect.Struct, reflect.Array, reflect.Map, reflect.Ptr:
 		return true
 	default:
@@ -359,59 +620,70 @@ func willGenerate(t reflect.Type) bool {
 	}
 }
 
-// implementsMarshaler returns the kind of Marshaler that t implements ("Binary"
-// or "Text"), or the empty string if it doesn't implement one.
-func implementsMarshaler(t reflect.Type) string {
-	if t.Implements(binaryMarshalerType) && reflect.PtrTo(t).Implements(binaryUnmarshalerType) {
+// implementsMarshaler returns the kind of Marshaler that t implements ("Codec",
+// "Binary" or "Text"), or the empty string if it doesn't implement one. A type
+// with a custom codec or a proxy is treated like a Marshaler, of kind "Custom"
+// or "Proxy".
+func (g *generator) implementsMarshaler(t genType) string {
+	if _, ok := g.custom[t]; ok {
+		return "Custom"
+	}
+	if t.Implements(g.codecMarshalerType) && t.PtrTo().Implements(g.codecUnmarshalerType) {
+		return "Codec"
+	}
+	if g.proxyType(t) != nil {
+		return "Proxy"
+	}
+	if t.Implements(g.binaryMarshalerType) && t.PtrTo().Implements(g.binaryUnmarshalerType) {
 		return "Binary"
 	}
-	if t.Implements(textMarshalerType) && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+	if t.Implements(g.textMarshalerType) && t.PtrTo().Implements(g.textUnmarshalerType) {
 		return "Text"
 	}
 	return ""
 }
 
-func (g *generator) genSlice(t reflect.Type) ([]byte, error) {
+func (g *generator) genSlice(t genType) ([]byte, error) {
 	return execute(g.sliceTemplate, struct {
-		Type    reflect.Type
+		Type    genType
 		ElField bool
 	}{
 		Type:    t,
-		ElField: willGenerate(t.Elem()),
+		ElField: g.willGenerate(t.Elem()),
 	})
 }
 
-func (g *generator) genArray(t reflect.Type) ([]byte, error) {
+func (g *generator) genArray(t genType) ([]byte, error) {
 	et := t.Elem()
-	st := reflect.SliceOf(et)
+	st := et.SliceOf()
 	return execute(g.arrayTemplate, struct {
-		Type, SliceType reflect.Type
+		Type, SliceType genType
 		IsBytes         bool
 		ElField         bool
 	}{
 		Type:      t,
 		SliceType: st,
-		IsBytes:   et == byteType,
-		ElField:   willGenerate(et),
+		IsBytes:   isByte(et),
+		ElField:   g.willGenerate(et),
 	})
 }
 
-func (g *generator) genMap(t reflect.Type) ([]byte, error) {
+func (g *generator) genMap(t genType) ([]byte, error) {
 	et := t.Elem()
 	kt := t.Key()
 	return execute(g.mapTemplate, struct {
-		Type              reflect.Type
+		Type              genType
 		KeyField, ElField bool
 	}{
 		Type:     t,
-		KeyField: willGenerate(kt),
-		ElField:  willGenerate(et) && kt != et,
+		KeyField: g.willGenerate(kt),
+		ElField:  g.willGenerate(et) && kt != et,
 	})
 }
 
-func (g *generator) genMarshaler(t reflect.Type, kind string) ([]byte, error) {
+func (g *generator) genMarshaler(t genType, kind string) ([]byte, error) {
 	return execute(g.marshalTemplate, struct {
-		Type reflect.Type
+		Type genType
 		Kind string
 	}{
 		Type: t,
@@ -419,90 +691,654 @@ func (g *generator) genMarshaler(t reflect.Type, kind string) ([]byte, error) {
 	})
 }
 
-func (g *generator) genPtr(t reflect.Type) ([]byte, error) {
+func (g *generator) genCustom(t genType, cf customFuncs) ([]byte, error) {
+	return execute(g.customTemplate, struct {
+		Type                   genType
+		Name                   string
+		EncodeFunc, DecodeFunc string
+	}{
+		Type:       t,
+		Name:       cf.name,
+		EncodeFunc: g.qualifiedName(cf.encodePkg, cf.encodeFun),
+		DecodeFunc: g.qualifiedName(cf.decodePkg, cf.decodeFun),
+	})
+}
+
+// qualifiedName returns the Go expression for the name in the package with
+// the given path.
+func (g *generator) qualifiedName(pkgPath, name string) string {
+	if q := g.pkgPathMap[pkgPath]; q != "" {
+		return q + "." + name
+	}
+	return name
+}
+
+var (
+	encoderPtrType = reflect.TypeOf((*codecapi.Encoder)(nil))
+	decoderPtrType = reflect.TypeOf((*codecapi.Decoder)(nil))
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// resolveCustom checks the functions of the custom codecs, and records their
+// names in g.custom.
+func (g *generator) resolveCustom(custom map[reflect.Type]CustomCodec) error {
+	g.custom = map[genType]customFuncs{}
+	for t, cc := range custom {
+		if t.Name() == "" {
+			return fmt.Errorf("Custom: %s is not a named type", t)
+		}
+		var (
+			cf  customFuncs
+			err error
+		)
+		cf.encodePkg, cf.encodeFun, err = g.funcName(cc.Encode, encoderPtrType, t)
+		if err != nil {
+			return fmt.Errorf("Custom: Encode for %s: %v", t, err)
+		}
+		cf.decodePkg, cf.decodeFun, err = g.funcName(cc.Decode, decoderPtrType, reflect.PtrTo(t))
+		if err != nil {
+			return fmt.Errorf("Custom: Decode for %s: %v", t, err)
+		}
+		cf.name = cc.Name
+		if cf.name == "" {
+			cf.name = cf.encodePkg + "." + cf.encodeFun
+		}
+		g.custom[rtype{t}] = cf
+	}
+	return nil
+}
+
+// funcName checks that fn is a top-level function of type
+// func(arg0, arg1) error, and returns its package path and name.
+func (g *generator) funcName(fn interface{}, arg0, arg1 reflect.Type) (pkgPath, name string, err error) {
+	return g.funcNameOfType(fn, reflect.FuncOf([]reflect.Type{arg0, arg1}, []reflect.Type{errorType}, false))
+}
+
+// funcNameOfType checks that fn is a top-level function of type want, and
+// returns its package path and name.
+func (g *generator) funcNameOfType(fn interface{}, want reflect.Type) (pkgPath, name string, err error) {
+	v := reflect.ValueOf(fn)
+	if !v.IsValid() || v.Type() != want {
+		return "", "", fmt.Errorf("got %T, want %s", fn, want)
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "", "", errors.New("cannot find function name")
+	}
+	// The full name is the package path, a dot and the name. Closures and
+	// methods have further dots or other punctuation in the name.
+	full := f.Name()
+	i := strings.LastIndexByte(full, '/')
+	j := strings.IndexByte(full[i+1:], '.')
+	if j < 0 {
+		return "", "", fmt.Errorf("bad function name %q", full)
+	}
+	pkgPath, name = full[:i+1+j], full[i+1+j+1:]
+	if !token.IsIdentifier(name) {
+		return "", "", fmt.Errorf("%s is not a top-level function", full)
+	}
+	if pkgPath != g.pkgPath && !token.IsExported(name) {
+		return "", "", fmt.Errorf("%s is not exported", full)
+	}
+	return pkgPath, name, nil
+}
+
+// resolveProxies checks the functions of the proxies, and records their
+// names in g.proxies.
+func (g *generator) resolveProxies(proxies map[reflect.Type]Proxy) error {
+	g.proxies = map[genType]proxyFuncs{}
+	for t, p := range proxies {
+		if t.Name() == "" {
+			return fmt.Errorf("Proxies: %s is not a named type", t)
+		}
+		if _, ok := g.custom[rtype{t}]; ok {
+			return fmt.Errorf("Proxies: %s also has a custom codec", t)
+		}
+		tv := reflect.ValueOf(p.To)
+		if tv.Kind() != reflect.Func || tv.Type().NumOut() != 1 {
+			return fmt.Errorf("Proxies: To for %s: got %T, want func(*%s) P", t, p.To, t)
+		}
+		pt := tv.Type().Out(0)
+		pf := proxyFuncs{proxyType: rtype{pt}}
+		if err := checkProxyType(rtype{t}, pf.proxyType); err != nil {
+			return fmt.Errorf("Proxies: %v", err)
+		}
+		var err error
+		pf.toPkg, pf.toFun, err = g.funcNameOfType(p.To,
+			reflect.FuncOf([]reflect.Type{reflect.PtrTo(t)}, []reflect.Type{pt}, false))
+		if err != nil {
+			return fmt.Errorf("Proxies: To for %s: %v", t, err)
+		}
+		pf.fromPkg, pf.fromFun, err = g.funcName(p.From, reflect.PtrTo(t), pt)
+		if err != nil {
+			return fmt.Errorf("Proxies: From for %s: %v", t, err)
+		}
+		g.proxies[rtype{t}] = pf
+	}
+	return nil
+}
+
+// proxyType returns the proxy type of t, from GenerateOptions.Proxies or from
+// t's ToCodec and FromCodec methods. It returns nil if t has no proxy.
+func (g *generator) proxyType(t genType) genType {
+	if pf, ok := g.proxies[t]; ok {
+		return pf.proxyType
+	}
+	pt, _ := proxyMethods(t)
+	return pt
+}
+
+// proxyMethods returns the proxy type named by the ToCodec and FromCodec
+// methods of *t. If *t has neither method, it returns nil. If it has only one,
+// or they have the wrong signatures, it returns an error.
+func proxyMethods(t genType) (genType, error) {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return nil, nil
+	}
+	pt := t.PtrTo()
+	toIn, toOut, hasTo := pt.MethodByName("ToCodec")
+	fromIn, fromOut, hasFrom := pt.MethodByName("FromCodec")
+	if !hasTo && !hasFrom {
+		return nil, nil
+	}
+	if !hasTo || !hasFrom || len(toIn) != 0 || len(toOut) != 1 {
+		return nil, fmt.Errorf("%s must have methods ToCodec() P and FromCodec(P) error", pt)
+	}
+	p := toOut[0]
+	if len(fromIn) != 1 || fromIn[0] != p || len(fromOut) != 1 || !isError(fromOut[0]) {
+		return nil, fmt.Errorf("%s.ToCodec returns %s, so FromCodec must be func(%[2]s) error", pt, p)
+	}
+	return p, nil
+}
+
+// checkProxyType checks that p can be the proxy type for t.
+func checkProxyType(t, p genType) error {
+	if p == t {
+		return fmt.Errorf("%s cannot be its own proxy", t)
+	}
+	if p.Kind() == reflect.Interface || p.Kind() == reflect.Func || p.Kind() == reflect.Chan {
+		return fmt.Errorf("proxy type %s for %s cannot be encoded", p, t)
+	}
+	return nil
+}
+
+func (g *generator) genProxy(t genType) ([]byte, error) {
+	var toExpr, fromExpr string
+	if pf, ok := g.proxies[t]; ok {
+		toExpr = fmt.Sprintf("%s(x)", g.qualifiedName(pf.toPkg, pf.toFun))
+		fromExpr = fmt.Sprintf("%s(p, px)", g.qualifiedName(pf.fromPkg, pf.fromFun))
+	} else {
+		toExpr = "x.ToCodec()"
+		fromExpr = "p.FromCodec(px)"
+	}
+	pt := g.proxyType(t)
+	if err := checkProxyType(t, pt); err != nil {
+		return nil, err
+	}
+	return execute(g.proxyTemplate, struct {
+		Type, ProxyType  genType
+		ProxyField       bool
+		ToExpr, FromExpr string
+	}{
+		Type:       t,
+		ProxyType:  pt,
+		ProxyField: g.willGenerate(pt),
+		ToExpr:     toExpr,
+		FromExpr:   fromExpr,
+	})
+}
+
+// identFromPath returns an identifier for the package with the given import
+// path, derived from its last component.
+func identFromPath(ppath string) string {
+	base := path.Base(ppath)
+	if strings.HasPrefix(base, "v") && len(base) > 1 && strings.Trim(base[1:], "0123456789") == "" {
+		// A major version suffix; use the component before it.
+		base = path.Base(path.Dir(ppath))
+	}
+	id := strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return -1
+	}, base)
+	if id == "" || !token.IsIdentifier(id) {
+		id = "pkg" + id
+	}
+	return id
+}
+
+func (g *generator) genPtr(t genType) ([]byte, error) {
 	return execute(g.ptrTemplate, struct {
-		Type    reflect.Type
+		Type    genType
 		ElField bool
 	}{
 		Type:    t,
-		ElField: willGenerate(t.Elem()),
+		ElField: g.willGenerate(t.Elem()),
 	})
 }
 
-func (g *generator) genStruct(t reflect.Type) ([]byte, error) {
+func (g *generator) genStruct(t genType) ([]byte, error) {
 	if t.Name() == "" {
 		return nil, fmt.Errorf("cannot generate code for unnamed struct type %s", t)
 	}
-	fields := g.structFields(t)
-	fieldTypesSet := map[reflect.Type]bool{}
+	allFields, err := g.structFields(t)
+	if err != nil {
+		return nil, err
+	}
+	// A Presence field isn't encoded, so it doesn't get a number.
+	var (
+		fields   []field
+		presence string
+	)
+	for _, f := range allFields {
+		if !isPresence(f.Type) {
+			fields = append(fields, f)
+			continue
+		}
+		if presence != "" {
+			return nil, fmt.Errorf("%s: more than one Presence field", t)
+		}
+		if len(f.Ptrs) > 0 {
+			return nil, fmt.Errorf("%s: Presence field %s is in an embedded pointer", t, f.Path)
+		}
+		presence = f.Path
+	}
+	var hasAliases bool
+	for _, f := range fields {
+		if len(f.Aliases) > 0 {
+			hasAliases = true
+		}
+	}
+	// The decoder must keep track of which fields it has seen if
+	// it needs to check for required fields or supply defaults.
+	trackFields := false
+	for _, f := range fields {
+		if f.Required || f.Default != "" {
+			trackFields = true
+		}
+	}
+	fieldTypesSet := map[genType]bool{}
 	for _, f := range fields {
 		ft := f.Type
 		if ft == nil {
 			continue
 		}
-		if willGenerate(ft) {
+		if g.willGenerate(ft) {
 			fieldTypesSet[ft] = true
 		}
 	}
-	var fieldTypes []reflect.Type
+	var fieldTypes []genType
 	for t := range fieldTypesSet {
 		fieldTypes = append(fieldTypes, t)
 	}
 	// Sort so the list is deterministic, for testing. The strings returned by
-	// reflect.Type.String aren't unique (e.g. []pkg.Foo where there are two
+	// genType.String aren't unique (e.g. []pkg.Foo where there are two
 	// packages with name "pkg"), but that doesn't matter as long as no tests
 	// trigger the problem.
 	sort.Slice(fieldTypes, func(i, j int) bool {
 		return fieldTypes[i].String() < fieldTypes[j].String()
 	})
 	return execute(g.structTemplate, struct {
-		Type, PtrType reflect.Type
+		Type, PtrType genType
 		Fields        []field
-		FieldTypes    []reflect.Type // unique list of types
+		FieldTypes    []genType // unique list of types
+		Presence      string    // selector of the Presence field, if any
+		TrackFields   bool      // whether the decoder should record the fields it sees
+		Defaulter     bool      // whether the pointer type implements Defaulter
+		HasAliases    bool      // whether any field has an alias
 	}{
-		Type:       t,
-		PtrType:    reflect.PtrTo(t),
-		Fields:     fields,
-		FieldTypes: fieldTypes,
+		Type:        t,
+		PtrType:     t.PtrTo(),
+		Fields:      fields,
+		FieldTypes:  fieldTypes,
+		Presence:    presence,
+		TrackFields: trackFields,
+		Defaulter:   t.PtrTo().Implements(g.defaulterType),
+		HasAliases:  hasAliases,
 	})
 }
 
 // A field holds the information necessary to generate the encoder for a struct field.
 // This struct's fields are exported so they can be used in templates.
 type field struct {
-	Name string
-	Type reflect.Type
-	Zero string // representation of the type's zero value
+	Name string // the name recorded in the encoded data
+	Type genType
+	Zero string        // representation of the type's zero value
+	Path string        // Go selector for the field, relative to the struct
+	Ptrs []embeddedPtr // embedded pointers that must be traversed to reach the field
+
+	Always   bool   // encode the field even if it is zero
+	Required bool   // fail decoding if the field is absent
+	Default  string // Go expression to assign if the field is absent, or ""
+
+	Aliases []string // former names of the field
+}
+
+// An embeddedPtr is an embedded pointer to a struct whose fields have been
+// promoted. It must be non-nil to reach those fields.
+type embeddedPtr struct {
+	Path string  // Go selector for the pointer, relative to the outermost struct
+	Type genType // type of the pointer
 }
 
 // structFields returns the fields of the struct type t that should be encoded.
-// For structs in a package other than the one being generated into, that
-// includes all direct exported fields, but not exported fields of embedded,
-// unexported types. For structs in the same package, unexported fields are
+//
+// Fields of embedded structs are promoted into the list following the rules of
+// encoding/json. An embedded struct or pointer to struct whose tag doesn't
+// give it a name contributes its fields instead of being a field itself, as
+// does any struct field with the "inline" tag option. Among fields with the
+// same name, the least nested one is chosen. If there is more than one at that
+// depth, a tagged one is chosen. If that still doesn't select a single field,
+// all fields with that name are omitted.
+//
+// For structs in a package other than the one being generated into, only
+// exported fields are included. Exported fields promoted from embedded,
+// unexported struct types are included only if they can be reached with a
+// promoted selector. For structs in the same package, unexported fields are
 // included.
-func (g *generator) structFields(t reflect.Type) []field {
+func (g *generator) structFields(t genType) ([]field, error) {
+	// A candidate is a field that may be encoded, if it is not dominated by
+	// another field with the same name.
+	type candidate struct {
+		field
+		index  []int // as in structField.Index
+		tagged bool  // whether the name came from a tag
+		hidden bool  // whether the path passes through an inaccessible field
+	}
+
+	// An embedding is a struct whose fields are promoted.
+	type embedding struct {
+		typ    genType
+		index  []int
+		path   string
+		ptrs   []embeddedPtr
+		hidden bool
+	}
+
+	var cands []candidate
+	next := []embedding{{typ: t}}
+	// Counts of the embedded struct types at the current and next level.
+	count, nextCount := map[genType]int{}, map[genType]int{}
+	visited := map[genType]bool{}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		count, nextCount = nextCount, map[genType]int{}
+		for _, em := range current {
+			if visited[em.typ] {
+				continue
+			}
+			visited[em.typ] = true
+			for i := 0; i < em.typ.NumField(); i++ {
+				sf := em.typ.Field(i)
+				name, omit, opts := parseTag(g.fieldTagKey, sf.Tag)
+				if omit {
+					continue
+				}
+				index := make([]int, len(em.index)+1)
+				copy(index, em.index)
+				index[len(em.index)] = i
+				path := sf.Name
+				if em.path != "" {
+					path = em.path + "." + sf.Name
+				}
+				inline := hasOption(opts, "inline")
+				if inline || (sf.Anonymous && name == "") {
+					st := sf.Type
+					isPtr := st.Kind() == reflect.Ptr && st.Name() == ""
+					if isPtr {
+						st = st.Elem()
+					}
+					if st.Kind() == reflect.Struct && (inline || g.implementsMarshaler(sf.Type) == "") {
+						hidden := em.hidden || (em.typ.PkgPath() != g.pkgPath && sf.PkgPath != "")
+						ptrs := em.ptrs
+						if isPtr {
+							if hidden || !g.canName(st) {
+								// Like encoding/json, ignore embedded pointers that
+								// can't be set.
+								continue
+							}
+							ptrs = append(ptrs[:len(ptrs):len(ptrs)], embeddedPtr{Path: path, Type: sf.Type})
+						}
+						nextCount[st]++
+						if nextCount[st] == 1 {
+							next = append(next, embedding{st, index, path, ptrs, hidden})
+						}
+						continue
+					}
+					if inline {
+						return nil, fmt.Errorf("%s: field %s has inline option but type %s is not a struct", t, path, sf.Type)
+					}
+				}
+				if g.ignoreField(em.typ, sf) {
+					continue
+				}
+				tagged := name != ""
+				if !tagged {
+					name = sf.Name
+				}
+				f := field{
+					Name: name,
+					Type: sf.Type,
+					Zero: zeroValue(sf.Type),
+					Path: path,
+					Ptrs: em.ptrs,
+				}
+				if err := setFieldOptions(&f, opts); err != nil {
+					return nil, fmt.Errorf("%s: field %s: %v", t, path, err)
+				}
+				c := candidate{
+					field:  f,
+					index:  index,
+					tagged: tagged,
+					hidden: em.hidden,
+				}
+				cands = append(cands, c)
+				if count[em.typ] > 1 {
+					// The struct containing this field was embedded more than
+					// once at the same level. Add a copy so that the field will
+					// be dominated, as encoding/json does.
+					cands = append(cands, c)
+				}
+			}
+		}
+	}
+
+	sort.Slice(cands, func(i, j int) bool {
+		ci, cj := cands[i], cands[j]
+		if ci.Name != cj.Name {
+			return ci.Name < cj.Name
+		}
+		if len(ci.index) != len(cj.index) {
+			return len(ci.index) < len(cj.index)
+		}
+		if ci.tagged != cj.tagged {
+			return ci.tagged
+		}
+		return indexLess(ci.index, cj.index)
+	})
+	var winners []candidate
+	for i := 0; i < len(cands); {
+		j := i + 1
+		for j < len(cands) && cands[j].Name == cands[i].Name {
+			j++
+		}
+		// The group of candidates with the same name is sorted by depth, then
+		// by tagging.
+		group := cands[i:j]
+		if len(group) == 1 || len(group[0].index) < len(group[1].index) || (group[0].tagged && !group[1].tagged) {
+			winners = append(winners, group[0])
+		}
+		i = j
+	}
+	sort.Slice(winners, func(i, j int) bool { return indexLess(winners[i].index, winners[j].index) })
+
+	// An alias must not be the name of another field, or another field's alias.
+	names := map[string]string{}
+	for _, w := range winners {
+		names[w.Name] = w.Name
+	}
+	for _, w := range winners {
+		for _, a := range w.Aliases {
+			if other, ok := names[a]; ok {
+				return nil, fmt.Errorf("%s: alias %q of field %s conflicts with field %s", t, a, w.Name, other)
+			}
+			names[a] = w.Name
+		}
+	}
+
 	var fields []field
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		if g.ignoreField(t, f) {
+	for _, w := range winners {
+		if w.hidden {
+			// The full path isn't valid outside the struct's package, but the
+			// promoted selector may be.
+			name := w.Path[strings.LastIndexByte(w.Path, '.')+1:]
+			sf, ok := t.FieldByName(name)
+			if !ok || !equalIndex(sf.Index, w.index) {
+				continue
+			}
+			w.Path = name
+		}
+		fields = append(fields, w.field)
+	}
+	return fields, nil
+}
+
+// setFieldOptions sets the parts of f that are determined by tag options.
+func setFieldOptions(f *field, opts []string) error {
+	f.Always = hasOption(opts, "always")
+	f.Required = hasOption(opts, "required")
+	for _, o := range opts {
+		if strings.HasPrefix(o, "alias=") {
+			alias := strings.TrimPrefix(o, "alias=")
+			if alias == "" || alias == f.Name {
+				return fmt.Errorf("bad alias %q", alias)
+			}
+			f.Aliases = append(f.Aliases, alias)
 			continue
 		}
-		name, _ := parseTag(g.fieldTagKey, f.Tag)
-		if name == "" {
-			name = f.Name
+		if !strings.HasPrefix(o, "default=") {
+			continue
+		}
+		if f.Default != "" {
+			return errors.New("more than one default")
+		}
+		lit, err := defaultLiteral(f.Type, strings.TrimPrefix(o, "default="))
+		if err != nil {
+			return err
+		}
+		f.Default = lit
+	}
+	if f.Required && f.Default != "" {
+		return errors.New("a required field cannot have a default")
+	}
+	// A zero value isn't normally encoded, so it would look absent to the
+	// decoder.
+	if f.Required || f.Default != "" {
+		f.Always = true
+	}
+	return nil
+}
+
+// defaultLiteral returns a Go expression for the value denoted by s, which
+// is the default for a field of type t. It returns an error if s does not denote
+// a value of type t.
+func defaultLiteral(t genType, s string) (string, error) {
+	bad := func(err error) (string, error) {
+		return "", fmt.Errorf("bad default %q for type %s: %v", s, t, err)
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return bad(err)
+		}
+		return strconv.FormatBool(b), nil
+	case reflect.String:
+		return strconv.Quote(s), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, t.Bits())
+		if err != nil {
+			return bad(err)
+		}
+		return strconv.FormatInt(i, 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 0, t.Bits())
+		if err != nil {
+			return bad(err)
+		}
+		return strconv.FormatUint(u, 10), nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return bad(err)
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return bad(errors.New("not a Go constant"))
+		}
+		return strconv.FormatFloat(f, 'g', -1, t.Bits()), nil
+	case reflect.Complex64, reflect.Complex128:
+		c, err := strconv.ParseComplex(s, t.Bits())
+		if err != nil {
+			return bad(err)
+		}
+		if cmplx.IsInf(c) || cmplx.IsNaN(c) {
+			return bad(errors.New("not a Go constant"))
 		}
-		fields = append(fields, field{
-			Name: name,
-			Type: f.Type,
-			Zero: zeroValue(f.Type),
-		})
+		return strconv.FormatComplex(c, 'g', -1, t.Bits()), nil
+	default:
+		return "", fmt.Errorf("default values are not supported for type %s", t)
 	}
-	return fields
+}
+
+// canName reports whether the generated code can refer to t by name.
+func (g *generator) canName(t genType) bool {
+	return t.PkgPath() == g.pkgPath || token.IsExported(t.Name())
+}
+
+// indexLess reports whether the field with index sequence x comes
+// before the one with sequence y.
+func indexLess(x, y []int) bool {
+	for i, xi := range x {
+		if i >= len(y) {
+			return false
+		}
+		if xi != y[i] {
+			return xi < y[i]
+		}
+	}
+	return len(x) < len(y)
+}
+
+func equalIndex(x, y []int) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeCond returns the condition under which a struct field
+// should be encoded, or the empty string if it should always be encoded.
+func (g *generator) encodeCond(f field) string {
+	var conds []string
+	for _, p := range f.Ptrs {
+		conds = append(conds, fmt.Sprintf("x.%s != nil", p.Path))
+	}
+	if f.Zero != "" && !f.Always {
+		conds = append(conds, fmt.Sprintf("x.%s != %s", f.Path, f.Zero))
+	}
+	return strings.Join(conds, " && ")
 }
 
 // zeroValue returns the string representation of a zero value of type t,
 // or the empty string if there isn't one.
-func zeroValue(t reflect.Type) string {
+func zeroValue(t genType) string {
 	switch t.Kind() {
 	case reflect.Bool:
 		return "false"
@@ -530,11 +1366,11 @@ func execute(tmpl *template.Template, data interface{}) ([]byte, error) {
 }
 
 // encodeStmt returns a Go statement that encodes a value denoted by arg, of type t.
-func (g *generator) encodeStmt(t reflect.Type, arg string) string {
-	bn, native := builtinName(t)
+func (g *generator) encodeStmt(t genType, arg string) string {
+	bn, native := g.builtinName(t)
 	if bn != "" {
 		// t can be handled by an Encoder method.
-		if t != native {
+		if g.goName(t) != native {
 			// t is not the Encoder method's argument type, so we must cast.
 			arg = fmt.Sprintf("%s(%s)", native, arg)
 		}
@@ -544,7 +1380,7 @@ func (g *generator) encodeStmt(t reflect.Type, arg string) string {
 		return fmt.Sprintf("e.EncodeAny(%s)", arg)
 	}
 	// If the encode function expects a pointer, take the address of the arg.
-	if encodePtrArg(t) {
+	if g.encodePtrArg(t) {
 		if arg[0] == '*' {
 			// If the arg is a dereference, just remove the dereference.
 			arg = arg[1:]
@@ -557,16 +1393,23 @@ func (g *generator) encodeStmt(t reflect.Type, arg string) string {
 
 // encodePtrArg reports whether the type is passed by pointer.
 // We pass potentially large values by pointer for efficiency.
-func encodePtrArg(t reflect.Type) bool {
-	if t.Implements(binaryMarshalerType) || t.Implements(textMarshalerType) {
+func (g *generator) encodePtrArg(t genType) bool {
+	if _, ok := g.custom[t]; ok {
+		return false
+	}
+	if g.proxyType(t) != nil {
+		// Its codec always takes a pointer. See proxy.tmpl.
+		return true
+	}
+	if t.Implements(g.codecMarshalerType) || t.Implements(g.binaryMarshalerType) || t.Implements(g.textMarshalerType) {
 		return false
 	}
 	return t.Kind() == reflect.Struct || t.Kind() == reflect.Array
 }
 
-func (g *generator) encodeFunc(t reflect.Type) string {
+func (g *generator) encodeFunc(t genType) string {
 	var typeName string
-	bn, _ := builtinName(t)
+	bn, _ := g.builtinName(t)
 	if bn != "" {
 		typeName = "codecapi." + bn
 	} else {
@@ -575,11 +1418,11 @@ func (g *generator) encodeFunc(t reflect.Type) string {
 	return fmt.Sprintf("c.%s_codec.encode", typeName)
 }
 
-func (g *generator) decodeStmt(t reflect.Type, arg string) string {
-	bn, native := builtinName(t)
+func (g *generator) decodeStmt(t genType, arg string) string {
+	bn, native := g.builtinName(t)
 	if bn != "" {
 		// t can be handled by a Decoder method.
-		if t != native {
+		if g.goName(t) != native {
 			// t is not the Decoder method's return type, so we must cast.
 			return fmt.Sprintf("%s = %s(d.Decode%s())", arg, g.goName(t), bn)
 		}
@@ -593,7 +1436,7 @@ func (g *generator) decodeStmt(t reflect.Type, arg string) string {
 		return fmt.Sprintf("%s = d.DecodeAny().(%s)", arg, g.goName(t))
 	}
 	// Assume we will generate a decode method for t.
-	if t.Name() != "" && !willGenerate(t) {
+	if t.Name() != "" && !g.willGenerate(t) {
 		arg = fmt.Sprintf("(*%s)(&%s)", g.goName(t), arg)
 	} else {
 		arg = "&" + arg
@@ -601,42 +1444,132 @@ func (g *generator) decodeStmt(t reflect.Type, arg string) string {
 	return fmt.Sprintf("c.%s_codec.decode(d, %s)", g.typeID(t), arg)
 }
 
+// wireKind returns a Go expression for the reflect.Kind that describes how t is
+// encoded. That is t's kind, except that a type encoded by a binary or text
+// marshaler is encoded as bytes, like a string, a type that implements
+// Marshaler or has a custom codec can encode any value, like an interface, and
+// a type with a proxy is encoded like the proxy.
+func (g *generator) wireKind(t genType) string {
+	k := reflect.Invalid
+	if t != nil {
+		switch g.implementsMarshaler(t) {
+		case "Proxy":
+			return g.wireKind(g.proxyType(t))
+		case "Codec", "Custom":
+			k = reflect.Interface
+		case "":
+			k = t.Kind()
+		default:
+			k = reflect.String
+		}
+	}
+	s := k.String()
+	return "reflect." + strings.ToUpper(s[:1]) + s[1:]
+}
+
+// elemKinds returns a Go expression for the element kinds of t, as
+// codecapi.FieldElemKinder describes them, or "nil" if t has no elements.
+func (g *generator) elemKinds(t genType) string {
+	var kinds []string
+	g.appendElemKinds(&kinds, t, map[genType]bool{})
+	if len(kinds) == 0 {
+		return "nil"
+	}
+	return "{" + strings.Join(kinds, ", ") + "}"
+}
+
+// appendElemKinds appends to kinds the wire kinds of the element types of t,
+// each followed by its own element kinds. An element type that contains
+// itself is given as reflect.Invalid where it recurs.
+func (g *generator) appendElemKinds(kinds *[]string, t genType, seen map[genType]bool) {
+	if t == nil {
+		return
+	}
+	switch g.implementsMarshaler(t) {
+	case "Proxy":
+		g.appendElemKinds(kinds, g.proxyType(t), seen)
+		return
+	case "":
+	default:
+		return
+	}
+	var elems []genType
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Ptr:
+		elems = []genType{t.Elem()}
+	case reflect.Map:
+		elems = []genType{t.Key(), t.Elem()}
+	default:
+		return
+	}
+	seen[t] = true
+	for _, et := range elems {
+		if seen[et] {
+			*kinds = append(*kinds, "reflect.Invalid")
+			continue
+		}
+		*kinds = append(*kinds, g.wireKind(et))
+		g.appendElemKinds(kinds, et, seen)
+	}
+	delete(seen, t)
+}
+
+// numeric reports whether t is a number that a Decoder method decodes, and so
+// can be converted.
+func (g *generator) numeric(t genType) bool {
+	switch bn, _ := g.builtinName(t); bn {
+	case "Byte", "Int", "Uint", "Float", "Complex":
+		return true
+	default:
+		return false
+	}
+}
+
+// reflectType returns a Go expression for the reflect.Type of t, or "nil" if t
+// is nil.
+func (g *generator) reflectType(t genType) string {
+	if t == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("reflect.TypeOf((*%s)(nil)).Elem()", g.goName(t))
+}
+
 // builtinName returns the suffix to append to "encode" or "decode" to get the
 // Encoder/Decoder method name for t. If t cannot be encoded by an Encoder
-// method, the suffix is "". The second return value is the "native" type of the
-// method: the argument to the Encoder method, and the return value of the
-// Decoder method.
-func builtinName(t reflect.Type) (suffix string, native reflect.Type) {
-	if implementsMarshaler(t) != "" {
-		return "", nil
+// method, the suffix is "". The second return value is the name of the "native"
+// type of the method: the argument to the Encoder method, and the return value
+// of the Decoder method.
+func (g *generator) builtinName(t genType) (suffix, native string) {
+	if g.implementsMarshaler(t) != "" {
+		return "", ""
 	}
 	switch t.Kind() {
 	case reflect.String:
-		return "String", reflect.TypeOf("")
+		return "String", "string"
 	case reflect.Bool:
-		return "Bool", reflect.TypeOf(true)
+		return "Bool", "bool"
 	case reflect.Int8, reflect.Uint8:
-		return "Byte", byteType
+		return "Byte", "uint8"
 	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		return "Int", reflect.TypeOf(int64(0))
+		return "Int", "int64"
 	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		return "Uint", reflect.TypeOf(uint64(0))
+		return "Uint", "uint64"
 	case reflect.Float32, reflect.Float64:
-		return "Float", reflect.TypeOf(0.0)
+		return "Float", "float64"
 	case reflect.Complex64, reflect.Complex128:
-		return "Complex", reflect.TypeOf(0 + 0i)
+		return "Complex", "complex128"
 	case reflect.Slice:
-		if t.Elem() == byteType {
-			return "Bytes", reflect.TypeOf([]byte(nil))
+		if isByte(t.Elem()) {
+			return "Bytes", "[]uint8"
 		}
 	}
-	return "", nil
+	return "", ""
 }
 
 // goName returns the name of t as it should appear in a Go program.
 // E.g. "go/ast.File" => ast.File
-func (g *generator) goName(t reflect.Type) string {
-	return codecapi.TypeString(t, g.pkgPathMap)
+func (g *generator) goName(t genType) string {
+	return typeString(t, g.pkgPathMap)
 }
 
 var typeIDReplacer = strings.NewReplacer(
@@ -648,7 +1581,7 @@ var typeIDReplacer = strings.NewReplacer(
 
 // typeID returns a valid Go identifier for type t.
 // E.g. "ast.File" => "ast_File", "[]int" => "slice_int".
-func (g *generator) typeID(t reflect.Type) string {
+func (g *generator) typeID(t genType) string {
 	if t.Name() != "" {
 		return strings.ReplaceAll(g.goName(t), ".", "_")
 	}
@@ -676,11 +1609,163 @@ func (g *generator) typeID(t reflect.Type) string {
 // name: the name given in tag, or "" if there is no name.
 // omit: true if the field should be omitted.
 // options: the list of options.
-func parseTag(key string, t reflect.StructTag) (name string, omit bool) {
+func parseTag(key string, t reflect.StructTag) (name string, omit bool, options []string) {
 	s := t.Get(key)
 	parts := strings.Split(s, ",")
 	if parts[0] == "-" {
-		return "", true
+		return "", true, nil
+	}
+	return parts[0], false, parts[1:]
+}
+
+// hasOption reports whether opt is among the options returned by parseTag.
+func hasOption(options []string, opt string) bool {
+	for _, o := range options {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// A genType is a type that code can be generated for. GenerateFile describes
+// types with reflection, as rtypes; GenerateFileFromSource loads them from
+// source, as srcTypes. A genType's methods behave like those of reflect.Type
+// with the same names. Equal types must be equal as interface values, so that
+// genTypes can be map keys.
+type genType interface {
+	Kind() reflect.Kind
+	Name() string
+	PkgPath() string
+	String() string
+	Elem() genType
+	Key() genType
+	Len() int
+	Bits() int
+	NumField() int
+	Field(i int) structField
+	FieldByName(name string) (structField, bool)
+	NumMethod() int
+	Implements(u genType) bool
+
+	// MethodByName returns the parameter and result types of the method
+	// with the given name, not including the receiver.
+	MethodByName(name string) (in, out []genType, ok bool)
+
+	// PtrTo returns the pointer type with element type t.
+	PtrTo() genType
+
+	// SliceOf returns the slice type with element type t.
+	SliceOf() genType
+}
+
+// A structField describes a field of a struct, like reflect.StructField.
+type structField struct {
+	Name      string
+	PkgPath   string
+	Type      genType
+	Tag       reflect.StructTag
+	Index     []int
+	Anonymous bool
+}
+
+// An rtype is a genType described by reflection.
+type rtype struct {
+	reflect.Type
+}
+
+func (t rtype) Elem() genType { return rtype{t.Type.Elem()} }
+func (t rtype) Key() genType  { return rtype{t.Type.Key()} }
+
+func (t rtype) Field(i int) structField {
+	return reflectStructField(t.Type.Field(i))
+}
+
+func (t rtype) FieldByName(name string) (structField, bool) {
+	sf, ok := t.Type.FieldByName(name)
+	return reflectStructField(sf), ok
+}
+
+func reflectStructField(sf reflect.StructField) structField {
+	f := structField{
+		Name:      sf.Name,
+		PkgPath:   sf.PkgPath,
+		Tag:       sf.Tag,
+		Index:     sf.Index,
+		Anonymous: sf.Anonymous,
+	}
+	if sf.Type != nil {
+		f.Type = rtype{sf.Type}
+	}
+	return f
+}
+
+func (t rtype) Implements(u genType) bool {
+	return t.Type.Implements(u.(rtype).Type)
+}
+
+func (t rtype) MethodByName(name string) (in, out []genType, ok bool) {
+	m, ok := t.Type.MethodByName(name)
+	if !ok {
+		return nil, nil, false
+	}
+	// The method's type includes the receiver.
+	for i := 1; i < m.Type.NumIn(); i++ {
+		in = append(in, rtype{m.Type.In(i)})
+	}
+	for i := 0; i < m.Type.NumOut(); i++ {
+		out = append(out, rtype{m.Type.Out(i)})
+	}
+	return in, out, true
+}
+
+func (t rtype) PtrTo() genType   { return rtype{reflect.PtrTo(t.Type)} }
+func (t rtype) SliceOf() genType { return rtype{reflect.SliceOf(t.Type)} }
+
+// typeString is codecapi.TypeString for a genType.
+func typeString(t genType, pkgPaths map[string]string) string {
+	if n := t.Name(); n != "" {
+		prefix := t.PkgPath()
+		if pkgPaths != nil {
+			if p, ok := pkgPaths[prefix]; ok {
+				prefix = p
+			} else if i := strings.LastIndexByte(prefix, '/'); i >= 0 {
+				prefix = prefix[i+1:]
+			}
+		}
+		if prefix == "" {
+			return n
+		}
+		return prefix + "." + n
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return "[]" + typeString(t.Elem(), pkgPaths)
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeString(t.Elem(), pkgPaths))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", typeString(t.Key(), pkgPaths), typeString(t.Elem(), pkgPaths))
+	case reflect.Ptr:
+		return "*" + typeString(t.Elem(), pkgPaths)
+	case reflect.Struct:
+		fields := make([]string, t.NumField())
+		for i := 0; i < len(fields); i++ {
+			f := t.Field(i)
+			tn := typeString(f.Type, pkgPaths)
+			if f.Anonymous {
+				fields[i] = tn
+			} else {
+				fields[i] = f.Name + " " + tn
+			}
+		}
+		return fmt.Sprintf("struct { %s }", strings.Join(fields, "; "))
+	case reflect.Interface:
+		// We only support the empty interface.
+		if t.NumMethod() == 0 {
+			return "interface{}"
+		}
+		panic(fmt.Sprintf("bad unnamed interface type (only the empty interface is valid): %s", t))
+	default:
+		panic(fmt.Sprintf("bad type: %s", t))
 	}
-	return parts[0], false
 }
@@ -20,6 +20,8 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jba/codec/codecapi"
 	othercmp "github.com/jba/codec/internal/cmp"
 	foo "github.com/jba/codec/internal/testpkg"
 )
@@ -90,12 +92,19 @@ func TestGenerate(t *testing.T) {
 	testGenerate(t, "defarray", definedArray{})
 	testGenerate(t, "defmap", definedMap{})
 	testGenerate(t, "slicemarsh", []marsh{})
+	testGenerate(t, "codecmarsh", reading{})
+	testGenerateOptions(t, "encodeonly", &GenerateOptions{FieldTag: "test", EncodeOnly: true}, []smallStruct{})
+	testGenerateOptions(t, "decodeonly", &GenerateOptions{FieldTag: "test", DecodeOnly: true}, []smallStruct{})
 }
 
 func testGenerate(t *testing.T, name string, x interface{}) {
+	testGenerateOptions(t, name, &GenerateOptions{FieldTag: "test"}, x)
+}
+
+func testGenerateOptions(t *testing.T, name string, opts *GenerateOptions, x interface{}) {
 	t.Run(name, func(t *testing.T) {
 		var buf bytes.Buffer
-		if err := generate(&buf, "github.com/jba/codec", "test", x); err != nil {
+		if err := generate(&buf, "github.com/jba/codec", opts, x); err != nil {
 			t.Fatal(err)
 		}
 		got := buf.String()
@@ -133,11 +142,101 @@ func readGolden(t *testing.T, name string) string {
 
 func TestGenerateErrors(t *testing.T) {
 	var buf bytes.Buffer
-	err := generate(&buf, "github.com/jba/codec", "test", struct{ X int }{})
+	err := generate(&buf, "github.com/jba/codec", &GenerateOptions{FieldTag: "test"}, struct{ X int }{})
 	const want = "unnamed struct"
 	if err == nil || !strings.Contains(err.Error(), want) {
 		t.Errorf("got %v, want error containing %q", err, want)
 	}
+
+	for _, test := range []struct {
+		names map[reflect.Type][]string
+		want  string
+	}{
+		{map[reflect.Type][]string{reflect.TypeOf(0): {"p.Int"}}, "no codec generated"},
+		{map[reflect.Type][]string{reflect.TypeOf(smallStruct{}): {"github.com/jba/codec.smallStruct"}}, "current name"},
+	} {
+		buf.Reset()
+		err := generate(&buf, "github.com/jba/codec", &GenerateOptions{PreviousNames: test.names}, smallStruct{})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("got %v, want error containing %q", err, test.want)
+		}
+	}
+
+	err = generate(&buf, "github.com/jba/codec", &GenerateOptions{EncodeOnly: true, DecodeOnly: true}, smallStruct{})
+	if want := "both set"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v, want error containing %q", err, want)
+	}
+
+	moneyType := reflect.TypeOf(money{})
+	for _, test := range []struct {
+		t    reflect.Type
+		cc   CustomCodec
+		want string
+	}{
+		{reflect.TypeOf([]int{}), CustomCodec{Encode: encodeMoney, Decode: decodeMoney}, "not a named type"},
+		{moneyType, CustomCodec{Decode: decodeMoney}, "got <nil>, want"},
+		{moneyType, CustomCodec{Encode: decodeMoney, Decode: decodeMoney}, "want func(*codecapi.Encoder, codec.money) error"},
+		{
+			moneyType,
+			CustomCodec{
+				Encode: func(*codecapi.Encoder, money) error { return nil },
+				Decode: decodeMoney,
+			},
+			"not a top-level function",
+		},
+		{moneyType, CustomCodec{Encode: encodeMoney, Decode: decodeMoney}, "not exported"},
+	} {
+		buf.Reset()
+		opts := &GenerateOptions{Custom: map[reflect.Type]CustomCodec{test.t: test.cc}}
+		pkgPath := "github.com/jba/codec"
+		if test.want == "not exported" {
+			pkgPath = "example.com/other"
+		}
+		err := generate(&buf, pkgPath, opts, invoice{})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("got %v, want error containing %q", err, test.want)
+		}
+	}
+}
+
+// halfProxy has a ToCodec method, but no FromCodec method.
+type halfProxy struct{ X int }
+
+func (*halfProxy) ToCodec() int { return 0 }
+
+func TestGenerateProxyErrors(t *testing.T) {
+	rgbType := reflect.TypeOf(rgb{})
+	for _, test := range []struct {
+		proxies map[reflect.Type]Proxy
+		value   interface{}
+		want    string
+	}{
+		{nil, halfProxy{}, "must have methods ToCodec() P and FromCodec(P) error"},
+		{map[reflect.Type]Proxy{rgbType: {To: "x", From: rgbFromHex}}, rgb{}, "want func(*codec.rgb) P"},
+		{map[reflect.Type]Proxy{rgbType: {To: rgbToHex, From: encodeMoney}}, rgb{}, "From for codec.rgb"},
+		{map[reflect.Type]Proxy{rgbType: {To: func(c *rgb) rgb { return *c }}}, rgb{}, "cannot be its own proxy"},
+	} {
+		var buf bytes.Buffer
+		err := generate(&buf, "github.com/jba/codec", &GenerateOptions{Proxies: test.proxies}, test.value)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("got %v, want error containing %q", err, test.want)
+		}
+	}
+}
+
+func TestIdentFromPath(t *testing.T) {
+	for _, test := range []struct {
+		path, want string
+	}{
+		{"example.com/decimal", "decimal"},
+		{"example.com/go-decimal", "godecimal"},
+		{"example.com/decimal/v2", "decimal"},
+		{"example.com/1x", "pkg1x"},
+	} {
+		if got := identFromPath(test.path); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.path, got, test.want)
+		}
+	}
 }
 
 func TestStructFields(t *testing.T) {
@@ -146,30 +245,191 @@ func TestStructFields(t *testing.T) {
 		B bool
 		I int `codec:"-"` // this field will be ignored
 		C string
-		D int `codec:"N"`
+		D int   `codec:"N"`
+		E int   `codec:",always"`
+		F int   `codec:",required"`
+		G uint8 `codec:",default=0x10"`
+		H int   `codec:"H2,alias=H1,alias=H0"`
 	}
 
 	var (
-		intType    = reflect.TypeOf(0)
-		stringType = reflect.TypeOf("")
-		boolType   = reflect.TypeOf(false)
+		intType    = rtype{reflect.TypeOf(0)}
+		stringType = rtype{reflect.TypeOf("")}
+		boolType   = rtype{reflect.TypeOf(false)}
 	)
 
-	g := &generator{pkgPath: "p", fieldTagKey: "codec"}
-	got := g.structFields(reflect.TypeOf(ef{}))
+	g := &generator{pkgPath: "p", fieldTagKey: "codec", interfaceTypes: reflectInterfaces}
+	got, err := g.structFields(rtype{reflect.TypeOf(ef{})})
+	if err != nil {
+		t.Fatal(err)
+	}
 	want := []field{
-		{"A", intType, "0"},
-		{"B", boolType, "false"},
-		{"C", stringType, `""`},
-		{"N", intType, "0"},
+		{Name: "A", Type: intType, Zero: "0", Path: "A"},
+		{Name: "B", Type: boolType, Zero: "false", Path: "B"},
+		{Name: "C", Type: stringType, Zero: `""`, Path: "C"},
+		{Name: "N", Type: intType, Zero: "0", Path: "D"},
+		{Name: "E", Type: intType, Zero: "0", Path: "E", Always: true},
+		{Name: "F", Type: intType, Zero: "0", Path: "F", Always: true, Required: true},
+		{Name: "G", Type: rtype{reflect.TypeOf(uint8(0))}, Zero: "0", Path: "G", Always: true, Default: "16"},
+		{Name: "H2", Type: intType, Zero: "0", Path: "H", Aliases: []string{"H1", "H0"}},
 	}
 	diff := cmp.Diff(want, got,
-		cmp.Comparer(func(t1, t2 reflect.Type) bool { return t1 == t2 }))
+		cmp.Comparer(func(t1, t2 genType) bool { return t1 == t2 }))
 	if diff != "" {
 		t.Errorf("mismatch (-want, +got):\n%s", diff)
 	}
 }
 
+func TestStructFieldsAliasErrors(t *testing.T) {
+	for _, x := range []interface{}{
+		struct {
+			A int
+			B int `codec:",alias=A"` // alias is another field's name
+		}{},
+		struct {
+			A int `codec:",alias=C"`
+			B int `codec:",alias=C"` // duplicate alias
+		}{},
+		struct {
+			A int `codec:",alias=A"` // alias is the field's own name
+		}{},
+		struct {
+			A int `codec:",alias="` // empty alias
+		}{},
+	} {
+		g := &generator{pkgPath: "p", fieldTagKey: "codec", interfaceTypes: reflectInterfaces}
+		if _, err := g.structFields(rtype{reflect.TypeOf(x)}); err == nil {
+			t.Errorf("%T: got nil, want error", x)
+		}
+	}
+}
+
+type (
+	embedInner struct {
+		X int
+		Y int `codec:"Z"`
+	}
+
+	embedPtrInner struct {
+		P int
+	}
+
+	embedConflict1 struct{ C, D int }
+	embedConflict2 struct {
+		C int
+		D int `codec:"D"`
+	}
+
+	embedOuter struct {
+		A int
+		embedInner
+		*embedPtrInner
+		Y     int        // dominates embedInner.Y, which has a different name anyway
+		X     int        // dominates embedInner.X
+		Named embedInner `codec:"N"`
+		In    embedInner `codec:",inline"` // conflicts with embedInner, so dropped
+		embedConflict1
+		embedConflict2
+	}
+)
+
+func TestDefaultLiteral(t *testing.T) {
+	for _, test := range []struct {
+		val  interface{}
+		lit  string
+		want string // empty means error
+	}{
+		{true, "true", "true"},
+		{true, "1", "true"},
+		{true, "yes", ""},
+		{"", "a b", `"a b"`},
+		{int8(0), "-128", "-128"},
+		{int8(0), "128", ""},
+		{int16(0), "0x7f", "127"},
+		{uint(0), "-1", ""},
+		{uint16(0), "65535", "65535"},
+		{float32(0), "1.5", "1.5"},
+		{float64(0), "1e6", "1e+06"},
+		{float64(0), "inf", ""},
+		{complex128(0), "1+2i", "(1+2i)"},
+		{[]int(nil), "1", ""},
+		{marsh(0), "3", "3"},
+	} {
+		typ := reflect.TypeOf(test.val)
+		got, err := defaultLiteral(rtype{typ}, test.lit)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s, %q: got %q, want error", typ, test.lit, got)
+			}
+		} else if err != nil {
+			t.Errorf("%s, %q: %v", typ, test.lit, err)
+		} else if got != test.want {
+			t.Errorf("%s, %q: got %q, want %q", typ, test.lit, got, test.want)
+		}
+	}
+
+	type bad struct {
+		X int `codec:",required,default=1"`
+	}
+	g := &generator{pkgPath: "p", fieldTagKey: "codec", interfaceTypes: reflectInterfaces}
+	if _, err := g.structFields(rtype{reflect.TypeOf(bad{})}); err == nil {
+		t.Error("required with default: got nil, want error")
+	}
+}
+
+func TestStructFieldsEmbedded(t *testing.T) {
+	g := &generator{pkgPath: "github.com/jba/codec", fieldTagKey: "codec", interfaceTypes: reflectInterfaces}
+	got, err := g.structFields(rtype{reflect.TypeOf(embedOuter{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type pf struct{ Name, Path, Ptrs string }
+	var gotpf []pf
+	for _, f := range got {
+		var ptrs []string
+		for _, p := range f.Ptrs {
+			ptrs = append(ptrs, p.Path)
+		}
+		gotpf = append(gotpf, pf{f.Name, f.Path, strings.Join(ptrs, ",")})
+	}
+	want := []pf{
+		{"A", "A", ""},
+		{"P", "embedPtrInner.P", "embedPtrInner"},
+		{"Y", "Y", ""},
+		{"X", "X", ""},
+		{"N", "Named", ""},
+		{"D", "embedConflict2.D", ""},
+	}
+	if diff := cmp.Diff(want, gotpf); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// From another package, promoted fields that are reachable only through an
+	// unexported embedded field need a valid promoted selector. D has none,
+	// because Go considers it ambiguous.
+	g = &generator{pkgPath: "p", fieldTagKey: "codec", interfaceTypes: reflectInterfaces}
+	got, err = g.structFields(rtype{reflect.TypeOf(embedOuter{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range got {
+		names = append(names, f.Name+":"+f.Path)
+	}
+	wantNames := []string{"A:A", "Y:Y", "X:X", "N:Named"}
+	if diff := cmp.Diff(wantNames, names); diff != "" {
+		t.Errorf("other package: mismatch (-want, +got):\n%s", diff)
+	}
+
+	type badInline struct {
+		I int `codec:",inline"`
+	}
+	_, err = g.structFields(rtype{reflect.TypeOf(badInline{})})
+	if err == nil || !strings.Contains(err.Error(), "inline") {
+		t.Errorf("got %v, want error about inline", err)
+	}
+}
+
 type parseTagStruct struct {
 	NoTag    int
 	Name     int `test:"tag"`
@@ -197,27 +457,30 @@ func TestParseTag(t *testing.T) {
 		if !ok {
 			t.Fatalf("no field %q", test.field)
 		}
-		gotName, gotOmit := parseTag("test", f.Tag)
+		gotName, gotOmit, gotOpts := parseTag("test", f.Tag)
 		if gotName != test.wantName {
 			t.Errorf("name: got %q, want %q", gotName, test.wantName)
 		}
 		if gotOmit != test.wantOmit {
 			t.Errorf("omit: got %t, want %t", gotOmit, test.wantOmit)
 		}
+		if !cmp.Equal(gotOpts, test.wantOpts, cmpopts.EquateEmpty()) {
+			t.Errorf("options: got %q, want %q", gotOpts, test.wantOpts)
+		}
 	}
 
 }
 
 var (
-	cmpType      = reflect.TypeOf(cmp.Indirect{})
-	othercmpType = reflect.TypeOf(othercmp.Indirect{})
-	fooType      = reflect.TypeOf(foo.T(nil))
+	cmpType      = rtype{reflect.TypeOf(cmp.Indirect{})}
+	othercmpType = rtype{reflect.TypeOf(othercmp.Indirect{})}
+	fooType      = rtype{reflect.TypeOf(foo.T(nil))}
 )
 
 func TestGoName(t *testing.T) {
 	var r io.Reader
 	g := &generator{pkgPath: "github.com/jba/codec"}
-	g.buildImportMap([]reflect.Type{cmpType, othercmpType})
+	g.buildImportMap([]genType{cmpType, othercmpType})
 	for _, test := range []struct {
 		v    interface{}
 		want string
@@ -235,7 +498,7 @@ func TestGoName(t *testing.T) {
 		{cmp.Indirect{}, "cmp.Indirect"},
 		{othercmp.Indirect{}, "cmp1.Indirect"},
 	} {
-		got := g.goName(reflect.TypeOf(test.v))
+		got := g.goName(rtype{reflect.TypeOf(test.v)})
 		if got != test.want {
 			t.Errorf("%T: got %q, want %q", test.v, got, test.want)
 		}
@@ -244,11 +507,11 @@ func TestGoName(t *testing.T) {
 
 func TestPackageName(t *testing.T) {
 	for _, test := range []struct {
-		typ  reflect.Type
+		typ  genType
 		want string
 	}{
-		{reflect.TypeOf(0), ""},               // builtin type
-		{reflect.TypeOf(new(cmp.Option)), ""}, // unnamed type
+		{rtype{reflect.TypeOf(0)}, ""},               // builtin type
+		{rtype{reflect.TypeOf(new(cmp.Option))}, ""}, // unnamed type
 		{cmpType, "cmp"},
 		{othercmpType, "cmp"},
 		{fooType, "foo"},
@@ -262,7 +525,7 @@ func TestPackageName(t *testing.T) {
 
 func TestBuildImportMap(t *testing.T) {
 	g := &generator{pkgPath: "github.com/jba/codec"}
-	types := []reflect.Type{reflect.TypeOf(0), cmpType, othercmpType, fooType, reflect.TypeOf(Decoder{})}
+	types := []genType{rtype{reflect.TypeOf(0)}, cmpType, othercmpType, fooType, rtype{reflect.TypeOf(Decoder{})}}
 	g.buildImportMap(types)
 	want := map[string]string{
 		"reflect":                               "",
@@ -290,23 +553,28 @@ func TestBuildImportMap(t *testing.T) {
 
 func TestReferencedTypeList(t *testing.T) {
 	g := &generator{
-		pkgPath:     "github.com/jba/codec",
-		fieldTagKey: "codec",
+		pkgPath:        "github.com/jba/codec",
+		fieldTagKey:    "codec",
+		interfaceTypes: reflectInterfaces,
 	}
 	cmpType := reflect.TypeOf(new(cmp.Option)).Elem()
-	got := g.referencedTypeList([]interface{}{0, []*cmp.Option{}, genStruct{}, token.Pos(0), net.IP{}})
+	var roots []genType
+	for _, v := range []interface{}{0, []*cmp.Option{}, genStruct{}, token.Pos(0), net.IP{}} {
+		roots = append(roots, rtype{reflect.TypeOf(v)})
+	}
+	got := g.referencedTypeList(roots)
 	wantvals := []interface{}{
 		new(cmp.Option), []*cmp.Option{}, cmpType, genStruct{},
 		foo.T{}, token.Pos(0), net.IP{}}
-	var want []reflect.Type
+	var want []genType
 	for _, v := range wantvals {
 		if t, ok := v.(reflect.Type); ok {
-			want = append(want, t)
+			want = append(want, rtype{t})
 		} else {
-			want = append(want, reflect.TypeOf(v))
+			want = append(want, rtype{reflect.TypeOf(v)})
 		}
 	}
-	if !cmp.Equal(got, want, cmp.Comparer(func(t1, t2 reflect.Type) bool { return t1 == t2 })) {
+	if !cmp.Equal(got, want, cmp.Comparer(func(t1, t2 genType) bool { return t1 == t2 })) {
 		t.Errorf("\ngot  %v\nwant %v", got, want)
 	}
 }
@@ -0,0 +1,68 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	api "github.com/jba/codec/codecapi"
+)
+
+// ToJSON reads a stream of encoded values from r and writes each to w as a JSON
+// object, holding the value and the metadata recorded with it. It doesn't need
+// the types the values were encoded from. Shared pointers are marked with
+// "$id", and references to them with "$ref". See FromJSON for the reverse.
+func ToJSON(w io.Writer, r io.Reader) error {
+	d := api.NewDecoder(r, api.DecodeOptions{})
+	var buf bytes.Buffer
+	for i := 0; ; i++ {
+		f, err := d.ReadFrame(nil)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		data, err := api.FrameToJSON(f)
+		if err != nil {
+			return fmt.Errorf("value %d: %v", i, err)
+		}
+		buf.Reset()
+		if err := json.Indent(&buf, data, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// FromJSON reads JSON objects written by ToJSON, possibly edited, from r and
+// writes them to w as a stream of encoded values. The type names and struct
+// fields in each object's metadata determine how its value is encoded, so edits
+// to the value must agree with them.
+func FromJSON(w io.Writer, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	e := api.NewEncoder(w, api.EncodeOptions{})
+	for i := 0; ; i++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		f, err := api.FrameFromJSON(raw)
+		if err != nil {
+			return fmt.Errorf("value %d: %v", i, err)
+		}
+		if err := e.WriteFrame(f); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tm := time.Date(2020, time.March, 20, 0, 0, 0, 0, time.UTC)
+	cycle := &node{Value: 99, Next: &node{Value: 111}}
+	cycle.Next.Next = cycle
+	two := 2
+	values := []interface{}{
+		nil, "Luke Luck likes lakes", "<&>", true, false,
+		1, -5, 255, 65000, uint64(1 << 63), int8(-3), uint8(200),
+		0.0, 98.6, float32(1.5), 1.23e63, math.NaN(), math.Inf(1), math.Inf(-1),
+		complex(1, -2),
+		tm,
+		&tm,
+		net.IPv4(10, 128, 2, 18),
+		cycle,
+		(*node)(nil),
+		[]int{1, 2, -3},
+		[]int(nil),
+		structType{B: 129, N: node{1, nil}, unexported: 23},
+		[1]structType{{B: 4}},
+		map[string]bool{"a": true},
+		map[[1]int]structType{{7}: {B: 99}},
+		[]byte{1, 2, 255},
+		[2]byte{4, 5},
+		&[]int{7, 8},
+		[]*int{&two, &two, nil},
+		promoted{A: 1, embed: embed{E: 2}, ptrEmbed: &ptrEmbed{P: "p"}, In: inlined{Q: true}},
+		invoice{Total: money{300, "EUR"}, Items: []money{{100, "EUR"}}},
+		library{Name: "main", Index: newIndex("a", "b"), Color: rgb{1, 2, 3}},
+	}
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, &EncodeOptions{TrackPointers: true, SchemaFingerprint: true})
+	for _, v := range values {
+		if err := e.Encode(v); err != nil {
+			t.Fatalf("%#v: %v", v, err)
+		}
+	}
+	var js, got bytes.Buffer
+	if err := ToJSON(&js, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if err := FromJSON(&got, bytes.NewReader(js.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), buf.Bytes()) {
+		t.Errorf("round trip changed the data; JSON:\n%s", js.String())
+	}
+}
+
+func TestJSONEdit(t *testing.T) {
+	n := &node{Value: 1, Next: &node{Value: 2}}
+	n.Next.Next = n
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, &EncodeOptions{TrackPointers: true}).Encode(n); err != nil {
+		t.Fatal(err)
+	}
+	var js bytes.Buffer
+	if err := ToJSON(&js, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"$id": 1`, `"$ref": 1`, `"Value": 1`} {
+		if !strings.Contains(js.String(), want) {
+			t.Fatalf("JSON does not contain %q:\n%s", want, js.String())
+		}
+	}
+	edited := strings.Replace(js.String(), `"Value": 1`, `"Value": -10`, 1)
+	var data bytes.Buffer
+	if err := FromJSON(&data, strings.NewReader(edited)); err != nil {
+		t.Fatal(err)
+	}
+	var got *node
+	if err := NewDecoder(&data, nil).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Value != -10 || got.Next.Value != 2 || got.Next.Next != got {
+		t.Errorf("got %+v, %+v", got, got.Next)
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{`[]`, "not a JSON object"},
+		{`{"types": ["int"], "value": {"$type": "string", "$value": "x"}}`, `type "string"`},
+		{`{"types": ["*int"], "value": {"$type": "*int", "$value": {"$ref": 1}}}`, "$ref 1"},
+		{`{"types": ["int8"], "value": {"$type": "int8", "$value": 300}}`, "bad number 300"},
+	} {
+		err := FromJSON(ioutil.Discard, strings.NewReader(test.in))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want error containing %q", test.in, err, test.want)
+		}
+	}
+}
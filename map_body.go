@@ -16,6 +16,12 @@ keys and values.
 In the decode function, we declare a variable v to hold the decoded map value
 rather than decoding directly into m[v]. This is necessary for decode
 functions that take pointers: you can't take a pointer to a map element.
+The variables are declared in the loop so each starts out zero; otherwise
+a struct value would keep the fields of the previous one that were absent from
+the encoding. When merging, v starts out as the existing value.
+
+Split collects the values along with the keys, because a key that is NaN
+can't be used to look up its value.
 «*/»
 
 « $typeID := typeID .Type »
@@ -28,6 +34,12 @@ var «$typeID»_type = reflect.TypeOf((*«$goName»)(nil)).Elem()
 
 type «$typeName» struct {
 	codecapi.NonStruct
+	«- if not encoders»
+		codecapi.NoEncoder
+	«- end»
+	«- if not decoders»
+		codecapi.NoDecoder
+	«- end»
 	«if .KeyField -»
 		«$keyTypeID»_codec *«$keyTypeID»_codec
 	«end -»
@@ -52,40 +64,128 @@ func (c *«$typeName») SetCodecs(tcs []codecapi.TypeCodec) {
 	«end -»
 }
 
+«if encoders»
 func (c *«$typeName») Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(«$goName»)) }
 
 func (c *«$typeName») encode(e *codecapi.Encoder, m «$goName») {
+	start := e.StatsStart()
 	if m == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(2*len(m))
+		for k, v := range m {
+			«encodeStmt .Type.Key "k"»
+			«encodeStmt .Type.Elem "v"»
+		}
 	}
-	e.StartList(2*len(m))
+	if start >= 0 {
+		e.StatsEnd(«$typeID»_type, start)
+	}
+}
+
+func (c *«$typeName») Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(«$goName»)
+	keys := make([]«goName .Type.Key», 0, len(m))
+	vals := make([]«goName .Type.Elem», 0, len(m))
 	for k, v := range m {
-		«encodeStmt .Type.Key "k"»
-		«encodeStmt .Type.Elem "v"»
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				«encodeStmt .Type.Key "k"»
+				«encodeStmt .Type.Elem "v"»
+			}
+		})
 	}
+	return 2 * len(m), parts
 }
+«end»
 
+«if decoders»
 func (c *«$typeName») Decode(d *codecapi.Decoder) interface{} {
 	var x «$goName»
 	c.decode(d, &x)
 	return x
 }
 
+func (c *«$typeName») DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*«$goName»)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z «$goName»
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *«$typeName») decode(d *codecapi.Decoder, p *«$goName») {
+	«- if or (numeric .Type.Key) (numeric .Type.Elem)»
+		«if numeric .Type.Key»keyConv«else»_«end», «if numeric .Type.Elem»elemConv«else»_«end» := d.ElemConversions()
+	«- end»
 	n2 := d.StartList()
 	if n2 < 0 { return }
 	n := n2/2
-	m := make(«$goName», n)
+	var m «$goName»
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(«$goName», n)
+	}
 	var k «goName .Type.Key»
-	var v «goName .Type.Elem»
-	for i := 0; i < n; i++ {
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk «goName .Type.Key»
+		k, inKey = zk, true
+		d.SetMerging(false)
+		«- if numeric .Type.Key»
+			d.Convert(keyConv)
+		«- end»
 		«decodeStmt .Type.Key "k"»
+		d.SetMerging(merging)
+		inKey = false
+		var v «goName .Type.Elem»
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		«- if numeric .Type.Elem»
+			d.Convert(elemConv)
+		«- end»
 		«decodeStmt .Type.Elem "v"»
+		d.SetMerging(merging)
 		m[k] = v
 	}
 	*p = m
 }
+«end»
 
 func init() { codecapi.Register(«$typeID»_type, func() codecapi.TypeCodec { return &«$typeName»{} }) }
 `
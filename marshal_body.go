@@ -7,7 +7,11 @@
 package codec
 
 const marshalBody = `
-«/*» Template body for a type that implements encoding.BinaryMarshaler or encoding.TextMarshaler. «*/»
+«/*»
+Template body for a type that implements codec.Marshaler, encoding.BinaryMarshaler
+or encoding.TextMarshaler. A codec.Marshaler encodes itself directly; the others
+are encoded as bytes.
+«*/»
 
 « $typeID := typeID .Type »
 « $typeName := print $typeID "_codec" »
@@ -17,34 +21,81 @@ var «$typeID»_type = reflect.TypeOf((*«$goName»)(nil)).Elem()
 
 type «$typeName» struct{
 	codecapi.NonStruct
+	«- if not encoders»
+		codecapi.NoEncoder
+	«- end»
+	«- if not decoders»
+		codecapi.NoDecoder
+	«- end»
 }
 
 func (c *«$typeName») TypesUsed() []reflect.Type { return nil }
 
 func (c *«$typeName») SetCodecs([]codecapi.TypeCodec) {}
 
+«if encoders»
 func (c *«$typeName») Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(«$goName»)) }
 
 func (c *«$typeName») encode(e *codecapi.Encoder, m «$goName») {
-	data, err := m.Marshal«.Kind»()
-	if err != nil {
-		codecapi.Fail(err)
+	start := e.StatsStart()
+	«if eq .Kind "Codec" -»
+		if err := m.MarshalCodec(e); err != nil {
+			codecapi.Fail(err)
+		}
+	«- else -»
+		data, err := m.Marshal«.Kind»()
+		if err != nil {
+			codecapi.Fail(err)
+		}
+		e.EncodeBytes(data)
+	«- end»
+	if start >= 0 {
+		e.StatsEnd(«$typeID»_type, start)
 	}
-	e.EncodeBytes(data)
 }
+«end»
 
+«if decoders»
 func (c *«$typeName») Decode(d *codecapi.Decoder) interface{} {
 	var x «$goName»
 	c.decode(d, &x)
 	return x
 }
 
+func (c *«$typeName») DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*«$goName»)
+	«if eq .Kind "Codec" -»
+		if !d.Merging() {
+			var z «$goName»
+			*x = z
+		}
+		c.decode(d, x)
+	«- else -»
+		// Read the data before clearing *x, so that it is unchanged if that fails.
+		data := d.DecodeBytes()
+		if !d.Merging() {
+			var z «$goName»
+			*x = z
+		}
+		if err := x.Unmarshal«.Kind»(data); err != nil {
+			codecapi.Fail(err)
+		}
+	«- end»
+}
+
 func (c *«$typeName») decode(d *codecapi.Decoder, p *«$goName») {
-	data := d.DecodeBytes()
-	if err := p.Unmarshal«.Kind»(data); err != nil {
-		codecapi.Fail(err)
-	}
+	«if eq .Kind "Codec" -»
+		if err := p.UnmarshalCodec(d); err != nil {
+			codecapi.Fail(err)
+		}
+	«- else -»
+		data := d.DecodeBytes()
+		if err := p.Unmarshal«.Kind»(data); err != nil {
+			codecapi.Fail(err)
+		}
+	«- end»
 }
+«end»
 
 func init() {
 	codecapi.Register(«$typeID»_type, func() codecapi.TypeCodec { return &«$typeName»{} })
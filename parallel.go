@@ -0,0 +1,173 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codec
+
+import (
+	"io"
+	"runtime"
+	"sync"
+
+	api "github.com/jba/codec/codecapi"
+)
+
+// A ParallelDecoder decodes the values of a stream on several goroutines.
+//
+// Each call to Encoder.Encode writes a self-contained value, with its own
+// metadata, so the values of a stream can be decoded independently. A
+// ParallelDecoder reads the encoded values in order, decodes them in parallel,
+// and delivers them in the order they were encoded.
+type ParallelDecoder struct {
+	r           io.Reader
+	opts        api.DecodeOptions
+	parallelism int
+}
+
+// NewParallelDecoder returns a ParallelDecoder that reads from r and decodes
+// on up to parallelism goroutines. If parallelism is less than one, it uses
+// runtime.GOMAXPROCS(0).
+func NewParallelDecoder(r io.Reader, parallelism int, opts *DecodeOptions) *ParallelDecoder {
+	if parallelism < 1 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	return &ParallelDecoder{r: r, opts: apiDecodeOptions(opts), parallelism: parallelism}
+}
+
+// Decode decodes all the values in the stream. For each value, it calls newValue
+// to get a pointer to decode into, as with Decoder.Decode, and after the value
+// is decoded, calls f with that pointer. The calls to f happen on the calling
+// goroutine, in stream order. newValue is called on another goroutine, but
+// calls to it are not concurrent with each other.
+//
+// Decode returns nil at the end of the stream. It stops at the first error in
+// stream order, whether from reading, from decoding or from f, and returns it.
+// f is called for every value before the one that failed. If newValue, f or
+// decoding a value panics, as a custom decoder can, Decode stops its goroutines
+// and panics with the same value on the calling goroutine.
+//
+// To bound the memory it uses, Decode reads ahead of the calls to f by no more
+// than about twice the parallelism values.
+func (pd *ParallelDecoder) Decode(newValue func() interface{}, f func(p interface{}) error) error {
+	type job struct {
+		frame    api.Frame
+		p        interface{}
+		panicked interface{} // from newValue or decoding
+		done     chan error  // receives the result of decoding
+	}
+
+	window := 2 * pd.parallelism
+	var (
+		jobs  = make(chan *job)             // to the decoding goroutines
+		order = make(chan *job, window)     // to the calling goroutine, in stream order
+		free  = make(chan []byte, window+2) // frame buffers for reuse
+		stop  = make(chan struct{})         // closed on error
+		wg    sync.WaitGroup
+	)
+
+	// Read frames.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(order)
+		defer close(jobs)
+		d := api.NewDecoder(pd.r, pd.opts)
+		for {
+			var buf []byte
+			select {
+			case buf = <-free:
+			default:
+			}
+			frame, err := d.ReadFrame(buf)
+			if err == io.EOF {
+				return
+			}
+			j := &job{frame: frame, done: make(chan error, 1)}
+			if err != nil {
+				// Deliver the error after the values before it.
+				j.done <- err
+				select {
+				case order <- j:
+				case <-stop:
+				}
+				return
+			}
+			j.panicked = recoverPanic(func() { j.p = newValue() })
+			if j.panicked != nil {
+				// Panic on the calling goroutine, after the values before it.
+				j.done <- nil
+				select {
+				case order <- j:
+				case <-stop:
+				}
+				return
+			}
+			select {
+			case order <- j:
+			case <-stop:
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// Decode frames.
+	for i := 0; i < pd.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d := api.NewDecoder(nil, pd.opts)
+			for j := range jobs {
+				// Panic on the calling goroutine, when it receives the result.
+				var err error
+				j.panicked = recoverPanic(func() { err = d.DecodeFrame(j.frame, j.p) })
+				j.done <- err
+			}
+		}()
+	}
+
+	// Deliver values. After an error, drain order so the reading goroutine
+	// can finish. If f panics, closing stop lets the other goroutines finish.
+	stopped := false
+	stopAll := func() {
+		if !stopped {
+			stopped = true
+			close(stop)
+		}
+	}
+	defer stopAll()
+	var err error
+	for j := range order {
+		if err != nil {
+			continue
+		}
+		err = <-j.done
+		if j.panicked != nil {
+			panic(j.panicked)
+		}
+		if err == nil {
+			err = f(j.p)
+		}
+		if err != nil {
+			stopAll()
+			continue
+		}
+		select {
+		case free <- j.frame.Data:
+		default:
+		}
+	}
+	wg.Wait()
+	return err
+}
+
+// recoverPanic calls f and returns the value it panicked with, or nil.
+func recoverPanic(f func()) (panicked interface{}) {
+	defer func() { panicked = recover() }()
+	f()
+	return nil
+}
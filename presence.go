@@ -0,0 +1,64 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codec
+
+// Presence records which fields of a struct were present in encoded data.
+//
+// To use it, add a field of type Presence to a struct. The field is never
+// encoded. When the struct is decoded, the generated code sets it to describe
+// which of the struct's other fields appeared in the encoded data. Since zero
+// values are not encoded unless the field has the "always" tag option, a field
+// that is absent may have been encoded as its zero value.
+type Presence struct {
+	fields []string // names of the struct's fields, as recorded in the encoding
+	bits   []uint64 // bit i is set if fields[i] was present
+}
+
+// Has reports whether the field with the given name was present. The name is
+// the one recorded in the encoding: the name from the field's tag if there is
+// one, otherwise the name of the Go field.
+func (p *Presence) Has(name string) bool {
+	for i, f := range p.fields {
+		if f == name {
+			return p.isSet(i)
+		}
+	}
+	return false
+}
+
+// Fields returns the names of the fields that were present,
+// in the order they are declared.
+func (p *Presence) Fields() []string {
+	var fs []string
+	for i, f := range p.fields {
+		if p.isSet(i) {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+func (p *Presence) isSet(i int) bool {
+	w := i / 64
+	return w < len(p.bits) && p.bits[w]&(1<<(uint(i)%64)) != 0
+}
+
+// Reset is called by generated code at the start of decoding a struct, with the
+// names of all the struct's fields. It clears p. The bits are freshly
+// allocated, so that decoding doesn't change a copy of the struct.
+func (p *Presence) Reset(fields []string) {
+	p.fields = fields
+	p.bits = make([]uint64, (len(fields)+63)/64)
+}
+
+// Set is called by generated code to record that the i'th field
+// was present.
+func (p *Presence) Set(i int) {
+	w := i / 64
+	for w >= len(p.bits) {
+		p.bits = append(p.bits, 0)
+	}
+	p.bits[w] |= 1 << (uint(i) % 64)
+}
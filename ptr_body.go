@@ -16,6 +16,12 @@ var «$typeID»_type = reflect.TypeOf((«$goName»)(nil))
 
 type «$typeName» struct {
 	codecapi.NonStruct
+	«- if not encoders»
+		codecapi.NoEncoder
+	«- end»
+	«- if not decoders»
+		codecapi.NoDecoder
+	«- end»
 	«if .ElField -»
 		«$elTypeID»_codec *«$elTypeID»_codec
 	«end -»
@@ -34,19 +40,39 @@ type «$typeName» struct {
 «end»
 
 
+«if encoders»
 func (c *«$typeName») Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(«$goName»)) }
 
 func (c *«$typeName») encode(e *codecapi.Encoder, x «$goName») {
-	if !e.StartPtr(x==nil, x) { return }
-	«encodeStmt .Type.Elem "*x"»
+	start := e.StatsStart()
+	if e.StartPtr(x==nil, x) {
+		«encodeStmt .Type.Elem "*x"»
+	}
+	if start >= 0 {
+		e.StatsEnd(«$typeID»_type, start)
+	}
 }
+«end»
 
+«if decoders»
 func (c *«$typeName») Decode(d *codecapi.Decoder) interface{} {
 	var x «$goName»
 	c.decode(d, &x)
 	return x
 }
 
+func (c *«$typeName») DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*«$goName»)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z «$goName»
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *«$typeName») decode(d *codecapi.Decoder, p *«$goName») {
 	proceed, ref := d.StartPtr()
 	if !proceed { return }
@@ -54,11 +80,22 @@ func (c *«$typeName») decode(d *codecapi.Decoder, p *«$goName») {
 		*p = ref.(«$goName»)
 		return
 	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		«decodeStmt .Type.Elem "(**p)"»
+		return
+	}
 	var x «goName .Type.Elem»
 	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
 	«decodeStmt .Type.Elem "x"»
+	d.SetMerging(merging)
 	*p = &x
 }
+«end»
 
 func init() {
 	codecapi.Register(«$typeID»_type, func() codecapi.TypeCodec {return &«$typeName»{}})
@@ -16,6 +16,12 @@ var «$typeID»_type = reflect.TypeOf((*«$goName»)(nil)).Elem()
 
 type «$typeName» struct {
 	codecapi.NonStruct
+	«- if not encoders»
+		codecapi.NoEncoder
+	«- end»
+	«- if not decoders»
+		codecapi.NoDecoder
+	«- end»
 	«if .ElField»
 		«$elTypeID»_codec *«$elTypeID»_codec
 	«end -»
@@ -34,34 +40,93 @@ type «$typeName» struct {
 	func (c *«$typeName») SetCodecs([]codecapi.TypeCodec) {}
 «end»
 
+«if encoders»
 func (c *«$typeName») Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(«$goName»)) }
 
 func (c *«$typeName») encode(e *codecapi.Encoder, s «$goName») {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			«encodeStmt .Type.Elem "x"»
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(«$typeID»_type, start)
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		«encodeStmt .Type.Elem "x"»
+}
+
+func (c *«$typeName») Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.(«$goName»)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				«encodeStmt .Type.Elem "x"»
+			}
+		})
 	}
+	return len(s), parts
 }
+«end»
 
+«if decoders»
 func (c *«$typeName») Decode(d *codecapi.Decoder) interface{} {
 	var x «$goName»
 	c.decode(d, &x)
 	return x
 }
 
+func (c *«$typeName») DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*«$goName»)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z «$goName»
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *«$typeName») decode(d *codecapi.Decoder, p *«$goName») {
+	«- if numeric .Type.Elem»
+		_, elemConv := d.ElemConversions()
+	«- end»
 	n := d.StartList()
 	if n < 0 { return }
 	s := make([]«goName .Type.Elem», n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		«- if numeric .Type.Elem»
+			d.Convert(elemConv)
+		«- end»
 		«decodeStmt .Type.Elem "s[i]"»
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
+«end»
 
 func init() {
   codecapi.Register(«$typeID»_type, func() codecapi.TypeCodec { return &«$typeName»{} })
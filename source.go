@@ -0,0 +1,513 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GenerateFileFromSource is like GenerateFile, but instead of using the types
+// of values, it loads the package in dir from source and generates code for
+// the types it declares with the given names, as well as any types they depend
+// on. packagePath is the package's import path.
+//
+// The package is loaded without the file being generated, and errors in the
+// package are ignored unless they affect the types that code is generated
+// for. So GenerateFileFromSource works on a package that doesn't compile
+// because the generated file is missing or out of date.
+//
+// The PreviousNames, Custom and Proxies options are not supported, because they
+// refer to types and functions by value. A type can still name its proxy with
+// ToCodec and FromCodec methods.
+func GenerateFileFromSource(filename, dir, packagePath string, opts *GenerateOptions, typeNames ...string) error {
+	if !strings.HasSuffix(filename, ".go") {
+		filename += ".go"
+	}
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return err
+	}
+	out, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, f := range append(bp.GoFiles, bp.CgoFiles...) {
+		path, err := filepath.Abs(filepath.Join(dir, f))
+		if err != nil {
+			return err
+		}
+		if path != out {
+			files = append(files, path)
+		}
+	}
+	var buf bytes.Buffer
+	if err := generateFromSource(&buf, dir, files, packagePath, opts, typeNames); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// generateFromSource type-checks the package made of the given files in dir,
+// and writes code for the types in it named by typeNames.
+func generateFromSource(w io.Writer, dir string, files []string, packagePath string, opts *GenerateOptions, typeNames []string) error {
+	if opts != nil && (len(opts.PreviousNames) > 0 || len(opts.Custom) > 0 || len(opts.Proxies) > 0) {
+		return errors.New("PreviousNames, Custom and Proxies are not supported for types loaded from source")
+	}
+	if len(typeNames) == 0 {
+		return errors.New("no type names")
+	}
+	fset := token.NewFileSet()
+	var syntax []*ast.File
+	for _, f := range files {
+		file, err := parser.ParseFile(fset, f, nil, 0)
+		if err != nil {
+			return err
+		}
+		syntax = append(syntax, file)
+	}
+	imp := importer.ForCompiler(fset, "source", nil).(types.ImporterFrom)
+	var typeErrs []string
+	conf := types.Config{
+		Importer: imp,
+		// Keep going after errors. They matter only if they make one of the
+		// types we generate code for invalid.
+		Error: func(err error) { typeErrs = append(typeErrs, err.Error()) },
+	}
+	pkg, _ := conf.Check(packagePath, fset, syntax, nil)
+
+	st := &sourceTypes{byKey: map[string]*srcType{}}
+	var roots []genType
+	for _, name := range typeNames {
+		tn, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return fmt.Errorf("%s: no type named %s", packagePath, name)
+		}
+		if !validType(tn.Type(), map[types.Type]bool{}) {
+			return fmt.Errorf("%s: type %s refers to invalid types; errors in the package:\n%s",
+				packagePath, name, strings.Join(typeErrs, "\n"))
+		}
+		roots = append(roots, st.wrap(tn.Type()))
+	}
+	ifaces, err := st.interfaces(imp, pkg, dir)
+	if err != nil {
+		return err
+	}
+	return generateTypes(w, packagePath, opts, ifaces, roots)
+}
+
+// validType reports whether t and the types it refers to are valid. It returns
+// false if the type checker couldn't determine some of them.
+func validType(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return true
+	}
+	seen[t] = true
+	switch t := unalias(t).(type) {
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Named:
+		return validType(t.Underlying(), seen)
+	case *types.Pointer:
+		return validType(t.Elem(), seen)
+	case *types.Slice:
+		return validType(t.Elem(), seen)
+	case *types.Array:
+		return validType(t.Elem(), seen)
+	case *types.Map:
+		return validType(t.Key(), seen) && validType(t.Elem(), seen)
+	case *types.Chan:
+		return validType(t.Elem(), seen)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !validType(t.Field(i).Type(), seen) {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// unalias returns the type that t, which may be an alias, denotes. Newer
+// versions of go/types represent aliases with their own type, which has an Rhs
+// method.
+func unalias(t types.Type) types.Type {
+	for {
+		a, ok := t.(interface{ Rhs() types.Type })
+		if !ok {
+			return t
+		}
+		t = a.Rhs()
+	}
+}
+
+// interfaces returns the interface types that the generator checks against,
+// as types loaded from source. The types of the codec package are built from
+// their methods, to avoid loading the package.
+func (st *sourceTypes) interfaces(imp types.ImporterFrom, pkg *types.Package, dir string) (interfaceTypes, error) {
+	api, err := imp.ImportFrom("github.com/jba/codec/codecapi", dir, 0)
+	if err != nil {
+		return interfaceTypes{}, err
+	}
+	apiType := func(name string) types.Type {
+		return types.NewPointer(api.Scope().Lookup(name).Type())
+	}
+	enc, err := imp.ImportFrom("encoding", dir, 0)
+	if err != nil {
+		return interfaceTypes{}, err
+	}
+	encodingType := func(name string) genType {
+		return st.wrap(enc.Scope().Lookup(name).Type())
+	}
+	var (
+		errorType = types.Universe.Lookup("error").Type()
+		// A method has the package of the interface that declares it, which
+		// doesn't matter for exported methods.
+		method = func(name string, param, result types.Type) genType {
+			var params, results []*types.Var
+			if param != nil {
+				params = append(params, types.NewParam(token.NoPos, nil, "", param))
+			}
+			if result != nil {
+				results = append(results, types.NewParam(token.NoPos, nil, "", result))
+			}
+			sig := types.NewSignature(nil, types.NewTuple(params...), types.NewTuple(results...), false)
+			fn := types.NewFunc(token.NoPos, pkg, name, sig)
+			return st.wrap(types.NewInterfaceType([]*types.Func{fn}, nil).Complete())
+		}
+	)
+	return interfaceTypes{
+		binaryMarshalerType:   encodingType("BinaryMarshaler"),
+		binaryUnmarshalerType: encodingType("BinaryUnmarshaler"),
+		textMarshalerType:     encodingType("TextMarshaler"),
+		textUnmarshalerType:   encodingType("TextUnmarshaler"),
+		codecMarshalerType:    method("MarshalCodec", apiType("Encoder"), errorType),
+		codecUnmarshalerType:  method("UnmarshalCodec", apiType("Decoder"), errorType),
+		defaulterType:         method("Default", nil, nil),
+	}, nil
+}
+
+// sourceTypes holds the srcTypes for a package loaded from source. There is
+// only one srcType for each distinct type, so they can be compared with ==,
+// like reflect.Types.
+type sourceTypes struct {
+	byKey map[string]*srcType
+}
+
+// A srcType is a genType loaded from source with go/types.
+type srcType struct {
+	st  *sourceTypes
+	typ types.Type
+	str string // reflect.Type.String for typ
+}
+
+// wrap returns the srcType for t.
+func (st *sourceTypes) wrap(t types.Type) genType {
+	t = unalias(t)
+	// Two types are identical when they print the same with full package
+	// paths.
+	key := typeFormat(t, (*types.Package).Path)
+	if s, ok := st.byKey[key]; ok {
+		return s
+	}
+	s := &srcType{st: st, typ: t, str: typeFormat(t, (*types.Package).Name)}
+	st.byKey[key] = s
+	return s
+}
+
+// typeFormat formats t like reflect.Type.String, naming packages with pkgName.
+func typeFormat(t types.Type, pkgName func(*types.Package) string) string {
+	format := func(t types.Type) string { return typeFormat(t, pkgName) }
+	switch t := unalias(t).(type) {
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() == nil {
+			return obj.Name()
+		}
+		return pkgName(obj.Pkg()) + "." + obj.Name()
+	case *types.Basic:
+		switch t.Kind() {
+		case types.Byte:
+			return "uint8"
+		case types.Rune:
+			return "int32"
+		case types.UnsafePointer:
+			return "unsafe.Pointer"
+		}
+		return t.Name()
+	case *types.Pointer:
+		return "*" + format(t.Elem())
+	case *types.Slice:
+		return "[]" + format(t.Elem())
+	case *types.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), format(t.Elem()))
+	case *types.Map:
+		return fmt.Sprintf("map[%s]%s", format(t.Key()), format(t.Elem()))
+	case *types.Chan:
+		switch t.Dir() {
+		case types.SendOnly:
+			return "chan<- " + format(t.Elem())
+		case types.RecvOnly:
+			return "<-chan " + format(t.Elem())
+		}
+		return "chan " + format(t.Elem())
+	case *types.Signature:
+		return "func" + signatureFormat(t, pkgName)
+	case *types.Struct:
+		if t.NumFields() == 0 {
+			return "struct {}"
+		}
+		var fields []string
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			s := format(f.Type())
+			if !f.Anonymous() {
+				s = f.Name() + " " + s
+			}
+			if tag := t.Tag(i); tag != "" {
+				s += " " + strconv.Quote(tag)
+			}
+			fields = append(fields, s)
+		}
+		return "struct { " + strings.Join(fields, "; ") + " }"
+	case *types.Interface:
+		if t.NumMethods() == 0 {
+			return "interface {}"
+		}
+		var methods []string
+		for i := 0; i < t.NumMethods(); i++ {
+			m := t.Method(i)
+			methods = append(methods, m.Name()+signatureFormat(m.Type().(*types.Signature), pkgName))
+		}
+		return "interface { " + strings.Join(methods, "; ") + " }"
+	default:
+		return t.String()
+	}
+}
+
+// signatureFormat formats sig like reflect.Type.String does for a function,
+// without the "func".
+func signatureFormat(sig *types.Signature, pkgName func(*types.Package) string) string {
+	var params, results []string
+	for i := 0; i < sig.Params().Len(); i++ {
+		t := sig.Params().At(i).Type()
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			params = append(params, "..."+typeFormat(t.(*types.Slice).Elem(), pkgName))
+		} else {
+			params = append(params, typeFormat(t, pkgName))
+		}
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		results = append(results, typeFormat(sig.Results().At(i).Type(), pkgName))
+	}
+	s := "(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+		return s
+	case 1:
+		return s + " " + results[0]
+	default:
+		return s + " (" + strings.Join(results, ", ") + ")"
+	}
+}
+
+var basicKinds = map[types.BasicKind]reflect.Kind{
+	types.Bool:          reflect.Bool,
+	types.Int:           reflect.Int,
+	types.Int8:          reflect.Int8,
+	types.Int16:         reflect.Int16,
+	types.Int32:         reflect.Int32,
+	types.Int64:         reflect.Int64,
+	types.Uint:          reflect.Uint,
+	types.Uint8:         reflect.Uint8,
+	types.Uint16:        reflect.Uint16,
+	types.Uint32:        reflect.Uint32,
+	types.Uint64:        reflect.Uint64,
+	types.Uintptr:       reflect.Uintptr,
+	types.Float32:       reflect.Float32,
+	types.Float64:       reflect.Float64,
+	types.Complex64:     reflect.Complex64,
+	types.Complex128:    reflect.Complex128,
+	types.String:        reflect.String,
+	types.UnsafePointer: reflect.UnsafePointer,
+}
+
+func (t *srcType) String() string { return t.str }
+
+func (t *srcType) Kind() reflect.Kind {
+	switch u := t.typ.Underlying().(type) {
+	case *types.Basic:
+		return basicKinds[u.Kind()]
+	case *types.Pointer:
+		return reflect.Ptr
+	case *types.Slice:
+		return reflect.Slice
+	case *types.Array:
+		return reflect.Array
+	case *types.Map:
+		return reflect.Map
+	case *types.Chan:
+		return reflect.Chan
+	case *types.Signature:
+		return reflect.Func
+	case *types.Struct:
+		return reflect.Struct
+	case *types.Interface:
+		return reflect.Interface
+	default:
+		return reflect.Invalid
+	}
+}
+
+func (t *srcType) Name() string {
+	switch u := t.typ.(type) {
+	case *types.Named:
+		return u.Obj().Name()
+	case *types.Basic:
+		if u.Kind() == types.UnsafePointer {
+			return "Pointer"
+		}
+		return t.str
+	default:
+		return ""
+	}
+}
+
+func (t *srcType) PkgPath() string {
+	if n, ok := t.typ.(*types.Named); ok && n.Obj().Pkg() != nil {
+		return n.Obj().Pkg().Path()
+	}
+	return ""
+}
+
+func (t *srcType) Elem() genType {
+	switch u := t.typ.Underlying().(type) {
+	case *types.Pointer:
+		return t.st.wrap(u.Elem())
+	case *types.Slice:
+		return t.st.wrap(u.Elem())
+	case *types.Array:
+		return t.st.wrap(u.Elem())
+	case *types.Map:
+		return t.st.wrap(u.Elem())
+	case *types.Chan:
+		return t.st.wrap(u.Elem())
+	default:
+		panic(fmt.Sprintf("Elem of invalid type %s", t))
+	}
+}
+
+func (t *srcType) Key() genType {
+	return t.st.wrap(t.typ.Underlying().(*types.Map).Key())
+}
+
+func (t *srcType) Len() int {
+	return int(t.typ.Underlying().(*types.Array).Len())
+}
+
+func (t *srcType) Bits() int {
+	switch t.Kind() {
+	case reflect.Int8, reflect.Uint8:
+		return 8
+	case reflect.Int16, reflect.Uint16:
+		return 16
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 32
+	case reflect.Int64, reflect.Uint64, reflect.Float64, reflect.Complex64:
+		return 64
+	case reflect.Complex128:
+		return 128
+	case reflect.Int, reflect.Uint, reflect.Uintptr:
+		return strconv.IntSize
+	default:
+		panic(fmt.Sprintf("Bits of non-arithmetic type %s", t))
+	}
+}
+
+func (t *srcType) NumField() int {
+	return t.typ.Underlying().(*types.Struct).NumFields()
+}
+
+func (t *srcType) Field(i int) structField {
+	s := t.typ.Underlying().(*types.Struct)
+	v := s.Field(i)
+	f := structField{
+		Name:      v.Name(),
+		Type:      t.st.wrap(v.Type()),
+		Tag:       reflect.StructTag(s.Tag(i)),
+		Index:     []int{i},
+		Anonymous: v.Anonymous(),
+	}
+	if !v.Exported() {
+		f.PkgPath = v.Pkg().Path()
+	}
+	return f
+}
+
+func (t *srcType) FieldByName(name string) (structField, bool) {
+	var pkg *types.Package
+	if n, ok := t.typ.(*types.Named); ok {
+		pkg = n.Obj().Pkg()
+	}
+	obj, index, _ := types.LookupFieldOrMethod(t.typ, false, pkg, name)
+	v, ok := obj.(*types.Var)
+	if !ok || !v.IsField() {
+		return structField{}, false
+	}
+	return structField{Name: v.Name(), Type: t.st.wrap(v.Type()), Index: index, Anonymous: v.Anonymous()}, true
+}
+
+func (t *srcType) NumMethod() int {
+	if i, ok := t.typ.Underlying().(*types.Interface); ok {
+		return i.NumMethods()
+	}
+	// Like reflection, count only exported methods.
+	ms := types.NewMethodSet(t.typ)
+	n := 0
+	for i := 0; i < ms.Len(); i++ {
+		if ms.At(i).Obj().Exported() {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *srcType) Implements(u genType) bool {
+	return types.Implements(t.typ, u.(*srcType).typ.Underlying().(*types.Interface))
+}
+
+func (t *srcType) MethodByName(name string) (in, out []genType, ok bool) {
+	sel := types.NewMethodSet(t.typ).Lookup(nil, name)
+	if sel == nil {
+		return nil, nil, false
+	}
+	sig := sel.Type().(*types.Signature)
+	for i := 0; i < sig.Params().Len(); i++ {
+		in = append(in, t.st.wrap(sig.Params().At(i).Type()))
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		out = append(out, t.st.wrap(sig.Results().At(i).Type()))
+	}
+	return in, out, true
+}
+
+func (t *srcType) PtrTo() genType   { return t.st.wrap(types.NewPointer(t.typ)) }
+func (t *srcType) SliceOf() genType { return t.st.wrap(types.NewSlice(t.typ)) }
@@ -0,0 +1,73 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codec
+
+import (
+	"bytes"
+	"go/build"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateFromSource(t *testing.T) {
+	// Load this package with its tests, which declare the types, but without
+	// the code generated for them.
+	bp, err := build.ImportDir(".", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, f := range append(bp.GoFiles, bp.TestGoFiles...) {
+		if f != "types.gen_test.go" {
+			files = append(files, f)
+		}
+	}
+	var want, got bytes.Buffer
+	if err := generate(&want, "github.com/jba/codec", nil, generatedTestTypes{}, genStruct{}, marsh(0)); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"generatedTestTypes", "genStruct", "marsh"}
+	if err := generateFromSource(&got, ".", files, "github.com/jba/codec", nil, names); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+		t.Errorf("mismatch (-reflection, +source):\n%s", diff)
+	}
+}
+
+func TestGenerateFileFromSource(t *testing.T) {
+	const dir = "testdata/broken"
+	filename := filepath.Join(t.TempDir(), "types.gen.go")
+	if err := GenerateFileFromSource(filename, dir, "example.com/broken", nil, "Point"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "var Point_type = "; !strings.Contains(string(got), want) {
+		t.Errorf("generated code does not contain %q:\n%s", want, got)
+	}
+
+	for _, test := range []struct {
+		name string
+		opts *GenerateOptions
+		want string
+	}{
+		{"Line", nil, "undefined: Style"},
+		{"Circle", nil, "no type named Circle"},
+		{"Point", &GenerateOptions{Proxies: map[reflect.Type]Proxy{reflect.TypeOf(rgb{}): {}}}, "not supported"},
+	} {
+		err := GenerateFileFromSource(filename, dir, "example.com/broken", test.opts, test.name)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want error containing %q", test.name, err, test.want)
+		}
+	}
+}
@@ -9,9 +9,26 @@ package codec
 const structBody = `
 «/*»
 Template body for a struct type.
-A struct is encoded as the start code, its exported fields, then
-the end code. Each non-zero field is encoded as its field number followed by
-its value. A field that equals its zero value isn't encoded.
+A struct is encoded as the start code, its exported fields (including
+those promoted from embedded structs), then the end code. Each non-zero
+field is encoded as its field number followed by its value. A field that
+equals its zero value isn't encoded, unless it has the "always" option.
+If the struct has a Presence field, the decoder records in it the numbers of
+the fields it sees.
+Fields that are required or have defaults are always encoded. The decoder keeps
+track of the fields it sees, and after decoding all of them either fails if a
+required field is missing or assigns a missing field its default. A required
+field promoted through an embedded pointer is checked only if decoding
+allocated the pointer.
+When merging into an existing value, the decoder doesn't call Default or
+assign defaults, so fields absent from the encoded data keep their values.
+Values that decoding creates, including embedded structs it allocates, get
+their defaults.
+If any fields have aliases, the codec implements codecapi.FieldAliaser so
+data encoded with the old names can be decoded.
+The codec implements codecapi.FieldKinder and codecapi.FieldElemKinder, so
+the decoder can convert a field, or the elements of a field, whose type has
+changed since it was encoded, and codecapi.FieldTyper, for schemas.
 «*/»
 
 « $typeID := typeID .Type »
@@ -21,7 +38,21 @@ its value. A field that equals its zero value isn't encoded.
 
 var «$typeID»_type = reflect.TypeOf((*«$goName»)(nil)).Elem()
 
+var «$typeID»_fields = []string{«range .Fields»"«.Name»", «end»}
+
+var «$typeID»_kinds = []reflect.Kind{«range .Fields»«wireKind .Type», «end»}
+
+var «$typeID»_elemKinds = [][]reflect.Kind{«range .Fields»«elemKinds .Type», «end»}
+
+var «$typeID»_fieldTypes = []reflect.Type{«range .Fields»«reflectType .Type», «end»}
+
 type «$typeName» struct{
+	«- if not encoders»
+		codecapi.NoEncoder
+	«- end»
+	«- if not decoders»
+		codecapi.NoDecoder
+	«- end»
 	«range .FieldTypes»
 		«typeID .»_codec *«typeID .»_codec
 	«- end»
@@ -29,9 +60,36 @@ type «$typeName» struct{
 }
 
 func (c *«$typeName») Fields() []string {
-	return []string{«range .Fields»"«.Name»", «end»}
+	return «$typeID»_fields
+}
+
+func (c *«$typeName») FieldKinds() []reflect.Kind {
+	return «$typeID»_kinds
+}
+
+func (c *«$typeName») FieldElemKinds() [][]reflect.Kind {
+	return «$typeID»_elemKinds
 }
 
+func (c *«$typeName») FieldTypes() []reflect.Type {
+	return «$typeID»_fieldTypes
+}
+
+«if .HasAliases»
+	var «$typeID»_aliases = map[string]string{
+		«- range .Fields»
+			«- $name := .Name»
+			«- range .Aliases»
+				"«.»": "«$name»",
+			«- end»
+		«- end»
+	}
+
+	func (c *«$typeName») FieldAliases() map[string]string {
+		return «$typeID»_aliases
+	}
+«end»
+
 func (c *«$typeName») SetFieldMap(fm []int) {
 	c.fieldMap = fm
 }
@@ -46,43 +104,105 @@ func (c *«$typeName») SetCodecs(tcs []codecapi.TypeCodec) {
 	«- end»
 }
 
+«if encoders»
 func (c *«$typeName») Encode(e *codecapi.Encoder, x interface{}) {
 	s := x.(«$goName»)
 	c.encode(e, &s)
  }
 
 func (c *«$typeName») encode(e *codecapi.Encoder, x *«$goName») {
+	start := e.StatsStart()
 	e.StartStruct()
 	«range $i, $f := .Fields»
 		«- if $f.Type -»
-			«- if $f.Zero -»
-				if x.«$f.Name» != «$f.Zero» {
+			«- $cond := encodeCond $f -»
+			«- if $cond -»
+				if «$cond» {
 			«- end»
 			e.EncodeUint(«$i»)
-			«encodeStmt .Type (print "x." $f.Name)»
-			«- if $f.Zero -»
+			«encodeStmt .Type (print "x." $f.Path)»
+			«- if $cond -»
 			}
 			«- end»
 		«- end»
 	«end -»
 	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(«$typeID»_type, start)
+	}
 }
+«end»
 
+«if decoders»
 func (c *«$typeName») Decode(d *codecapi.Decoder) interface{} {
 	var x «$goName»
 	c.decode(d, &x)
 	return x
 }
 
+func (c *«$typeName») DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*«$goName»)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z «$goName»
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *«$typeName») decode(d *codecapi.Decoder, x *«$goName») {
 	d.StartStruct()
+	«- if .Defaulter»
+		if !d.Merging() {
+			x.Default()
+		}
+	«- end»
+	«- with .Presence»
+		x.«.».Reset(«$typeID»_fields)
+	«- end»
+	«- if .TrackFields»
+		var seen [«len .Fields»]bool
+	«- end»
+	«- range $i, $f := .Fields»
+		«- if and (or $f.Default $f.Required) $f.Ptrs»
+			// Whether decoding allocates an embedded struct holding field «$i».
+			fresh«$i» := «range $j, $p := $f.Ptrs»«if $j» || «end»x.«$p.Path» == nil«end»
+		«- end»
+	«- end»
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(«$typeID»_fields[field])
+			}
+		}()
+	}
 	loop: for {
+		field = -1
 		n := d.NextStructField(c.fieldMap)
+		«- if or .Presence .TrackFields»
+			if n >= 0 {
+				«- with .Presence»
+					x.«.».Set(n)
+				«- end»
+				«- if .TrackFields»
+					seen[n] = true
+				«- end»
+			}
+		«- end»
+		field = n
 		switch n {
 		«range $i, $f := .Fields -»
 			«- if $f.Type -»
 			   case «$i»:
-				«decodeStmt $f.Type (print "x." $f.Name)»
+				«- range $f.Ptrs»
+					if x.«.Path» == nil {
+						x.«.Path» = new(«goName .Type.Elem»)
+					}
+				«- end»
+				«decodeStmt $f.Type (print "x." $f.Path)»
 			«end -»
 		«end -»
 		case -1:
@@ -93,9 +213,29 @@ func (c *«$typeName») decode(d *codecapi.Decoder, x *«$goName») {
 			codecapi.Failf("bad struct field value: %d", n)
 		}
 	}
+	«- range $i, $f := .Fields»
+		«- if $f.Required»
+			«- if $f.Ptrs»
+				// The field is missing only if decoding allocated the embedded
+				// struct holding it. Otherwise the struct's pointer was nil when
+				// it was encoded, or may have been, when merging.
+			«- end»
+			if !seen[«$i»]«if $f.Ptrs» && fresh«$i»«range $f.Ptrs» && x.«.Path» != nil«end»«end» {
+				codecapi.Failf("%s: missing required field %s", "«$goName»", "«$f.Name»")
+			}
+		«- else if $f.Default»
+			if !seen[«$i»] && («if $f.Ptrs»fresh«$i» || «end»!d.Merging()) {
+				«- range $f.Ptrs»
+					if x.«.Path» == nil {
+						x.«.Path» = new(«goName .Type.Elem»)
+					}
+				«- end»
+				x.«$f.Path» = «$f.Default»
+			}
+		«- end»
+	«- end»
 }
-
-
+«end»
 
 func init() {
 	codecapi.Register(«$typeID»_type, func() codecapi.TypeCodec { return &«$typeName»{} })
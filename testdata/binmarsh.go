@@ -24,11 +24,15 @@ func (c *time_Time_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *time_Time_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(time.Time)) }
 
 func (c *time_Time_codec) encode(e *codecapi.Encoder, m time.Time) {
+	start := e.StatsStart()
 	data, err := m.MarshalBinary()
 	if err != nil {
 		codecapi.Fail(err)
 	}
 	e.EncodeBytes(data)
+	if start >= 0 {
+		e.StatsEnd(time_Time_type, start)
+	}
 }
 
 func (c *time_Time_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -37,6 +41,19 @@ func (c *time_Time_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *time_Time_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*time.Time)
+	// Read the data before clearing *x, so that it is unchanged if that fails.
+	data := d.DecodeBytes()
+	if !d.Merging() {
+		var z time.Time
+		*x = z
+	}
+	if err := x.UnmarshalBinary(data); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
 func (c *time_Time_codec) decode(d *codecapi.Decoder, p *time.Time) {
 	data := d.DecodeBytes()
 	if err := p.UnmarshalBinary(data); err != nil {
@@ -0,0 +1,18 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package broken doesn't compile without its generated code.
+package broken
+
+type Point struct {
+	X, Y int
+}
+
+// Point_type is declared in the generated code.
+var _ = Point_type
+
+type Line struct {
+	From, To Point
+	Style    Style // undefined
+}
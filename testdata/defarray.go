@@ -22,31 +22,82 @@ func (c *slice_int_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *slice_int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]int)) }
 
 func (c *slice_int_codec) encode(e *codecapi.Encoder, s []int) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(int64(x))
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		e.EncodeInt(int64(x))
+	if start >= 0 {
+		e.StatsEnd(slice_int_type, start)
 	}
 }
 
+func (c *slice_int_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]int)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(int64(x))
+			}
+		})
+	}
+	return len(s), parts
+}
+
 func (c *slice_int_codec) Decode(d *codecapi.Decoder) interface{} {
 	var x []int
 	c.decode(d, &x)
 	return x
 }
 
+func (c *slice_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *slice_int_codec) decode(d *codecapi.Decoder, p *[]int) {
+	_, elemConv := d.ElemConversions()
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
 	s := make([]int, n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
 		s[i] = int(d.DecodeInt())
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
 
@@ -77,7 +128,11 @@ func (c *definedArray_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *definedArray_codec) encode(e *codecapi.Encoder, s *definedArray) {
+	start := e.StatsStart()
 	c.slice_int_codec.encode(e, (*s)[:])
+	if start >= 0 {
+		e.StatsEnd(definedArray_type, start)
+	}
 }
 
 func (c *definedArray_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -86,15 +141,40 @@ func (c *definedArray_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *definedArray_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*definedArray)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z definedArray
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *definedArray_codec) decode(d *codecapi.Decoder, p *definedArray) {
+	_, elemConv := d.ElemConversions()
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
-	if n != 1 {
-		codecapi.Failf("array size mismatch: got %d, want 1", n)
+	if n > 1 {
+		codecapi.Failf("array size mismatch: got %d, want at most 1", n)
+	}
+	if n < 1 && !d.Merging() {
+		*p = definedArray{}
+	}
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
 	}
-	for i := 0; i < n; i++ {
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
 		(*p)[i] = int(d.DecodeInt())
 	}
 }
@@ -26,15 +26,46 @@ func (c *definedMap_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 func (c *definedMap_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(definedMap)) }
 
 func (c *definedMap_codec) encode(e *codecapi.Encoder, m definedMap) {
+	start := e.StatsStart()
 	if m == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			e.EncodeBool(v)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(definedMap_type, start)
 	}
-	e.StartList(2 * len(m))
+}
+
+func (c *definedMap_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(definedMap)
+	keys := make([]string, 0, len(m))
+	vals := make([]bool, 0, len(m))
 	for k, v := range m {
-		e.EncodeString(k)
-		e.EncodeBool(v)
+		keys = append(keys, k)
+		vals = append(vals, v)
 	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				e.EncodeBool(v)
+			}
+		})
+	}
+	return 2 * len(m), parts
 }
 
 func (c *definedMap_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -43,18 +74,62 @@ func (c *definedMap_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *definedMap_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*definedMap)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z definedMap
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *definedMap_codec) decode(d *codecapi.Decoder, p *definedMap) {
 	n2 := d.StartList()
 	if n2 < 0 {
 		return
 	}
 	n := n2 / 2
-	m := make(definedMap, n)
+	var m definedMap
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(definedMap, n)
+	}
 	var k string
-	var v bool
-	for i := 0; i < n; i++ {
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
 		k = d.DecodeString()
+		d.SetMerging(merging)
+		inKey = false
+		var v bool
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
 		v = d.DecodeBool()
+		d.SetMerging(merging)
 		m[k] = v
 	}
 	*p = m
@@ -24,31 +24,82 @@ func (c *definedSlice_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *definedSlice_codec) encode(e *codecapi.Encoder, s definedSlice) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(int64(x))
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		e.EncodeInt(int64(x))
+	if start >= 0 {
+		e.StatsEnd(definedSlice_type, start)
 	}
 }
 
+func (c *definedSlice_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.(definedSlice)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(int64(x))
+			}
+		})
+	}
+	return len(s), parts
+}
+
 func (c *definedSlice_codec) Decode(d *codecapi.Decoder) interface{} {
 	var x definedSlice
 	c.decode(d, &x)
 	return x
 }
 
+func (c *definedSlice_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*definedSlice)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z definedSlice
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *definedSlice_codec) decode(d *codecapi.Decoder, p *definedSlice) {
+	_, elemConv := d.ElemConversions()
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
 	s := make([]int, n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
 		s[i] = int(d.DecodeInt())
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
 
@@ -24,31 +24,80 @@ func (c *slice_interface_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *slice_interface_codec) encode(e *codecapi.Encoder, s []interface{}) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeAny(x)
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		e.EncodeAny(x)
+	if start >= 0 {
+		e.StatsEnd(slice_interface_type, start)
 	}
 }
 
+func (c *slice_interface_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]interface{})
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeAny(x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
 func (c *slice_interface_codec) Decode(d *codecapi.Decoder) interface{} {
 	var x []interface{}
 	c.decode(d, &x)
 	return x
 }
 
+func (c *slice_interface_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]interface{})
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []interface{}
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *slice_interface_codec) decode(d *codecapi.Decoder, p *[]interface{}) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
 	s := make([]interface{}, n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
 		s[i] = d.DecodeAny()
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
 
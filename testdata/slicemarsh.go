@@ -29,31 +29,80 @@ func (c *slice_marsh_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 func (c *slice_marsh_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]marsh)) }
 
 func (c *slice_marsh_codec) encode(e *codecapi.Encoder, s []marsh) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.marsh_codec.encode(e, x)
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		c.marsh_codec.encode(e, x)
+	if start >= 0 {
+		e.StatsEnd(slice_marsh_type, start)
 	}
 }
 
+func (c *slice_marsh_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]marsh)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.marsh_codec.encode(e, x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
 func (c *slice_marsh_codec) Decode(d *codecapi.Decoder) interface{} {
 	var x []marsh
 	c.decode(d, &x)
 	return x
 }
 
+func (c *slice_marsh_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]marsh)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []marsh
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *slice_marsh_codec) decode(d *codecapi.Decoder, p *[]marsh) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
 	s := make([]marsh, n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
 		c.marsh_codec.decode(d, &s[i])
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
 
@@ -76,11 +125,15 @@ func (c *marsh_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *marsh_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(marsh)) }
 
 func (c *marsh_codec) encode(e *codecapi.Encoder, m marsh) {
+	start := e.StatsStart()
 	data, err := m.MarshalText()
 	if err != nil {
 		codecapi.Fail(err)
 	}
 	e.EncodeBytes(data)
+	if start >= 0 {
+		e.StatsEnd(marsh_type, start)
+	}
 }
 
 func (c *marsh_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -89,6 +142,19 @@ func (c *marsh_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *marsh_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*marsh)
+	// Read the data before clearing *x, so that it is unchanged if that fails.
+	data := d.DecodeBytes()
+	if !d.Merging() {
+		var z marsh
+		*x = z
+	}
+	if err := x.UnmarshalText(data); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
 func (c *marsh_codec) decode(d *codecapi.Decoder, p *marsh) {
 	data := d.DecodeBytes()
 	if err := p.UnmarshalText(data); err != nil {
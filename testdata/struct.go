@@ -13,13 +13,33 @@ import (
 
 var genStruct_type = reflect.TypeOf((*genStruct)(nil)).Elem()
 
+var genStruct_fields = []string{"S", "B", "I", "I8", "I16", "I32", "I64", "F32", "F64", "U8", "U16", "U32", "U64", "C64", "C128", "BS", "T", "unexported"}
+
+var genStruct_kinds = []reflect.Kind{reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Complex64, reflect.Complex128, reflect.Slice, reflect.Slice, reflect.Int}
+
+var genStruct_elemKinds = [][]reflect.Kind{nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, {reflect.Uint8}, {reflect.Int}, nil}
+
+var genStruct_fieldTypes = []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*bool)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int8)(nil)).Elem(), reflect.TypeOf((*int16)(nil)).Elem(), reflect.TypeOf((*int32)(nil)).Elem(), reflect.TypeOf((*int64)(nil)).Elem(), reflect.TypeOf((*float32)(nil)).Elem(), reflect.TypeOf((*float64)(nil)).Elem(), reflect.TypeOf((*uint8)(nil)).Elem(), reflect.TypeOf((*uint16)(nil)).Elem(), reflect.TypeOf((*uint32)(nil)).Elem(), reflect.TypeOf((*uint64)(nil)).Elem(), reflect.TypeOf((*complex64)(nil)).Elem(), reflect.TypeOf((*complex128)(nil)).Elem(), reflect.TypeOf((*[]uint8)(nil)).Elem(), reflect.TypeOf((*foo.T)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem()}
+
 type genStruct_codec struct {
 	foo_T_codec *foo_T_codec
 	fieldMap    []int
 }
 
 func (c *genStruct_codec) Fields() []string {
-	return []string{"S", "B", "I", "I8", "I16", "I32", "I64", "F32", "F64", "U8", "U16", "U32", "U64", "C64", "C128", "BS", "T", "unexported"}
+	return genStruct_fields
+}
+
+func (c *genStruct_codec) FieldKinds() []reflect.Kind {
+	return genStruct_kinds
+}
+
+func (c *genStruct_codec) FieldElemKinds() [][]reflect.Kind {
+	return genStruct_elemKinds
+}
+
+func (c *genStruct_codec) FieldTypes() []reflect.Type {
+	return genStruct_fieldTypes
 }
 
 func (c *genStruct_codec) SetFieldMap(fm []int) {
@@ -40,6 +60,7 @@ func (c *genStruct_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *genStruct_codec) encode(e *codecapi.Encoder, x *genStruct) {
+	start := e.StatsStart()
 	e.StartStruct()
 	if x.S != "" {
 		e.EncodeUint(0)
@@ -114,6 +135,9 @@ func (c *genStruct_codec) encode(e *codecapi.Encoder, x *genStruct) {
 		e.EncodeInt(int64(x.unexported))
 	}
 	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(genStruct_type, start)
+	}
 }
 
 func (c *genStruct_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -122,11 +146,33 @@ func (c *genStruct_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *genStruct_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*genStruct)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z genStruct
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *genStruct_codec) decode(d *codecapi.Decoder, x *genStruct) {
 	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(genStruct_fields[field])
+			}
+		}()
+	}
 loop:
 	for {
+		field = -1
 		n := d.NextStructField(c.fieldMap)
+		field = n
 		switch n {
 		case 0:
 			x.S = d.DecodeString()
@@ -192,14 +238,36 @@ func (c *foo_T_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *foo_T_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(foo.T)) }
 
 func (c *foo_T_codec) encode(e *codecapi.Encoder, s foo.T) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(int64(x))
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		e.EncodeInt(int64(x))
+	if start >= 0 {
+		e.StatsEnd(foo_T_type, start)
+	}
+}
+
+func (c *foo_T_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.(foo.T)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(int64(x))
+			}
+		})
 	}
+	return len(s), parts
 }
 
 func (c *foo_T_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -208,15 +276,44 @@ func (c *foo_T_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *foo_T_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*foo.T)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z foo.T
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *foo_T_codec) decode(d *codecapi.Decoder, p *foo.T) {
+	_, elemConv := d.ElemConversions()
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
 	s := make([]int, n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
 		s[i] = int(d.DecodeInt())
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
 
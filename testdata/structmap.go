@@ -31,7 +31,11 @@ func (c *array_1_int_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *array_1_int_codec) encode(e *codecapi.Encoder, s *[1]int) {
+	start := e.StatsStart()
 	c.slice_int_codec.encode(e, (*s)[:])
+	if start >= 0 {
+		e.StatsEnd(array_1_int_type, start)
+	}
 }
 
 func (c *array_1_int_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -40,15 +44,40 @@ func (c *array_1_int_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *array_1_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[1]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [1]int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *array_1_int_codec) decode(d *codecapi.Decoder, p *[1]int) {
+	_, elemConv := d.ElemConversions()
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
-	if n != 1 {
-		codecapi.Failf("array size mismatch: got %d, want 1", n)
+	if n > 1 {
+		codecapi.Failf("array size mismatch: got %d, want at most 1", n)
+	}
+	if n < 1 && !d.Merging() {
+		*p = [1]int{}
+	}
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
 	}
-	for i := 0; i < n; i++ {
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
 		(*p)[i] = int(d.DecodeInt())
 	}
 }
@@ -71,14 +100,36 @@ func (c *slice_int_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *slice_int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]int)) }
 
 func (c *slice_int_codec) encode(e *codecapi.Encoder, s []int) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(int64(x))
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(slice_int_type, start)
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		e.EncodeInt(int64(x))
+}
+
+func (c *slice_int_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]int)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(int64(x))
+			}
+		})
 	}
+	return len(s), parts
 }
 
 func (c *slice_int_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -87,15 +138,44 @@ func (c *slice_int_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *slice_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *slice_int_codec) decode(d *codecapi.Decoder, p *[]int) {
+	_, elemConv := d.ElemConversions()
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
 	s := make([]int, n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
 		s[i] = int(d.DecodeInt())
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
 
@@ -107,12 +187,32 @@ func init() {
 
 var smallStruct_type = reflect.TypeOf((*smallStruct)(nil)).Elem()
 
+var smallStruct_fields = []string{"X"}
+
+var smallStruct_kinds = []reflect.Kind{reflect.Int}
+
+var smallStruct_elemKinds = [][]reflect.Kind{nil}
+
+var smallStruct_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem()}
+
 type smallStruct_codec struct {
 	fieldMap []int
 }
 
 func (c *smallStruct_codec) Fields() []string {
-	return []string{"X"}
+	return smallStruct_fields
+}
+
+func (c *smallStruct_codec) FieldKinds() []reflect.Kind {
+	return smallStruct_kinds
+}
+
+func (c *smallStruct_codec) FieldElemKinds() [][]reflect.Kind {
+	return smallStruct_elemKinds
+}
+
+func (c *smallStruct_codec) FieldTypes() []reflect.Type {
+	return smallStruct_fieldTypes
 }
 
 func (c *smallStruct_codec) SetFieldMap(fm []int) {
@@ -132,12 +232,16 @@ func (c *smallStruct_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *smallStruct_codec) encode(e *codecapi.Encoder, x *smallStruct) {
+	start := e.StatsStart()
 	e.StartStruct()
 	if x.X != 0 {
 		e.EncodeUint(0)
 		e.EncodeInt(int64(x.X))
 	}
 	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(smallStruct_type, start)
+	}
 }
 
 func (c *smallStruct_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -146,11 +250,33 @@ func (c *smallStruct_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *smallStruct_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*smallStruct)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z smallStruct
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *smallStruct_codec) decode(d *codecapi.Decoder, x *smallStruct) {
 	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(smallStruct_fields[field])
+			}
+		}()
+	}
 loop:
 	for {
+		field = -1
 		n := d.NextStructField(c.fieldMap)
+		field = n
 		switch n {
 		case 0:
 			x.X = int(d.DecodeInt())
@@ -195,15 +321,46 @@ func (c *map_array_1_int__smallStruct_codec) Encode(e *codecapi.Encoder, x inter
 }
 
 func (c *map_array_1_int__smallStruct_codec) encode(e *codecapi.Encoder, m map[[1]int]smallStruct) {
+	start := e.StatsStart()
 	if m == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			c.array_1_int_codec.encode(e, &k)
+			c.smallStruct_codec.encode(e, &v)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(map_array_1_int__smallStruct_type, start)
 	}
-	e.StartList(2 * len(m))
+}
+
+func (c *map_array_1_int__smallStruct_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[[1]int]smallStruct)
+	keys := make([][1]int, 0, len(m))
+	vals := make([]smallStruct, 0, len(m))
 	for k, v := range m {
-		c.array_1_int_codec.encode(e, &k)
-		c.smallStruct_codec.encode(e, &v)
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				c.array_1_int_codec.encode(e, &k)
+				c.smallStruct_codec.encode(e, &v)
+			}
+		})
 	}
+	return 2 * len(m), parts
 }
 
 func (c *map_array_1_int__smallStruct_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -212,18 +369,62 @@ func (c *map_array_1_int__smallStruct_codec) Decode(d *codecapi.Decoder) interfa
 	return x
 }
 
+func (c *map_array_1_int__smallStruct_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[[1]int]smallStruct)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[[1]int]smallStruct
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *map_array_1_int__smallStruct_codec) decode(d *codecapi.Decoder, p *map[[1]int]smallStruct) {
 	n2 := d.StartList()
 	if n2 < 0 {
 		return
 	}
 	n := n2 / 2
-	m := make(map[[1]int]smallStruct, n)
+	var m map[[1]int]smallStruct
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[[1]int]smallStruct, n)
+	}
 	var k [1]int
-	var v smallStruct
-	for i := 0; i < n; i++ {
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk [1]int
+		k, inKey = zk, true
+		d.SetMerging(false)
 		c.array_1_int_codec.decode(d, &k)
+		d.SetMerging(merging)
+		inKey = false
+		var v smallStruct
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
 		c.smallStruct_codec.decode(d, &v)
+		d.SetMerging(merging)
 		m[k] = v
 	}
 	*p = m
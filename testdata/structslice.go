@@ -31,31 +31,80 @@ func (c *slice_smallStruct_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *slice_smallStruct_codec) encode(e *codecapi.Encoder, s []smallStruct) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.smallStruct_codec.encode(e, &x)
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		c.smallStruct_codec.encode(e, &x)
+	if start >= 0 {
+		e.StatsEnd(slice_smallStruct_type, start)
 	}
 }
 
+func (c *slice_smallStruct_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]smallStruct)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.smallStruct_codec.encode(e, &x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
 func (c *slice_smallStruct_codec) Decode(d *codecapi.Decoder) interface{} {
 	var x []smallStruct
 	c.decode(d, &x)
 	return x
 }
 
+func (c *slice_smallStruct_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]smallStruct)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []smallStruct
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *slice_smallStruct_codec) decode(d *codecapi.Decoder, p *[]smallStruct) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
 	s := make([]smallStruct, n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
 		c.smallStruct_codec.decode(d, &s[i])
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
 
@@ -67,12 +116,32 @@ func init() {
 
 var smallStruct_type = reflect.TypeOf((*smallStruct)(nil)).Elem()
 
+var smallStruct_fields = []string{"X"}
+
+var smallStruct_kinds = []reflect.Kind{reflect.Int}
+
+var smallStruct_elemKinds = [][]reflect.Kind{nil}
+
+var smallStruct_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem()}
+
 type smallStruct_codec struct {
 	fieldMap []int
 }
 
 func (c *smallStruct_codec) Fields() []string {
-	return []string{"X"}
+	return smallStruct_fields
+}
+
+func (c *smallStruct_codec) FieldKinds() []reflect.Kind {
+	return smallStruct_kinds
+}
+
+func (c *smallStruct_codec) FieldElemKinds() [][]reflect.Kind {
+	return smallStruct_elemKinds
+}
+
+func (c *smallStruct_codec) FieldTypes() []reflect.Type {
+	return smallStruct_fieldTypes
 }
 
 func (c *smallStruct_codec) SetFieldMap(fm []int) {
@@ -92,12 +161,16 @@ func (c *smallStruct_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *smallStruct_codec) encode(e *codecapi.Encoder, x *smallStruct) {
+	start := e.StatsStart()
 	e.StartStruct()
 	if x.X != 0 {
 		e.EncodeUint(0)
 		e.EncodeInt(int64(x.X))
 	}
 	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(smallStruct_type, start)
+	}
 }
 
 func (c *smallStruct_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -106,11 +179,33 @@ func (c *smallStruct_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *smallStruct_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*smallStruct)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z smallStruct
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *smallStruct_codec) decode(d *codecapi.Decoder, x *smallStruct) {
 	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(smallStruct_fields[field])
+			}
+		}()
+	}
 loop:
 	for {
+		field = -1
 		n := d.NextStructField(c.fieldMap)
+		field = n
 		switch n {
 		case 0:
 			x.X = int(d.DecodeInt())
@@ -24,11 +24,15 @@ func (c *net_IP_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *net_IP_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(net.IP)) }
 
 func (c *net_IP_codec) encode(e *codecapi.Encoder, m net.IP) {
+	start := e.StatsStart()
 	data, err := m.MarshalText()
 	if err != nil {
 		codecapi.Fail(err)
 	}
 	e.EncodeBytes(data)
+	if start >= 0 {
+		e.StatsEnd(net_IP_type, start)
+	}
 }
 
 func (c *net_IP_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -37,6 +41,19 @@ func (c *net_IP_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *net_IP_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*net.IP)
+	// Read the data before clearing *x, so that it is unchanged if that fails.
+	data := d.DecodeBytes()
+	if !d.Merging() {
+		var z net.IP
+		*x = z
+	}
+	if err := x.UnmarshalText(data); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
 func (c *net_IP_codec) decode(d *codecapi.Decoder, p *net.IP) {
 	data := d.DecodeBytes()
 	if err := p.UnmarshalText(data); err != nil {
@@ -32,10 +32,13 @@ func (c *ptr_array_1_int_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 func (c *ptr_array_1_int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*[1]int)) }
 
 func (c *ptr_array_1_int_codec) encode(e *codecapi.Encoder, x *[1]int) {
-	if !e.StartPtr(x == nil, x) {
-		return
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.array_1_int_codec.encode(e, x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_array_1_int_type, start)
 	}
-	c.array_1_int_codec.encode(e, x)
 }
 
 func (c *ptr_array_1_int_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -44,6 +47,18 @@ func (c *ptr_array_1_int_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *ptr_array_1_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**[1]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *[1]int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *ptr_array_1_int_codec) decode(d *codecapi.Decoder, p **[1]int) {
 	proceed, ref := d.StartPtr()
 	if !proceed {
@@ -53,9 +68,19 @@ func (c *ptr_array_1_int_codec) decode(d *codecapi.Decoder, p **[1]int) {
 		*p = ref.(*[1]int)
 		return
 	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.array_1_int_codec.decode(d, &(**p))
+		return
+	}
 	var x [1]int
 	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
 	c.array_1_int_codec.decode(d, &x)
+	d.SetMerging(merging)
 	*p = &x
 }
 
@@ -83,10 +108,13 @@ func (c *ptr_slice_int_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 func (c *ptr_slice_int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*[]int)) }
 
 func (c *ptr_slice_int_codec) encode(e *codecapi.Encoder, x *[]int) {
-	if !e.StartPtr(x == nil, x) {
-		return
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.slice_int_codec.encode(e, *x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_slice_int_type, start)
 	}
-	c.slice_int_codec.encode(e, *x)
 }
 
 func (c *ptr_slice_int_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -95,6 +123,18 @@ func (c *ptr_slice_int_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *ptr_slice_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**[]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *[]int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *ptr_slice_int_codec) decode(d *codecapi.Decoder, p **[]int) {
 	proceed, ref := d.StartPtr()
 	if !proceed {
@@ -104,9 +144,19 @@ func (c *ptr_slice_int_codec) decode(d *codecapi.Decoder, p **[]int) {
 		*p = ref.(*[]int)
 		return
 	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.slice_int_codec.decode(d, &(**p))
+		return
+	}
 	var x []int
 	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
 	c.slice_int_codec.decode(d, &x)
+	d.SetMerging(merging)
 	*p = &x
 }
 
@@ -114,6 +164,310 @@ func init() {
 	codecapi.Register(ptr_slice_int_type, func() codecapi.TypeCodec { return &ptr_slice_int_codec{} })
 }
 
+//// *codec.dfltNew
+
+var ptr_dfltNew_type = reflect.TypeOf((*dfltNew)(nil))
+
+type ptr_dfltNew_codec struct {
+	codecapi.NonStruct
+	dfltNew_codec *dfltNew_codec
+}
+
+func (c *ptr_dfltNew_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{dfltNew_type}
+}
+
+func (c *ptr_dfltNew_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.dfltNew_codec = tcs[0].(*dfltNew_codec)
+}
+
+func (c *ptr_dfltNew_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*dfltNew)) }
+
+func (c *ptr_dfltNew_codec) encode(e *codecapi.Encoder, x *dfltNew) {
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.dfltNew_codec.encode(e, x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_dfltNew_type, start)
+	}
+}
+
+func (c *ptr_dfltNew_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x *dfltNew
+	c.decode(d, &x)
+	return x
+}
+
+func (c *ptr_dfltNew_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**dfltNew)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *dfltNew
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *ptr_dfltNew_codec) decode(d *codecapi.Decoder, p **dfltNew) {
+	proceed, ref := d.StartPtr()
+	if !proceed {
+		return
+	}
+	if ref != nil {
+		*p = ref.(*dfltNew)
+		return
+	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.dfltNew_codec.decode(d, &(**p))
+		return
+	}
+	var x dfltNew
+	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
+	c.dfltNew_codec.decode(d, &x)
+	d.SetMerging(merging)
+	*p = &x
+}
+
+func init() {
+	codecapi.Register(ptr_dfltNew_type, func() codecapi.TypeCodec { return &ptr_dfltNew_codec{} })
+}
+
+//// *codec.dfltOld
+
+var ptr_dfltOld_type = reflect.TypeOf((*dfltOld)(nil))
+
+type ptr_dfltOld_codec struct {
+	codecapi.NonStruct
+	dfltOld_codec *dfltOld_codec
+}
+
+func (c *ptr_dfltOld_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{dfltOld_type}
+}
+
+func (c *ptr_dfltOld_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.dfltOld_codec = tcs[0].(*dfltOld_codec)
+}
+
+func (c *ptr_dfltOld_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*dfltOld)) }
+
+func (c *ptr_dfltOld_codec) encode(e *codecapi.Encoder, x *dfltOld) {
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.dfltOld_codec.encode(e, x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_dfltOld_type, start)
+	}
+}
+
+func (c *ptr_dfltOld_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x *dfltOld
+	c.decode(d, &x)
+	return x
+}
+
+func (c *ptr_dfltOld_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**dfltOld)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *dfltOld
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *ptr_dfltOld_codec) decode(d *codecapi.Decoder, p **dfltOld) {
+	proceed, ref := d.StartPtr()
+	if !proceed {
+		return
+	}
+	if ref != nil {
+		*p = ref.(*dfltOld)
+		return
+	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.dfltOld_codec.decode(d, &(**p))
+		return
+	}
+	var x dfltOld
+	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
+	c.dfltOld_codec.decode(d, &x)
+	d.SetMerging(merging)
+	*p = &x
+}
+
+func init() {
+	codecapi.Register(ptr_dfltOld_type, func() codecapi.TypeCodec { return &ptr_dfltOld_codec{} })
+}
+
+//// *codec.index
+
+var ptr_index_type = reflect.TypeOf((*index)(nil))
+
+type ptr_index_codec struct {
+	codecapi.NonStruct
+	index_codec *index_codec
+}
+
+func (c *ptr_index_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{index_type}
+}
+
+func (c *ptr_index_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.index_codec = tcs[0].(*index_codec)
+}
+
+func (c *ptr_index_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*index)) }
+
+func (c *ptr_index_codec) encode(e *codecapi.Encoder, x *index) {
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.index_codec.encode(e, x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_index_type, start)
+	}
+}
+
+func (c *ptr_index_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x *index
+	c.decode(d, &x)
+	return x
+}
+
+func (c *ptr_index_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**index)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *index
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *ptr_index_codec) decode(d *codecapi.Decoder, p **index) {
+	proceed, ref := d.StartPtr()
+	if !proceed {
+		return
+	}
+	if ref != nil {
+		*p = ref.(*index)
+		return
+	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.index_codec.decode(d, &(**p))
+		return
+	}
+	var x index
+	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
+	c.index_codec.decode(d, &x)
+	d.SetMerging(merging)
+	*p = &x
+}
+
+func init() {
+	codecapi.Register(ptr_index_type, func() codecapi.TypeCodec { return &ptr_index_codec{} })
+}
+
+//// *codec.mergeSub
+
+var ptr_mergeSub_type = reflect.TypeOf((*mergeSub)(nil))
+
+type ptr_mergeSub_codec struct {
+	codecapi.NonStruct
+	mergeSub_codec *mergeSub_codec
+}
+
+func (c *ptr_mergeSub_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{mergeSub_type}
+}
+
+func (c *ptr_mergeSub_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.mergeSub_codec = tcs[0].(*mergeSub_codec)
+}
+
+func (c *ptr_mergeSub_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*mergeSub)) }
+
+func (c *ptr_mergeSub_codec) encode(e *codecapi.Encoder, x *mergeSub) {
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.mergeSub_codec.encode(e, x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_mergeSub_type, start)
+	}
+}
+
+func (c *ptr_mergeSub_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x *mergeSub
+	c.decode(d, &x)
+	return x
+}
+
+func (c *ptr_mergeSub_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**mergeSub)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *mergeSub
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *ptr_mergeSub_codec) decode(d *codecapi.Decoder, p **mergeSub) {
+	proceed, ref := d.StartPtr()
+	if !proceed {
+		return
+	}
+	if ref != nil {
+		*p = ref.(*mergeSub)
+		return
+	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.mergeSub_codec.decode(d, &(**p))
+		return
+	}
+	var x mergeSub
+	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
+	c.mergeSub_codec.decode(d, &x)
+	d.SetMerging(merging)
+	*p = &x
+}
+
+func init() {
+	codecapi.Register(ptr_mergeSub_type, func() codecapi.TypeCodec { return &ptr_mergeSub_codec{} })
+}
+
 //// *codec.node
 
 var ptr_node_type = reflect.TypeOf((*node)(nil))
@@ -134,10 +488,13 @@ func (c *ptr_node_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 func (c *ptr_node_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*node)) }
 
 func (c *ptr_node_codec) encode(e *codecapi.Encoder, x *node) {
-	if !e.StartPtr(x == nil, x) {
-		return
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.node_codec.encode(e, x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_node_type, start)
 	}
-	c.node_codec.encode(e, x)
 }
 
 func (c *ptr_node_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -146,6 +503,18 @@ func (c *ptr_node_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *ptr_node_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**node)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *node
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *ptr_node_codec) decode(d *codecapi.Decoder, p **node) {
 	proceed, ref := d.StartPtr()
 	if !proceed {
@@ -155,9 +524,19 @@ func (c *ptr_node_codec) decode(d *codecapi.Decoder, p **node) {
 		*p = ref.(*node)
 		return
 	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.node_codec.decode(d, &(**p))
+		return
+	}
 	var x node
 	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
 	c.node_codec.decode(d, &x)
+	d.SetMerging(merging)
 	*p = &x
 }
 
@@ -165,6 +544,82 @@ func init() {
 	codecapi.Register(ptr_node_type, func() codecapi.TypeCodec { return &ptr_node_codec{} })
 }
 
+//// *codec.ptrEmbed
+
+var ptr_ptrEmbed_type = reflect.TypeOf((*ptrEmbed)(nil))
+
+type ptr_ptrEmbed_codec struct {
+	codecapi.NonStruct
+	ptrEmbed_codec *ptrEmbed_codec
+}
+
+func (c *ptr_ptrEmbed_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{ptrEmbed_type}
+}
+
+func (c *ptr_ptrEmbed_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.ptrEmbed_codec = tcs[0].(*ptrEmbed_codec)
+}
+
+func (c *ptr_ptrEmbed_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*ptrEmbed)) }
+
+func (c *ptr_ptrEmbed_codec) encode(e *codecapi.Encoder, x *ptrEmbed) {
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.ptrEmbed_codec.encode(e, x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_ptrEmbed_type, start)
+	}
+}
+
+func (c *ptr_ptrEmbed_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x *ptrEmbed
+	c.decode(d, &x)
+	return x
+}
+
+func (c *ptr_ptrEmbed_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**ptrEmbed)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *ptrEmbed
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *ptr_ptrEmbed_codec) decode(d *codecapi.Decoder, p **ptrEmbed) {
+	proceed, ref := d.StartPtr()
+	if !proceed {
+		return
+	}
+	if ref != nil {
+		*p = ref.(*ptrEmbed)
+		return
+	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.ptrEmbed_codec.decode(d, &(**p))
+		return
+	}
+	var x ptrEmbed
+	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
+	c.ptrEmbed_codec.decode(d, &x)
+	d.SetMerging(merging)
+	*p = &x
+}
+
+func init() {
+	codecapi.Register(ptr_ptrEmbed_type, func() codecapi.TypeCodec { return &ptr_ptrEmbed_codec{} })
+}
+
 //// *int
 
 var ptr_int_type = reflect.TypeOf((*int)(nil))
@@ -179,10 +634,13 @@ func (c *ptr_int_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *ptr_int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*int)) }
 
 func (c *ptr_int_codec) encode(e *codecapi.Encoder, x *int) {
-	if !e.StartPtr(x == nil, x) {
-		return
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		e.EncodeInt(int64(*x))
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_int_type, start)
 	}
-	e.EncodeInt(int64(*x))
 }
 
 func (c *ptr_int_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -191,6 +649,18 @@ func (c *ptr_int_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *ptr_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *ptr_int_codec) decode(d *codecapi.Decoder, p **int) {
 	proceed, ref := d.StartPtr()
 	if !proceed {
@@ -200,9 +670,19 @@ func (c *ptr_int_codec) decode(d *codecapi.Decoder, p **int) {
 		*p = ref.(*int)
 		return
 	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		(**p) = int(d.DecodeInt())
+		return
+	}
 	var x int
 	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
 	x = int(d.DecodeInt())
+	d.SetMerging(merging)
 	*p = &x
 }
 
@@ -232,10 +712,13 @@ func (c *ptr_map_int__int_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *ptr_map_int__int_codec) encode(e *codecapi.Encoder, x *map[int]int) {
-	if !e.StartPtr(x == nil, x) {
-		return
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.map_int__int_codec.encode(e, *x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_map_int__int_type, start)
 	}
-	c.map_int__int_codec.encode(e, *x)
 }
 
 func (c *ptr_map_int__int_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -244,6 +727,18 @@ func (c *ptr_map_int__int_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *ptr_map_int__int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**map[int]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *map[int]int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *ptr_map_int__int_codec) decode(d *codecapi.Decoder, p **map[int]int) {
 	proceed, ref := d.StartPtr()
 	if !proceed {
@@ -253,9 +748,19 @@ func (c *ptr_map_int__int_codec) decode(d *codecapi.Decoder, p **map[int]int) {
 		*p = ref.(*map[int]int)
 		return
 	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.map_int__int_codec.decode(d, &(**p))
+		return
+	}
 	var x map[int]int
 	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
 	c.map_int__int_codec.decode(d, &x)
+	d.SetMerging(merging)
 	*p = &x
 }
 
@@ -283,10 +788,13 @@ func (c *ptr_time_Time_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 func (c *ptr_time_Time_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(*time.Time)) }
 
 func (c *ptr_time_Time_codec) encode(e *codecapi.Encoder, x *time.Time) {
-	if !e.StartPtr(x == nil, x) {
-		return
+	start := e.StatsStart()
+	if e.StartPtr(x == nil, x) {
+		c.time_Time_codec.encode(e, *x)
+	}
+	if start >= 0 {
+		e.StatsEnd(ptr_time_Time_type, start)
 	}
-	c.time_Time_codec.encode(e, *x)
 }
 
 func (c *ptr_time_Time_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -295,6 +803,18 @@ func (c *ptr_time_Time_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *ptr_time_Time_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(**time.Time)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z *time.Time
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *ptr_time_Time_codec) decode(d *codecapi.Decoder, p **time.Time) {
 	proceed, ref := d.StartPtr()
 	if !proceed {
@@ -304,9 +824,19 @@ func (c *ptr_time_Time_codec) decode(d *codecapi.Decoder, p **time.Time) {
 		*p = ref.(*time.Time)
 		return
 	}
+	if d.Merging() && *p != nil {
+		// Decode into the existing value.
+		d.StoreRef(*p)
+		c.time_Time_codec.decode(d, &(**p))
+		return
+	}
 	var x time.Time
 	d.StoreRef(&x)
+	// x is new, so don't merge into it.
+	merging := d.Merging()
+	d.SetMerging(false)
 	c.time_Time_codec.decode(d, &x)
+	d.SetMerging(merging)
 	*p = &x
 }
 
@@ -342,7 +872,11 @@ func (c *array_1_structType_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *array_1_structType_codec) encode(e *codecapi.Encoder, s *[1]structType) {
+	start := e.StatsStart()
 	c.slice_structType_codec.encode(e, (*s)[:])
+	if start >= 0 {
+		e.StatsEnd(array_1_structType_type, start)
+	}
 }
 
 func (c *array_1_structType_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -351,15 +885,38 @@ func (c *array_1_structType_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *array_1_structType_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[1]structType)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [1]structType
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *array_1_structType_codec) decode(d *codecapi.Decoder, p *[1]structType) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
-	if n != 1 {
-		codecapi.Failf("array size mismatch: got %d, want 1", n)
+	if n > 1 {
+		codecapi.Failf("array size mismatch: got %d, want at most 1", n)
+	}
+	if n < 1 && !d.Merging() {
+		*p = [1]structType{}
 	}
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	for i = 0; i < n; i++ {
 		c.structType_codec.decode(d, &(*p)[i])
 	}
 }
@@ -391,7 +948,11 @@ func (c *array_1_int_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *array_1_int_codec) encode(e *codecapi.Encoder, s *[1]int) {
+	start := e.StatsStart()
 	c.slice_int_codec.encode(e, (*s)[:])
+	if start >= 0 {
+		e.StatsEnd(array_1_int_type, start)
+	}
 }
 
 func (c *array_1_int_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -400,15 +961,40 @@ func (c *array_1_int_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *array_1_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[1]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [1]int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *array_1_int_codec) decode(d *codecapi.Decoder, p *[1]int) {
+	_, elemConv := d.ElemConversions()
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
-	if n != 1 {
-		codecapi.Failf("array size mismatch: got %d, want 1", n)
+	if n > 1 {
+		codecapi.Failf("array size mismatch: got %d, want at most 1", n)
+	}
+	if n < 1 && !d.Merging() {
+		*p = [1]int{}
+	}
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
 	}
-	for i := 0; i < n; i++ {
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
 		(*p)[i] = int(d.DecodeInt())
 	}
 }
@@ -417,15 +1003,171 @@ func init() {
 	codecapi.Register(array_1_int_type, func() codecapi.TypeCodec { return &array_1_int_codec{} })
 }
 
-//// [2]uint8
+//// [2]int64
 
-var array_2_uint8_type = reflect.TypeOf((*[2]uint8)(nil)).Elem()
+var array_2_int64_type = reflect.TypeOf((*[2]int64)(nil)).Elem()
 
-type array_2_uint8_codec struct {
+type array_2_int64_codec struct {
 	codecapi.NonStruct
+	slice_int64_codec *slice_int64_codec
 }
 
-func (c *array_2_uint8_codec) TypesUsed() []reflect.Type {
+func (c *array_2_int64_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_int64_type}
+}
+
+func (c *array_2_int64_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_int64_codec = tcs[0].(*slice_int64_codec)
+}
+
+func (c *array_2_int64_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	a := x.([2]int64)
+	c.encode(e, &a)
+}
+
+func (c *array_2_int64_codec) encode(e *codecapi.Encoder, s *[2]int64) {
+	start := e.StatsStart()
+	c.slice_int64_codec.encode(e, (*s)[:])
+	if start >= 0 {
+		e.StatsEnd(array_2_int64_type, start)
+	}
+}
+
+func (c *array_2_int64_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x [2]int64
+	c.decode(d, &x)
+	return x
+}
+
+func (c *array_2_int64_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[2]int64)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [2]int64
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *array_2_int64_codec) decode(d *codecapi.Decoder, p *[2]int64) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	if n > 2 {
+		codecapi.Failf("array size mismatch: got %d, want at most 2", n)
+	}
+	if n < 2 && !d.Merging() {
+		*p = [2]int64{}
+	}
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		(*p)[i] = d.DecodeInt()
+	}
+}
+
+func init() {
+	codecapi.Register(array_2_int64_type, func() codecapi.TypeCodec { return &array_2_int64_codec{} })
+}
+
+//// [2]int8
+
+var array_2_int8_type = reflect.TypeOf((*[2]int8)(nil)).Elem()
+
+type array_2_int8_codec struct {
+	codecapi.NonStruct
+	slice_int8_codec *slice_int8_codec
+}
+
+func (c *array_2_int8_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_int8_type}
+}
+
+func (c *array_2_int8_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_int8_codec = tcs[0].(*slice_int8_codec)
+}
+
+func (c *array_2_int8_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	a := x.([2]int8)
+	c.encode(e, &a)
+}
+
+func (c *array_2_int8_codec) encode(e *codecapi.Encoder, s *[2]int8) {
+	start := e.StatsStart()
+	c.slice_int8_codec.encode(e, (*s)[:])
+	if start >= 0 {
+		e.StatsEnd(array_2_int8_type, start)
+	}
+}
+
+func (c *array_2_int8_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x [2]int8
+	c.decode(d, &x)
+	return x
+}
+
+func (c *array_2_int8_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[2]int8)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [2]int8
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *array_2_int8_codec) decode(d *codecapi.Decoder, p *[2]int8) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	if n > 2 {
+		codecapi.Failf("array size mismatch: got %d, want at most 2", n)
+	}
+	if n < 2 && !d.Merging() {
+		*p = [2]int8{}
+	}
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		(*p)[i] = int8(d.DecodeByte())
+	}
+}
+
+func init() {
+	codecapi.Register(array_2_int8_type, func() codecapi.TypeCodec { return &array_2_int8_codec{} })
+}
+
+//// [2]uint8
+
+var array_2_uint8_type = reflect.TypeOf((*[2]uint8)(nil)).Elem()
+
+type array_2_uint8_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *array_2_uint8_codec) TypesUsed() []reflect.Type {
 	return []reflect.Type{}
 }
 
@@ -438,7 +1180,11 @@ func (c *array_2_uint8_codec) Encode(e *codecapi.Encoder, x interface{}) {
 }
 
 func (c *array_2_uint8_codec) encode(e *codecapi.Encoder, s *[2]uint8) {
+	start := e.StatsStart()
 	e.EncodeBytes((*s)[:])
+	if start >= 0 {
+		e.StatsEnd(array_2_uint8_type, start)
+	}
 }
 
 func (c *array_2_uint8_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -447,8 +1193,27 @@ func (c *array_2_uint8_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *array_2_uint8_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[2]uint8)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [2]uint8
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *array_2_uint8_codec) decode(d *codecapi.Decoder, p *[2]uint8) {
 	b := d.DecodeBytes()
+	n := len(b)
+	if n > 2 {
+		codecapi.Failf("array size mismatch: got %d, want at most 2", n)
+	}
+	if n < 2 && !d.Merging() {
+		*p = [2]uint8{}
+	}
 	copy((*p)[:], b)
 }
 
@@ -456,6 +1221,84 @@ func init() {
 	codecapi.Register(array_2_uint8_type, func() codecapi.TypeCodec { return &array_2_uint8_codec{} })
 }
 
+//// [3]int
+
+var array_3_int_type = reflect.TypeOf((*[3]int)(nil)).Elem()
+
+type array_3_int_codec struct {
+	codecapi.NonStruct
+	slice_int_codec *slice_int_codec
+}
+
+func (c *array_3_int_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_int_type}
+}
+
+func (c *array_3_int_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_int_codec = tcs[0].(*slice_int_codec)
+}
+
+func (c *array_3_int_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	a := x.([3]int)
+	c.encode(e, &a)
+}
+
+func (c *array_3_int_codec) encode(e *codecapi.Encoder, s *[3]int) {
+	start := e.StatsStart()
+	c.slice_int_codec.encode(e, (*s)[:])
+	if start >= 0 {
+		e.StatsEnd(array_3_int_type, start)
+	}
+}
+
+func (c *array_3_int_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x [3]int
+	c.decode(d, &x)
+	return x
+}
+
+func (c *array_3_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[3]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [3]int
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *array_3_int_codec) decode(d *codecapi.Decoder, p *[3]int) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	if n > 3 {
+		codecapi.Failf("array size mismatch: got %d, want at most 3", n)
+	}
+	if n < 3 && !d.Merging() {
+		*p = [3]int{}
+	}
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		(*p)[i] = int(d.DecodeInt())
+	}
+}
+
+func init() {
+	codecapi.Register(array_3_int_type, func() codecapi.TypeCodec { return &array_3_int_codec{} })
+}
+
 //// []*int
 
 var slice_ptr_int_type = reflect.TypeOf((*[]*int)(nil)).Elem()
@@ -477,14 +1320,36 @@ func (c *slice_ptr_int_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 func (c *slice_ptr_int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]*int)) }
 
 func (c *slice_ptr_int_codec) encode(e *codecapi.Encoder, s []*int) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.ptr_int_codec.encode(e, x)
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		c.ptr_int_codec.encode(e, x)
+	if start >= 0 {
+		e.StatsEnd(slice_ptr_int_type, start)
+	}
+}
+
+func (c *slice_ptr_int_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]*int)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.ptr_int_codec.encode(e, x)
+			}
+		})
 	}
+	return len(s), parts
 }
 
 func (c *slice_ptr_int_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -493,15 +1358,42 @@ func (c *slice_ptr_int_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *slice_ptr_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]*int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []*int
+	c.decode(d, &z)
+	*x = z
+}
+
 func (c *slice_ptr_int_codec) decode(d *codecapi.Decoder, p *[]*int) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
 	s := make([]*int, n)
-	for i := 0; i < n; i++ {
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
 		c.ptr_int_codec.decode(d, &s[i])
 	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
 	*p = s
 }
 
@@ -509,606 +1401,1517 @@ func init() {
 	codecapi.Register(slice_ptr_int_type, func() codecapi.TypeCodec { return &slice_ptr_int_codec{} })
 }
 
-//// []codec.structType
+//// [][]int32
 
-var slice_structType_type = reflect.TypeOf((*[]structType)(nil)).Elem()
+var slice_slice_int32_type = reflect.TypeOf((*[][]int32)(nil)).Elem()
 
-type slice_structType_codec struct {
+type slice_slice_int32_codec struct {
 	codecapi.NonStruct
 
-	structType_codec *structType_codec
+	slice_int32_codec *slice_int32_codec
 }
 
-func (c *slice_structType_codec) TypesUsed() []reflect.Type {
-	return []reflect.Type{structType_type}
+func (c *slice_slice_int32_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_int32_type}
 }
 
-func (c *slice_structType_codec) SetCodecs(tcs []codecapi.TypeCodec) {
-	c.structType_codec = tcs[0].(*structType_codec)
+func (c *slice_slice_int32_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_int32_codec = tcs[0].(*slice_int32_codec)
 }
 
-func (c *slice_structType_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	c.encode(e, x.([]structType))
+func (c *slice_slice_int32_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.([][]int32))
 }
 
-func (c *slice_structType_codec) encode(e *codecapi.Encoder, s []structType) {
+func (c *slice_slice_int32_codec) encode(e *codecapi.Encoder, s [][]int32) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.slice_int32_codec.encode(e, x)
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		c.structType_codec.encode(e, &x)
+	if start >= 0 {
+		e.StatsEnd(slice_slice_int32_type, start)
 	}
 }
 
-func (c *slice_structType_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x []structType
+func (c *slice_slice_int32_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([][]int32)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.slice_int32_codec.encode(e, x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *slice_slice_int32_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x [][]int32
 	c.decode(d, &x)
 	return x
 }
 
-func (c *slice_structType_codec) decode(d *codecapi.Decoder, p *[]structType) {
+func (c *slice_slice_int32_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[][]int32)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [][]int32
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_slice_int32_codec) decode(d *codecapi.Decoder, p *[][]int32) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
-	s := make([]structType, n)
-	for i := 0; i < n; i++ {
-		c.structType_codec.decode(d, &s[i])
+	s := make([][]int32, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		c.slice_int32_codec.decode(d, &s[i])
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
 	}
 	*p = s
 }
 
 func init() {
-	codecapi.Register(slice_structType_type, func() codecapi.TypeCodec { return &slice_structType_codec{} })
+	codecapi.Register(slice_slice_int32_type, func() codecapi.TypeCodec { return &slice_slice_int32_codec{} })
 }
 
-//// []int
+//// [][]int64
 
-var slice_int_type = reflect.TypeOf((*[]int)(nil)).Elem()
+var slice_slice_int64_type = reflect.TypeOf((*[][]int64)(nil)).Elem()
 
-type slice_int_codec struct {
+type slice_slice_int64_codec struct {
 	codecapi.NonStruct
+
+	slice_int64_codec *slice_int64_codec
 }
 
-func (c *slice_int_codec) TypesUsed() []reflect.Type      { return nil }
-func (c *slice_int_codec) SetCodecs([]codecapi.TypeCodec) {}
+func (c *slice_slice_int64_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_int64_type}
+}
 
-func (c *slice_int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]int)) }
+func (c *slice_slice_int64_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_int64_codec = tcs[0].(*slice_int64_codec)
+}
 
-func (c *slice_int_codec) encode(e *codecapi.Encoder, s []int) {
+func (c *slice_slice_int64_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.([][]int64))
+}
+
+func (c *slice_slice_int64_codec) encode(e *codecapi.Encoder, s [][]int64) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.slice_int64_codec.encode(e, x)
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		e.EncodeInt(int64(x))
+	if start >= 0 {
+		e.StatsEnd(slice_slice_int64_type, start)
 	}
 }
 
-func (c *slice_int_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x []int
+func (c *slice_slice_int64_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([][]int64)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.slice_int64_codec.encode(e, x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *slice_slice_int64_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x [][]int64
 	c.decode(d, &x)
 	return x
 }
 
-func (c *slice_int_codec) decode(d *codecapi.Decoder, p *[]int) {
+func (c *slice_slice_int64_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[][]int64)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z [][]int64
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_slice_int64_codec) decode(d *codecapi.Decoder, p *[][]int64) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
-	s := make([]int, n)
-	for i := 0; i < n; i++ {
-		s[i] = int(d.DecodeInt())
+	s := make([][]int64, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		c.slice_int64_codec.decode(d, &s[i])
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
 	}
 	*p = s
 }
 
 func init() {
-	codecapi.Register(slice_int_type, func() codecapi.TypeCodec { return &slice_int_codec{} })
+	codecapi.Register(slice_slice_int64_type, func() codecapi.TypeCodec { return &slice_slice_int64_codec{} })
 }
 
-//// codec.definedArray
+//// []codec.money
 
-var definedArray_type = reflect.TypeOf((*definedArray)(nil)).Elem()
+var slice_money_type = reflect.TypeOf((*[]money)(nil)).Elem()
 
-type definedArray_codec struct {
+type slice_money_codec struct {
 	codecapi.NonStruct
-	slice_int_codec *slice_int_codec
+
+	money_codec *money_codec
 }
 
-func (c *definedArray_codec) TypesUsed() []reflect.Type {
-	return []reflect.Type{slice_int_type}
+func (c *slice_money_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{money_type}
 }
 
-func (c *definedArray_codec) SetCodecs(tcs []codecapi.TypeCodec) {
-	c.slice_int_codec = tcs[0].(*slice_int_codec)
+func (c *slice_money_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.money_codec = tcs[0].(*money_codec)
 }
 
-func (c *definedArray_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	a := x.(definedArray)
-	c.encode(e, &a)
+func (c *slice_money_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]money)) }
+
+func (c *slice_money_codec) encode(e *codecapi.Encoder, s []money) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.money_codec.encode(e, x)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(slice_money_type, start)
+	}
 }
 
-func (c *definedArray_codec) encode(e *codecapi.Encoder, s *definedArray) {
-	c.slice_int_codec.encode(e, (*s)[:])
+func (c *slice_money_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]money)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.money_codec.encode(e, x)
+			}
+		})
+	}
+	return len(s), parts
 }
 
-func (c *definedArray_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x definedArray
+func (c *slice_money_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []money
 	c.decode(d, &x)
 	return x
 }
 
-func (c *definedArray_codec) decode(d *codecapi.Decoder, p *definedArray) {
+func (c *slice_money_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]money)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []money
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_money_codec) decode(d *codecapi.Decoder, p *[]money) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
-	if n != 1 {
-		codecapi.Failf("array size mismatch: got %d, want 1", n)
+	s := make([]money, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
 	}
-	for i := 0; i < n; i++ {
-		(*p)[i] = int(d.DecodeInt())
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		c.money_codec.decode(d, &s[i])
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
 	}
+	*p = s
 }
 
 func init() {
-	codecapi.Register(definedArray_type, func() codecapi.TypeCodec { return &definedArray_codec{} })
+	codecapi.Register(slice_money_type, func() codecapi.TypeCodec { return &slice_money_codec{} })
 }
 
-//// codec.definedMap
+//// []codec.moved
 
-var definedMap_type = reflect.TypeOf((*definedMap)(nil)).Elem()
+var slice_moved_type = reflect.TypeOf((*[]moved)(nil)).Elem()
 
-type definedMap_codec struct {
+type slice_moved_codec struct {
 	codecapi.NonStruct
+
+	moved_codec *moved_codec
 }
 
-func (c *definedMap_codec) TypesUsed() []reflect.Type {
-	return []reflect.Type{}
+func (c *slice_moved_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{moved_type}
 }
 
-func (c *definedMap_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+func (c *slice_moved_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.moved_codec = tcs[0].(*moved_codec)
 }
 
-func (c *definedMap_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(definedMap)) }
+func (c *slice_moved_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]moved)) }
 
-func (c *definedMap_codec) encode(e *codecapi.Encoder, m definedMap) {
-	if m == nil {
+func (c *slice_moved_codec) encode(e *codecapi.Encoder, s []moved) {
+	start := e.StatsStart()
+	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.moved_codec.encode(e, &x)
+		}
 	}
-	e.StartList(2 * len(m))
-	for k, v := range m {
-		e.EncodeString(k)
-		e.EncodeBool(v)
+	if start >= 0 {
+		e.StatsEnd(slice_moved_type, start)
 	}
 }
 
-func (c *definedMap_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x definedMap
+func (c *slice_moved_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]moved)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.moved_codec.encode(e, &x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *slice_moved_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []moved
 	c.decode(d, &x)
 	return x
 }
 
-func (c *definedMap_codec) decode(d *codecapi.Decoder, p *definedMap) {
-	n2 := d.StartList()
-	if n2 < 0 {
+func (c *slice_moved_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]moved)
+	if d.Merging() {
+		c.decode(d, x)
 		return
 	}
-	n := n2 / 2
-	m := make(definedMap, n)
-	var k string
-	var v bool
-	for i := 0; i < n; i++ {
-		k = d.DecodeString()
-		v = d.DecodeBool()
-		m[k] = v
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []moved
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_moved_codec) decode(d *codecapi.Decoder, p *[]moved) {
+	n := d.StartList()
+	if n < 0 {
+		return
 	}
-	*p = m
+	s := make([]moved, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		c.moved_codec.decode(d, &s[i])
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
+	*p = s
 }
 
 func init() {
-	codecapi.Register(definedMap_type, func() codecapi.TypeCodec { return &definedMap_codec{} })
+	codecapi.Register(slice_moved_type, func() codecapi.TypeCodec { return &slice_moved_codec{} })
 }
 
-//// codec.definedSlice
+//// []codec.parallelItem
 
-var definedSlice_type = reflect.TypeOf((*definedSlice)(nil)).Elem()
+var slice_parallelItem_type = reflect.TypeOf((*[]parallelItem)(nil)).Elem()
 
-type definedSlice_codec struct {
+type slice_parallelItem_codec struct {
 	codecapi.NonStruct
+
+	parallelItem_codec *parallelItem_codec
 }
 
-func (c *definedSlice_codec) TypesUsed() []reflect.Type      { return nil }
-func (c *definedSlice_codec) SetCodecs([]codecapi.TypeCodec) {}
+func (c *slice_parallelItem_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{parallelItem_type}
+}
 
-func (c *definedSlice_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	c.encode(e, x.(definedSlice))
+func (c *slice_parallelItem_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.parallelItem_codec = tcs[0].(*parallelItem_codec)
 }
 
-func (c *definedSlice_codec) encode(e *codecapi.Encoder, s definedSlice) {
+func (c *slice_parallelItem_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.([]parallelItem))
+}
+
+func (c *slice_parallelItem_codec) encode(e *codecapi.Encoder, s []parallelItem) {
+	start := e.StatsStart()
 	if s == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.parallelItem_codec.encode(e, &x)
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		e.EncodeInt(int64(x))
+	if start >= 0 {
+		e.StatsEnd(slice_parallelItem_type, start)
 	}
 }
 
-func (c *definedSlice_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x definedSlice
+func (c *slice_parallelItem_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]parallelItem)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.parallelItem_codec.encode(e, &x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *slice_parallelItem_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []parallelItem
 	c.decode(d, &x)
 	return x
 }
 
-func (c *definedSlice_codec) decode(d *codecapi.Decoder, p *definedSlice) {
+func (c *slice_parallelItem_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]parallelItem)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []parallelItem
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_parallelItem_codec) decode(d *codecapi.Decoder, p *[]parallelItem) {
 	n := d.StartList()
 	if n < 0 {
 		return
 	}
-	s := make([]int, n)
-	for i := 0; i < n; i++ {
-		s[i] = int(d.DecodeInt())
+	s := make([]parallelItem, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		c.parallelItem_codec.decode(d, &s[i])
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
 	}
 	*p = s
 }
 
 func init() {
-	codecapi.Register(definedSlice_type, func() codecapi.TypeCodec { return &definedSlice_codec{} })
+	codecapi.Register(slice_parallelItem_type, func() codecapi.TypeCodec { return &slice_parallelItem_codec{} })
 }
 
-//// codec.embed
+//// []codec.structType
 
-var embed_type = reflect.TypeOf((*embed)(nil)).Elem()
+var slice_structType_type = reflect.TypeOf((*[]structType)(nil)).Elem()
 
-type embed_codec struct {
-	fieldMap []int
-}
+type slice_structType_codec struct {
+	codecapi.NonStruct
 
-func (c *embed_codec) Fields() []string {
-	return []string{"E"}
+	structType_codec *structType_codec
 }
 
-func (c *embed_codec) SetFieldMap(fm []int) {
-	c.fieldMap = fm
+func (c *slice_structType_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{structType_type}
 }
 
-func (c *embed_codec) TypesUsed() []reflect.Type {
-	return []reflect.Type{}
+func (c *slice_structType_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.structType_codec = tcs[0].(*structType_codec)
 }
 
-func (c *embed_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+func (c *slice_structType_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.([]structType))
 }
 
-func (c *embed_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	s := x.(embed)
-	c.encode(e, &s)
+func (c *slice_structType_codec) encode(e *codecapi.Encoder, s []structType) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			c.structType_codec.encode(e, &x)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(slice_structType_type, start)
+	}
 }
 
-func (c *embed_codec) encode(e *codecapi.Encoder, x *embed) {
-	e.StartStruct()
-	if x.E != 0 {
-		e.EncodeUint(0)
-		e.EncodeInt(int64(x.E))
+func (c *slice_structType_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]structType)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				c.structType_codec.encode(e, &x)
+			}
+		})
 	}
-	e.EndStruct()
+	return len(s), parts
 }
 
-func (c *embed_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x embed
+func (c *slice_structType_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []structType
 	c.decode(d, &x)
 	return x
 }
 
-func (c *embed_codec) decode(d *codecapi.Decoder, x *embed) {
-	d.StartStruct()
-loop:
-	for {
-		n := d.NextStructField(c.fieldMap)
-		switch n {
-		case 0:
-			x.E = int(d.DecodeInt())
-		case -1:
-			break loop
-		case -2:
-			d.UnknownField("embed")
-		default:
-			codecapi.Failf("bad struct field value: %d", n)
-		}
+func (c *slice_structType_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]structType)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []structType
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_structType_codec) decode(d *codecapi.Decoder, p *[]structType) {
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]structType, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		c.structType_codec.decode(d, &s[i])
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
 	}
+	*p = s
 }
 
 func init() {
-	codecapi.Register(embed_type, func() codecapi.TypeCodec { return &embed_codec{} })
+	codecapi.Register(slice_structType_type, func() codecapi.TypeCodec { return &slice_structType_codec{} })
 }
 
-//// codec.generatedTestTypes
+//// []int
 
-var generatedTestTypes_type = reflect.TypeOf((*generatedTestTypes)(nil)).Elem()
+var slice_int_type = reflect.TypeOf((*[]int)(nil)).Elem()
 
-type generatedTestTypes_codec struct {
-	ptr_array_1_int_codec             *ptr_array_1_int_codec
-	ptr_slice_int_codec               *ptr_slice_int_codec
-	ptr_node_codec                    *ptr_node_codec
-	ptr_map_int__int_codec            *ptr_map_int__int_codec
-	ptr_time_Time_codec               *ptr_time_Time_codec
-	array_1_structType_codec          *array_1_structType_codec
-	array_1_int_codec                 *array_1_int_codec
-	array_2_uint8_codec               *array_2_uint8_codec
-	slice_ptr_int_codec               *slice_ptr_int_codec
-	slice_structType_codec            *slice_structType_codec
-	slice_int_codec                   *slice_int_codec
-	definedArray_codec                *definedArray_codec
-	definedMap_codec                  *definedMap_codec
-	definedSlice_codec                *definedSlice_codec
-	structType_codec                  *structType_codec
-	foo_T_codec                       *foo_T_codec
-	map_array_1_int__structType_codec *map_array_1_int__structType_codec
-	map_string__bool_codec            *map_string__bool_codec
-	net_IP_codec                      *net_IP_codec
-	fieldMap                          []int
+type slice_int_codec struct {
+	codecapi.NonStruct
 }
 
-func (c *generatedTestTypes_codec) Fields() []string {
-	return []string{"Node", "Slice", "Array", "ByteSlice", "ByteArray", "Map", "Struct", "IP", "StructSlice", "StructArray", "StructMap", "DefSlice", "DefArray", "DefMap", "Pos", "T", "PtrSlice", "PtrArray", "PtrMap", "PtrTime", "SlicePtrInt"}
-}
+func (c *slice_int_codec) TypesUsed() []reflect.Type      { return nil }
+func (c *slice_int_codec) SetCodecs([]codecapi.TypeCodec) {}
 
-func (c *generatedTestTypes_codec) SetFieldMap(fm []int) {
-	c.fieldMap = fm
-}
+func (c *slice_int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]int)) }
 
-func (c *generatedTestTypes_codec) TypesUsed() []reflect.Type {
-	return []reflect.Type{ptr_array_1_int_type, ptr_slice_int_type, ptr_node_type, ptr_map_int__int_type, ptr_time_Time_type, array_1_structType_type, array_1_int_type, array_2_uint8_type, slice_ptr_int_type, slice_structType_type, slice_int_type, definedArray_type, definedMap_type, definedSlice_type, structType_type, foo_T_type, map_array_1_int__structType_type, map_string__bool_type, net_IP_type}
+func (c *slice_int_codec) encode(e *codecapi.Encoder, s []int) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(int64(x))
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(slice_int_type, start)
+	}
 }
 
-func (c *generatedTestTypes_codec) SetCodecs(tcs []codecapi.TypeCodec) {
-	c.ptr_array_1_int_codec = tcs[0].(*ptr_array_1_int_codec)
-	c.ptr_slice_int_codec = tcs[1].(*ptr_slice_int_codec)
-	c.ptr_node_codec = tcs[2].(*ptr_node_codec)
-	c.ptr_map_int__int_codec = tcs[3].(*ptr_map_int__int_codec)
-	c.ptr_time_Time_codec = tcs[4].(*ptr_time_Time_codec)
-	c.array_1_structType_codec = tcs[5].(*array_1_structType_codec)
-	c.array_1_int_codec = tcs[6].(*array_1_int_codec)
-	c.array_2_uint8_codec = tcs[7].(*array_2_uint8_codec)
-	c.slice_ptr_int_codec = tcs[8].(*slice_ptr_int_codec)
-	c.slice_structType_codec = tcs[9].(*slice_structType_codec)
-	c.slice_int_codec = tcs[10].(*slice_int_codec)
-	c.definedArray_codec = tcs[11].(*definedArray_codec)
-	c.definedMap_codec = tcs[12].(*definedMap_codec)
-	c.definedSlice_codec = tcs[13].(*definedSlice_codec)
-	c.structType_codec = tcs[14].(*structType_codec)
-	c.foo_T_codec = tcs[15].(*foo_T_codec)
-	c.map_array_1_int__structType_codec = tcs[16].(*map_array_1_int__structType_codec)
-	c.map_string__bool_codec = tcs[17].(*map_string__bool_codec)
-	c.net_IP_codec = tcs[18].(*net_IP_codec)
+func (c *slice_int_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]int)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(int64(x))
+			}
+		})
+	}
+	return len(s), parts
 }
 
-func (c *generatedTestTypes_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	s := x.(generatedTestTypes)
-	c.encode(e, &s)
+func (c *slice_int_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []int
+	c.decode(d, &x)
+	return x
 }
 
-func (c *generatedTestTypes_codec) encode(e *codecapi.Encoder, x *generatedTestTypes) {
-	e.StartStruct()
-	if x.Node != nil {
-		e.EncodeUint(0)
-		c.ptr_node_codec.encode(e, x.Node)
-	}
-	if x.Slice != nil {
-		e.EncodeUint(1)
-		c.slice_int_codec.encode(e, x.Slice)
+func (c *slice_int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
 	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []int
+	c.decode(d, &z)
+	*x = z
+}
 
-	e.EncodeUint(2)
-	c.array_1_int_codec.encode(e, &x.Array)
-	if x.ByteSlice != nil {
-		e.EncodeUint(3)
-		e.EncodeBytes(x.ByteSlice)
+func (c *slice_int_codec) decode(d *codecapi.Decoder, p *[]int) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
 	}
-
-	e.EncodeUint(4)
-	c.array_2_uint8_codec.encode(e, &x.ByteArray)
-	if x.Map != nil {
-		e.EncodeUint(5)
-		c.map_string__bool_codec.encode(e, x.Map)
+	s := make([]int, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
 	}
-
-	e.EncodeUint(6)
-	c.structType_codec.encode(e, &x.Struct)
-	if x.IP != nil {
-		e.EncodeUint(7)
-		c.net_IP_codec.encode(e, x.IP)
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		s[i] = int(d.DecodeInt())
 	}
-	if x.StructSlice != nil {
-		e.EncodeUint(8)
-		c.slice_structType_codec.encode(e, x.StructSlice)
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
 	}
+	*p = s
+}
 
-	e.EncodeUint(9)
-	c.array_1_structType_codec.encode(e, &x.StructArray)
-	if x.StructMap != nil {
-		e.EncodeUint(10)
-		c.map_array_1_int__structType_codec.encode(e, x.StructMap)
-	}
-	if x.DefSlice != nil {
-		e.EncodeUint(11)
-		c.definedSlice_codec.encode(e, x.DefSlice)
-	}
+func init() {
+	codecapi.Register(slice_int_type, func() codecapi.TypeCodec { return &slice_int_codec{} })
+}
 
-	e.EncodeUint(12)
-	c.definedArray_codec.encode(e, &x.DefArray)
-	if x.DefMap != nil {
-		e.EncodeUint(13)
-		c.definedMap_codec.encode(e, x.DefMap)
-	}
-	if x.Pos != 0 {
-		e.EncodeUint(14)
-		e.EncodeInt(int64(x.Pos))
-	}
-	if x.T != nil {
-		e.EncodeUint(15)
-		c.foo_T_codec.encode(e, x.T)
-	}
-	if x.PtrSlice != nil {
-		e.EncodeUint(16)
-		c.ptr_slice_int_codec.encode(e, x.PtrSlice)
-	}
-	if x.PtrArray != nil {
-		e.EncodeUint(17)
-		c.ptr_array_1_int_codec.encode(e, x.PtrArray)
-	}
-	if x.PtrMap != nil {
-		e.EncodeUint(18)
-		c.ptr_map_int__int_codec.encode(e, x.PtrMap)
+//// []int32
+
+var slice_int32_type = reflect.TypeOf((*[]int32)(nil)).Elem()
+
+type slice_int32_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *slice_int32_codec) TypesUsed() []reflect.Type      { return nil }
+func (c *slice_int32_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *slice_int32_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]int32)) }
+
+func (c *slice_int32_codec) encode(e *codecapi.Encoder, s []int32) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(int64(x))
+		}
 	}
-	if x.PtrTime != nil {
-		e.EncodeUint(19)
-		c.ptr_time_Time_codec.encode(e, x.PtrTime)
+	if start >= 0 {
+		e.StatsEnd(slice_int32_type, start)
 	}
-	if x.SlicePtrInt != nil {
-		e.EncodeUint(20)
-		c.slice_ptr_int_codec.encode(e, x.SlicePtrInt)
+}
+
+func (c *slice_int32_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]int32)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(int64(x))
+			}
+		})
 	}
-	e.EndStruct()
+	return len(s), parts
 }
 
-func (c *generatedTestTypes_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x generatedTestTypes
+func (c *slice_int32_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []int32
 	c.decode(d, &x)
 	return x
 }
 
-func (c *generatedTestTypes_codec) decode(d *codecapi.Decoder, x *generatedTestTypes) {
-	d.StartStruct()
-loop:
-	for {
-		n := d.NextStructField(c.fieldMap)
-		switch n {
-		case 0:
-			c.ptr_node_codec.decode(d, &x.Node)
-		case 1:
-			c.slice_int_codec.decode(d, &x.Slice)
-		case 2:
-			c.array_1_int_codec.decode(d, &x.Array)
-		case 3:
-			x.ByteSlice = d.DecodeBytes()
-		case 4:
-			c.array_2_uint8_codec.decode(d, &x.ByteArray)
-		case 5:
-			c.map_string__bool_codec.decode(d, &x.Map)
-		case 6:
-			c.structType_codec.decode(d, &x.Struct)
-		case 7:
-			c.net_IP_codec.decode(d, &x.IP)
-		case 8:
-			c.slice_structType_codec.decode(d, &x.StructSlice)
-		case 9:
-			c.array_1_structType_codec.decode(d, &x.StructArray)
-		case 10:
-			c.map_array_1_int__structType_codec.decode(d, &x.StructMap)
-		case 11:
-			c.definedSlice_codec.decode(d, &x.DefSlice)
-		case 12:
-			c.definedArray_codec.decode(d, &x.DefArray)
-		case 13:
-			c.definedMap_codec.decode(d, &x.DefMap)
-		case 14:
-			x.Pos = token.Pos(d.DecodeInt())
-		case 15:
-			c.foo_T_codec.decode(d, &x.T)
-		case 16:
-			c.ptr_slice_int_codec.decode(d, &x.PtrSlice)
-		case 17:
-			c.ptr_array_1_int_codec.decode(d, &x.PtrArray)
-		case 18:
-			c.ptr_map_int__int_codec.decode(d, &x.PtrMap)
-		case 19:
-			c.ptr_time_Time_codec.decode(d, &x.PtrTime)
-		case 20:
-			c.slice_ptr_int_codec.decode(d, &x.SlicePtrInt)
-		case -1:
-			break loop
-		case -2:
-			d.UnknownField("generatedTestTypes")
-		default:
-			codecapi.Failf("bad struct field value: %d", n)
+func (c *slice_int32_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]int32)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []int32
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_int32_codec) decode(d *codecapi.Decoder, p *[]int32) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]int32, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		s[i] = int32(d.DecodeInt())
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
+	*p = s
+}
+
+func init() {
+	codecapi.Register(slice_int32_type, func() codecapi.TypeCodec { return &slice_int32_codec{} })
+}
+
+//// []int64
+
+var slice_int64_type = reflect.TypeOf((*[]int64)(nil)).Elem()
+
+type slice_int64_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *slice_int64_codec) TypesUsed() []reflect.Type      { return nil }
+func (c *slice_int64_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *slice_int64_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]int64)) }
+
+func (c *slice_int64_codec) encode(e *codecapi.Encoder, s []int64) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(x)
 		}
 	}
+	if start >= 0 {
+		e.StatsEnd(slice_int64_type, start)
+	}
+}
+
+func (c *slice_int64_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]int64)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *slice_int64_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []int64
+	c.decode(d, &x)
+	return x
+}
+
+func (c *slice_int64_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]int64)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []int64
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_int64_codec) decode(d *codecapi.Decoder, p *[]int64) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]int64, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		s[i] = d.DecodeInt()
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
+	*p = s
 }
 
 func init() {
-	codecapi.Register(generatedTestTypes_type, func() codecapi.TypeCodec { return &generatedTestTypes_codec{} })
+	codecapi.Register(slice_int64_type, func() codecapi.TypeCodec { return &slice_int64_codec{} })
 }
 
-//// codec.node
+//// []int8
 
-var node_type = reflect.TypeOf((*node)(nil)).Elem()
+var slice_int8_type = reflect.TypeOf((*[]int8)(nil)).Elem()
 
-type node_codec struct {
-	ptr_node_codec *ptr_node_codec
-	fieldMap       []int
+type slice_int8_codec struct {
+	codecapi.NonStruct
 }
 
-func (c *node_codec) Fields() []string {
-	return []string{"Value", "Next"}
+func (c *slice_int8_codec) TypesUsed() []reflect.Type      { return nil }
+func (c *slice_int8_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *slice_int8_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]int8)) }
+
+func (c *slice_int8_codec) encode(e *codecapi.Encoder, s []int8) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeByte(uint8(x))
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(slice_int8_type, start)
+	}
 }
 
-func (c *node_codec) SetFieldMap(fm []int) {
+func (c *slice_int8_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]int8)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeByte(uint8(x))
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *slice_int8_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []int8
+	c.decode(d, &x)
+	return x
+}
+
+func (c *slice_int8_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]int8)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []int8
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_int8_codec) decode(d *codecapi.Decoder, p *[]int8) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]int8, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		s[i] = int8(d.DecodeByte())
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
+	*p = s
+}
+
+func init() {
+	codecapi.Register(slice_int8_type, func() codecapi.TypeCodec { return &slice_int8_codec{} })
+}
+
+//// []string
+
+var slice_string_type = reflect.TypeOf((*[]string)(nil)).Elem()
+
+type slice_string_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *slice_string_codec) TypesUsed() []reflect.Type      { return nil }
+func (c *slice_string_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *slice_string_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.([]string)) }
+
+func (c *slice_string_codec) encode(e *codecapi.Encoder, s []string) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeString(x)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(slice_string_type, start)
+	}
+}
+
+func (c *slice_string_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.([]string)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeString(x)
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *slice_string_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x []string
+	c.decode(d, &x)
+	return x
+}
+
+func (c *slice_string_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*[]string)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z []string
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *slice_string_codec) decode(d *codecapi.Decoder, p *[]string) {
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]string, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		s[i] = d.DecodeString()
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
+	*p = s
+}
+
+func init() {
+	codecapi.Register(slice_string_type, func() codecapi.TypeCodec { return &slice_string_codec{} })
+}
+
+//// codec.celsius
+
+var celsius_type = reflect.TypeOf((*celsius)(nil)).Elem()
+
+type celsius_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *celsius_codec) TypesUsed() []reflect.Type { return nil }
+
+func (c *celsius_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *celsius_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(celsius)) }
+
+func (c *celsius_codec) encode(e *codecapi.Encoder, m celsius) {
+	start := e.StatsStart()
+	if err := m.MarshalCodec(e); err != nil {
+		codecapi.Fail(err)
+	}
+	if start >= 0 {
+		e.StatsEnd(celsius_type, start)
+	}
+}
+
+func (c *celsius_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x celsius
+	c.decode(d, &x)
+	return x
+}
+
+func (c *celsius_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*celsius)
+	if !d.Merging() {
+		var z celsius
+		*x = z
+	}
+	c.decode(d, x)
+}
+
+func (c *celsius_codec) decode(d *codecapi.Decoder, p *celsius) {
+	if err := p.UnmarshalCodec(d); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
+func init() {
+	codecapi.Register(celsius_type, func() codecapi.TypeCodec { return &celsius_codec{} })
+}
+
+//// codec.convNew
+
+var convNew_type = reflect.TypeOf((*convNew)(nil)).Elem()
+
+var convNew_fields = []string{"I", "U", "F", "S", "D", "N", "W", "M", "A", "X", "T"}
+
+var convNew_kinds = []reflect.Kind{reflect.Int64, reflect.Uint16, reflect.Float32, reflect.Array, reflect.Slice, reflect.Slice, reflect.Slice, reflect.Map, reflect.Array, reflect.Slice, reflect.Slice}
+
+var convNew_elemKinds = [][]reflect.Kind{nil, nil, nil, {reflect.Int}, {reflect.Int}, {reflect.Int32}, {reflect.Int64}, {reflect.String, reflect.Uint16}, {reflect.Int8}, {reflect.Slice, reflect.Int32}, {reflect.String}}
+
+var convNew_fieldTypes = []reflect.Type{reflect.TypeOf((*int64)(nil)).Elem(), reflect.TypeOf((*uint16)(nil)).Elem(), reflect.TypeOf((*float32)(nil)).Elem(), reflect.TypeOf((*[3]int)(nil)).Elem(), reflect.TypeOf((*[]int)(nil)).Elem(), reflect.TypeOf((*[]int32)(nil)).Elem(), reflect.TypeOf((*[]int64)(nil)).Elem(), reflect.TypeOf((*map[string]uint16)(nil)).Elem(), reflect.TypeOf((*[2]int8)(nil)).Elem(), reflect.TypeOf((*[][]int32)(nil)).Elem(), reflect.TypeOf((*[]string)(nil)).Elem()}
+
+type convNew_codec struct {
+	array_2_int8_codec       *array_2_int8_codec
+	array_3_int_codec        *array_3_int_codec
+	slice_slice_int32_codec  *slice_slice_int32_codec
+	slice_int_codec          *slice_int_codec
+	slice_int32_codec        *slice_int32_codec
+	slice_int64_codec        *slice_int64_codec
+	slice_string_codec       *slice_string_codec
+	map_string__uint16_codec *map_string__uint16_codec
+	fieldMap                 []int
+}
+
+func (c *convNew_codec) Fields() []string {
+	return convNew_fields
+}
+
+func (c *convNew_codec) FieldKinds() []reflect.Kind {
+	return convNew_kinds
+}
+
+func (c *convNew_codec) FieldElemKinds() [][]reflect.Kind {
+	return convNew_elemKinds
+}
+
+func (c *convNew_codec) FieldTypes() []reflect.Type {
+	return convNew_fieldTypes
+}
+
+func (c *convNew_codec) SetFieldMap(fm []int) {
 	c.fieldMap = fm
 }
 
-func (c *node_codec) TypesUsed() []reflect.Type {
-	return []reflect.Type{ptr_node_type}
+func (c *convNew_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{array_2_int8_type, array_3_int_type, slice_slice_int32_type, slice_int_type, slice_int32_type, slice_int64_type, slice_string_type, map_string__uint16_type}
 }
 
-func (c *node_codec) SetCodecs(tcs []codecapi.TypeCodec) {
-	c.ptr_node_codec = tcs[0].(*ptr_node_codec)
+func (c *convNew_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.array_2_int8_codec = tcs[0].(*array_2_int8_codec)
+	c.array_3_int_codec = tcs[1].(*array_3_int_codec)
+	c.slice_slice_int32_codec = tcs[2].(*slice_slice_int32_codec)
+	c.slice_int_codec = tcs[3].(*slice_int_codec)
+	c.slice_int32_codec = tcs[4].(*slice_int32_codec)
+	c.slice_int64_codec = tcs[5].(*slice_int64_codec)
+	c.slice_string_codec = tcs[6].(*slice_string_codec)
+	c.map_string__uint16_codec = tcs[7].(*map_string__uint16_codec)
 }
 
-func (c *node_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	s := x.(node)
+func (c *convNew_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(convNew)
 	c.encode(e, &s)
 }
 
-func (c *node_codec) encode(e *codecapi.Encoder, x *node) {
+func (c *convNew_codec) encode(e *codecapi.Encoder, x *convNew) {
+	start := e.StatsStart()
 	e.StartStruct()
-	if x.Value != 0 {
+	if x.I != 0 {
 		e.EncodeUint(0)
-		e.EncodeInt(int64(x.Value))
+		e.EncodeInt(x.I)
 	}
-	if x.Next != nil {
+	if x.U != 0 {
 		e.EncodeUint(1)
-		c.ptr_node_codec.encode(e, x.Next)
+		e.EncodeUint(uint64(x.U))
+	}
+	if x.F != 0 {
+		e.EncodeUint(2)
+		e.EncodeFloat(float64(x.F))
+	}
+
+	e.EncodeUint(3)
+	c.array_3_int_codec.encode(e, &x.S)
+	if x.D != nil {
+		e.EncodeUint(4)
+		c.slice_int_codec.encode(e, x.D)
+	}
+	if x.N != nil {
+		e.EncodeUint(5)
+		c.slice_int32_codec.encode(e, x.N)
+	}
+	if x.W != nil {
+		e.EncodeUint(6)
+		c.slice_int64_codec.encode(e, x.W)
+	}
+	if x.M != nil {
+		e.EncodeUint(7)
+		c.map_string__uint16_codec.encode(e, x.M)
+	}
+
+	e.EncodeUint(8)
+	c.array_2_int8_codec.encode(e, &x.A)
+	if x.X != nil {
+		e.EncodeUint(9)
+		c.slice_slice_int32_codec.encode(e, x.X)
+	}
+	if x.T != nil {
+		e.EncodeUint(10)
+		c.slice_string_codec.encode(e, x.T)
 	}
 	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(convNew_type, start)
+	}
 }
 
-func (c *node_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x node
+func (c *convNew_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x convNew
 	c.decode(d, &x)
 	return x
 }
 
-func (c *node_codec) decode(d *codecapi.Decoder, x *node) {
+func (c *convNew_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*convNew)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z convNew
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *convNew_codec) decode(d *codecapi.Decoder, x *convNew) {
 	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(convNew_fields[field])
+			}
+		}()
+	}
 loop:
 	for {
+		field = -1
 		n := d.NextStructField(c.fieldMap)
+		field = n
 		switch n {
 		case 0:
-			x.Value = int(d.DecodeInt())
+			x.I = d.DecodeInt()
 		case 1:
-			c.ptr_node_codec.decode(d, &x.Next)
+			x.U = uint16(d.DecodeUint())
+		case 2:
+			x.F = float32(d.DecodeFloat())
+		case 3:
+			c.array_3_int_codec.decode(d, &x.S)
+		case 4:
+			c.slice_int_codec.decode(d, &x.D)
+		case 5:
+			c.slice_int32_codec.decode(d, &x.N)
+		case 6:
+			c.slice_int64_codec.decode(d, &x.W)
+		case 7:
+			c.map_string__uint16_codec.decode(d, &x.M)
+		case 8:
+			c.array_2_int8_codec.decode(d, &x.A)
+		case 9:
+			c.slice_slice_int32_codec.decode(d, &x.X)
+		case 10:
+			c.slice_string_codec.decode(d, &x.T)
 		case -1:
 			break loop
 		case -2:
-			d.UnknownField("node")
+			d.UnknownField("convNew")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(convNew_type, func() codecapi.TypeCodec { return &convNew_codec{} })
+}
+
+//// codec.convOld
+
+var convOld_type = reflect.TypeOf((*convOld)(nil)).Elem()
+
+var convOld_fields = []string{"I", "U", "F", "S", "D", "N", "W", "M", "A", "X", "T"}
+
+var convOld_kinds = []reflect.Kind{reflect.Int8, reflect.Int, reflect.Float64, reflect.Slice, reflect.Slice, reflect.Slice, reflect.Slice, reflect.Map, reflect.Array, reflect.Slice, reflect.Slice}
+
+var convOld_elemKinds = [][]reflect.Kind{nil, nil, nil, {reflect.Int}, {reflect.Int}, {reflect.Int64}, {reflect.Int32}, {reflect.String, reflect.Int64}, {reflect.Int64}, {reflect.Slice, reflect.Int64}, {reflect.Int}}
+
+var convOld_fieldTypes = []reflect.Type{reflect.TypeOf((*int8)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*float64)(nil)).Elem(), reflect.TypeOf((*[]int)(nil)).Elem(), reflect.TypeOf((*definedSlice)(nil)).Elem(), reflect.TypeOf((*[]int64)(nil)).Elem(), reflect.TypeOf((*[]int32)(nil)).Elem(), reflect.TypeOf((*map[string]int64)(nil)).Elem(), reflect.TypeOf((*[2]int64)(nil)).Elem(), reflect.TypeOf((*[][]int64)(nil)).Elem(), reflect.TypeOf((*[]int)(nil)).Elem()}
+
+type convOld_codec struct {
+	array_2_int64_codec     *array_2_int64_codec
+	slice_slice_int64_codec *slice_slice_int64_codec
+	slice_int_codec         *slice_int_codec
+	slice_int32_codec       *slice_int32_codec
+	slice_int64_codec       *slice_int64_codec
+	definedSlice_codec      *definedSlice_codec
+	map_string__int64_codec *map_string__int64_codec
+	fieldMap                []int
+}
+
+func (c *convOld_codec) Fields() []string {
+	return convOld_fields
+}
+
+func (c *convOld_codec) FieldKinds() []reflect.Kind {
+	return convOld_kinds
+}
+
+func (c *convOld_codec) FieldElemKinds() [][]reflect.Kind {
+	return convOld_elemKinds
+}
+
+func (c *convOld_codec) FieldTypes() []reflect.Type {
+	return convOld_fieldTypes
+}
+
+func (c *convOld_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *convOld_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{array_2_int64_type, slice_slice_int64_type, slice_int_type, slice_int32_type, slice_int64_type, definedSlice_type, map_string__int64_type}
+}
+
+func (c *convOld_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.array_2_int64_codec = tcs[0].(*array_2_int64_codec)
+	c.slice_slice_int64_codec = tcs[1].(*slice_slice_int64_codec)
+	c.slice_int_codec = tcs[2].(*slice_int_codec)
+	c.slice_int32_codec = tcs[3].(*slice_int32_codec)
+	c.slice_int64_codec = tcs[4].(*slice_int64_codec)
+	c.definedSlice_codec = tcs[5].(*definedSlice_codec)
+	c.map_string__int64_codec = tcs[6].(*map_string__int64_codec)
+}
+
+func (c *convOld_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(convOld)
+	c.encode(e, &s)
+}
+
+func (c *convOld_codec) encode(e *codecapi.Encoder, x *convOld) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.I != 0 {
+		e.EncodeUint(0)
+		e.EncodeByte(uint8(x.I))
+	}
+	if x.U != 0 {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.U))
+	}
+	if x.F != 0 {
+		e.EncodeUint(2)
+		e.EncodeFloat(x.F)
+	}
+	if x.S != nil {
+		e.EncodeUint(3)
+		c.slice_int_codec.encode(e, x.S)
+	}
+	if x.D != nil {
+		e.EncodeUint(4)
+		c.definedSlice_codec.encode(e, x.D)
+	}
+	if x.N != nil {
+		e.EncodeUint(5)
+		c.slice_int64_codec.encode(e, x.N)
+	}
+	if x.W != nil {
+		e.EncodeUint(6)
+		c.slice_int32_codec.encode(e, x.W)
+	}
+	if x.M != nil {
+		e.EncodeUint(7)
+		c.map_string__int64_codec.encode(e, x.M)
+	}
+
+	e.EncodeUint(8)
+	c.array_2_int64_codec.encode(e, &x.A)
+	if x.X != nil {
+		e.EncodeUint(9)
+		c.slice_slice_int64_codec.encode(e, x.X)
+	}
+	if x.T != nil {
+		e.EncodeUint(10)
+		c.slice_int_codec.encode(e, x.T)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(convOld_type, start)
+	}
+}
+
+func (c *convOld_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x convOld
+	c.decode(d, &x)
+	return x
+}
+
+func (c *convOld_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*convOld)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z convOld
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *convOld_codec) decode(d *codecapi.Decoder, x *convOld) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(convOld_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.I = int8(d.DecodeByte())
+		case 1:
+			x.U = int(d.DecodeInt())
+		case 2:
+			x.F = d.DecodeFloat()
+		case 3:
+			c.slice_int_codec.decode(d, &x.S)
+		case 4:
+			c.definedSlice_codec.decode(d, &x.D)
+		case 5:
+			c.slice_int64_codec.decode(d, &x.N)
+		case 6:
+			c.slice_int32_codec.decode(d, &x.W)
+		case 7:
+			c.map_string__int64_codec.decode(d, &x.M)
+		case 8:
+			c.array_2_int64_codec.decode(d, &x.A)
+		case 9:
+			c.slice_slice_int64_codec.decode(d, &x.X)
+		case 10:
+			c.slice_int_codec.decode(d, &x.T)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("convOld")
 		default:
 			codecapi.Failf("bad struct field value: %d", n)
 		}
@@ -1116,317 +2919,5349 @@ loop:
 }
 
 func init() {
-	codecapi.Register(node_type, func() codecapi.TypeCodec { return &node_codec{} })
+	codecapi.Register(convOld_type, func() codecapi.TypeCodec { return &convOld_codec{} })
+}
+
+//// codec.definedArray
+
+var definedArray_type = reflect.TypeOf((*definedArray)(nil)).Elem()
+
+type definedArray_codec struct {
+	codecapi.NonStruct
+	slice_int_codec *slice_int_codec
+}
+
+func (c *definedArray_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_int_type}
+}
+
+func (c *definedArray_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_int_codec = tcs[0].(*slice_int_codec)
+}
+
+func (c *definedArray_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	a := x.(definedArray)
+	c.encode(e, &a)
+}
+
+func (c *definedArray_codec) encode(e *codecapi.Encoder, s *definedArray) {
+	start := e.StatsStart()
+	c.slice_int_codec.encode(e, (*s)[:])
+	if start >= 0 {
+		e.StatsEnd(definedArray_type, start)
+	}
+}
+
+func (c *definedArray_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x definedArray
+	c.decode(d, &x)
+	return x
+}
+
+func (c *definedArray_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*definedArray)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z definedArray
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *definedArray_codec) decode(d *codecapi.Decoder, p *definedArray) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	if n > 1 {
+		codecapi.Failf("array size mismatch: got %d, want at most 1", n)
+	}
+	if n < 1 && !d.Merging() {
+		*p = definedArray{}
+	}
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		(*p)[i] = int(d.DecodeInt())
+	}
+}
+
+func init() {
+	codecapi.Register(definedArray_type, func() codecapi.TypeCodec { return &definedArray_codec{} })
+}
+
+//// codec.definedMap
+
+var definedMap_type = reflect.TypeOf((*definedMap)(nil)).Elem()
+
+type definedMap_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *definedMap_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *definedMap_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *definedMap_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(definedMap)) }
+
+func (c *definedMap_codec) encode(e *codecapi.Encoder, m definedMap) {
+	start := e.StatsStart()
+	if m == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			e.EncodeBool(v)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(definedMap_type, start)
+	}
+}
+
+func (c *definedMap_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(definedMap)
+	keys := make([]string, 0, len(m))
+	vals := make([]bool, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				e.EncodeBool(v)
+			}
+		})
+	}
+	return 2 * len(m), parts
+}
+
+func (c *definedMap_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x definedMap
+	c.decode(d, &x)
+	return x
+}
+
+func (c *definedMap_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*definedMap)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z definedMap
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *definedMap_codec) decode(d *codecapi.Decoder, p *definedMap) {
+	n2 := d.StartList()
+	if n2 < 0 {
+		return
+	}
+	n := n2 / 2
+	var m definedMap
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(definedMap, n)
+	}
+	var k string
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
+		k = d.DecodeString()
+		d.SetMerging(merging)
+		inKey = false
+		var v bool
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		v = d.DecodeBool()
+		d.SetMerging(merging)
+		m[k] = v
+	}
+	*p = m
+}
+
+func init() {
+	codecapi.Register(definedMap_type, func() codecapi.TypeCodec { return &definedMap_codec{} })
+}
+
+//// codec.definedSlice
+
+var definedSlice_type = reflect.TypeOf((*definedSlice)(nil)).Elem()
+
+type definedSlice_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *definedSlice_codec) TypesUsed() []reflect.Type      { return nil }
+func (c *definedSlice_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *definedSlice_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(definedSlice))
+}
+
+func (c *definedSlice_codec) encode(e *codecapi.Encoder, s definedSlice) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(int64(x))
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(definedSlice_type, start)
+	}
+}
+
+func (c *definedSlice_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.(definedSlice)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(int64(x))
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *definedSlice_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x definedSlice
+	c.decode(d, &x)
+	return x
+}
+
+func (c *definedSlice_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*definedSlice)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z definedSlice
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *definedSlice_codec) decode(d *codecapi.Decoder, p *definedSlice) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]int, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		s[i] = int(d.DecodeInt())
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
+	*p = s
+}
+
+func init() {
+	codecapi.Register(definedSlice_type, func() codecapi.TypeCodec { return &definedSlice_codec{} })
+}
+
+//// codec.dfltNew
+
+var dfltNew_type = reflect.TypeOf((*dfltNew)(nil)).Elem()
+
+var dfltNew_fields = []string{"A", "D", "L"}
+
+var dfltNew_kinds = []reflect.Kind{reflect.Int, reflect.Int, reflect.Slice}
+
+var dfltNew_elemKinds = [][]reflect.Kind{nil, nil, {reflect.String}}
+
+var dfltNew_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*[]string)(nil)).Elem()}
+
+type dfltNew_codec struct {
+	slice_string_codec *slice_string_codec
+	fieldMap           []int
+}
+
+func (c *dfltNew_codec) Fields() []string {
+	return dfltNew_fields
+}
+
+func (c *dfltNew_codec) FieldKinds() []reflect.Kind {
+	return dfltNew_kinds
+}
+
+func (c *dfltNew_codec) FieldElemKinds() [][]reflect.Kind {
+	return dfltNew_elemKinds
+}
+
+func (c *dfltNew_codec) FieldTypes() []reflect.Type {
+	return dfltNew_fieldTypes
+}
+
+func (c *dfltNew_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *dfltNew_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_string_type}
+}
+
+func (c *dfltNew_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_string_codec = tcs[0].(*slice_string_codec)
+}
+
+func (c *dfltNew_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(dfltNew)
+	c.encode(e, &s)
+}
+
+func (c *dfltNew_codec) encode(e *codecapi.Encoder, x *dfltNew) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.A != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.A))
+	}
+
+	e.EncodeUint(1)
+	e.EncodeInt(int64(x.D))
+	if x.L != nil {
+		e.EncodeUint(2)
+		c.slice_string_codec.encode(e, x.L)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(dfltNew_type, start)
+	}
+}
+
+func (c *dfltNew_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x dfltNew
+	c.decode(d, &x)
+	return x
+}
+
+func (c *dfltNew_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*dfltNew)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z dfltNew
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *dfltNew_codec) decode(d *codecapi.Decoder, x *dfltNew) {
+	d.StartStruct()
+	if !d.Merging() {
+		x.Default()
+	}
+	var seen [3]bool
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(dfltNew_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		if n >= 0 {
+			seen[n] = true
+		}
+		field = n
+		switch n {
+		case 0:
+			x.A = int(d.DecodeInt())
+		case 1:
+			x.D = int(d.DecodeInt())
+		case 2:
+			c.slice_string_codec.decode(d, &x.L)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("dfltNew")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+	if !seen[1] && (!d.Merging()) {
+		x.D = 7
+	}
+}
+
+func init() {
+	codecapi.Register(dfltNew_type, func() codecapi.TypeCodec { return &dfltNew_codec{} })
+}
+
+//// codec.dfltOld
+
+var dfltOld_type = reflect.TypeOf((*dfltOld)(nil)).Elem()
+
+var dfltOld_fields = []string{"A"}
+
+var dfltOld_kinds = []reflect.Kind{reflect.Int}
+
+var dfltOld_elemKinds = [][]reflect.Kind{nil}
+
+var dfltOld_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem()}
+
+type dfltOld_codec struct {
+	fieldMap []int
+}
+
+func (c *dfltOld_codec) Fields() []string {
+	return dfltOld_fields
+}
+
+func (c *dfltOld_codec) FieldKinds() []reflect.Kind {
+	return dfltOld_kinds
+}
+
+func (c *dfltOld_codec) FieldElemKinds() [][]reflect.Kind {
+	return dfltOld_elemKinds
+}
+
+func (c *dfltOld_codec) FieldTypes() []reflect.Type {
+	return dfltOld_fieldTypes
+}
+
+func (c *dfltOld_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *dfltOld_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *dfltOld_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *dfltOld_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(dfltOld)
+	c.encode(e, &s)
+}
+
+func (c *dfltOld_codec) encode(e *codecapi.Encoder, x *dfltOld) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.A != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.A))
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(dfltOld_type, start)
+	}
+}
+
+func (c *dfltOld_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x dfltOld
+	c.decode(d, &x)
+	return x
+}
+
+func (c *dfltOld_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*dfltOld)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z dfltOld
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *dfltOld_codec) decode(d *codecapi.Decoder, x *dfltOld) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(dfltOld_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.A = int(d.DecodeInt())
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("dfltOld")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(dfltOld_type, func() codecapi.TypeCodec { return &dfltOld_codec{} })
+}
+
+//// codec.generatedTestTypes
+
+var generatedTestTypes_type = reflect.TypeOf((*generatedTestTypes)(nil)).Elem()
+
+var generatedTestTypes_fields = []string{"Node", "Slice", "Array", "ByteSlice", "ByteArray", "Map", "Struct", "IP", "StructSlice", "StructArray", "StructMap", "DefSlice", "DefArray", "DefMap", "Pos", "T", "PtrSlice", "PtrArray", "PtrMap", "PtrTime", "SlicePtrInt", "Promoted", "Patch", "ReqdA", "ReqdB", "ReqdC", "ReqdEmbA", "ReqdEmbB", "RenamedA", "RenamedB", "RenamedC", "Moved", "ConvOld", "ConvNew", "Merge", "MergeDflt", "MergeOld", "Parallel", "Panicky", "Reading", "Invoice", "Library"}
+
+var generatedTestTypes_kinds = []reflect.Kind{reflect.Ptr, reflect.Slice, reflect.Array, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct, reflect.String, reflect.Slice, reflect.Array, reflect.Map, reflect.Slice, reflect.Array, reflect.Map, reflect.Int, reflect.Slice, reflect.Ptr, reflect.Ptr, reflect.Ptr, reflect.Ptr, reflect.Slice, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Slice, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Struct, reflect.Slice, reflect.Interface, reflect.Struct, reflect.Struct, reflect.Struct}
+
+var generatedTestTypes_elemKinds = [][]reflect.Kind{{reflect.Struct}, {reflect.Int}, {reflect.Int}, {reflect.Uint8}, {reflect.Uint8}, {reflect.String, reflect.Bool}, nil, nil, {reflect.Struct}, {reflect.Struct}, {reflect.Array, reflect.Int, reflect.Struct}, {reflect.Int}, {reflect.Int}, {reflect.String, reflect.Bool}, nil, {reflect.Int}, {reflect.Slice, reflect.Int}, {reflect.Array, reflect.Int}, {reflect.Map, reflect.Int, reflect.Int}, {reflect.String}, {reflect.Ptr, reflect.Int}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, {reflect.Struct}, nil, nil, nil, nil, nil, {reflect.Struct}, nil, nil, nil, nil}
+
+var generatedTestTypes_fieldTypes = []reflect.Type{reflect.TypeOf((**node)(nil)).Elem(), reflect.TypeOf((*[]int)(nil)).Elem(), reflect.TypeOf((*[1]int)(nil)).Elem(), reflect.TypeOf((*[]uint8)(nil)).Elem(), reflect.TypeOf((*[2]uint8)(nil)).Elem(), reflect.TypeOf((*map[string]bool)(nil)).Elem(), reflect.TypeOf((*structType)(nil)).Elem(), reflect.TypeOf((*net.IP)(nil)).Elem(), reflect.TypeOf((*[]structType)(nil)).Elem(), reflect.TypeOf((*[1]structType)(nil)).Elem(), reflect.TypeOf((*map[[1]int]structType)(nil)).Elem(), reflect.TypeOf((*definedSlice)(nil)).Elem(), reflect.TypeOf((*definedArray)(nil)).Elem(), reflect.TypeOf((*definedMap)(nil)).Elem(), reflect.TypeOf((*token.Pos)(nil)).Elem(), reflect.TypeOf((*foo.T)(nil)).Elem(), reflect.TypeOf((**[]int)(nil)).Elem(), reflect.TypeOf((**[1]int)(nil)).Elem(), reflect.TypeOf((**map[int]int)(nil)).Elem(), reflect.TypeOf((**time.Time)(nil)).Elem(), reflect.TypeOf((*[]*int)(nil)).Elem(), reflect.TypeOf((*promoted)(nil)).Elem(), reflect.TypeOf((*patch)(nil)).Elem(), reflect.TypeOf((*reqdA)(nil)).Elem(), reflect.TypeOf((*reqdB)(nil)).Elem(), reflect.TypeOf((*reqdC)(nil)).Elem(), reflect.TypeOf((*reqdEmbA)(nil)).Elem(), reflect.TypeOf((*reqdEmbB)(nil)).Elem(), reflect.TypeOf((*renamedA)(nil)).Elem(), reflect.TypeOf((*renamedB)(nil)).Elem(), reflect.TypeOf((*renamedC)(nil)).Elem(), reflect.TypeOf((*[]moved)(nil)).Elem(), reflect.TypeOf((*convOld)(nil)).Elem(), reflect.TypeOf((*convNew)(nil)).Elem(), reflect.TypeOf((*mergeConfig)(nil)).Elem(), reflect.TypeOf((*mergeDfltNew)(nil)).Elem(), reflect.TypeOf((*mergeDfltOld)(nil)).Elem(), reflect.TypeOf((*[]parallelItem)(nil)).Elem(), reflect.TypeOf((*panicky)(nil)).Elem(), reflect.TypeOf((*reading)(nil)).Elem(), reflect.TypeOf((*invoice)(nil)).Elem(), reflect.TypeOf((*library)(nil)).Elem()}
+
+type generatedTestTypes_codec struct {
+	ptr_array_1_int_codec             *ptr_array_1_int_codec
+	ptr_slice_int_codec               *ptr_slice_int_codec
+	ptr_node_codec                    *ptr_node_codec
+	ptr_map_int__int_codec            *ptr_map_int__int_codec
+	ptr_time_Time_codec               *ptr_time_Time_codec
+	array_1_structType_codec          *array_1_structType_codec
+	array_1_int_codec                 *array_1_int_codec
+	array_2_uint8_codec               *array_2_uint8_codec
+	slice_ptr_int_codec               *slice_ptr_int_codec
+	slice_moved_codec                 *slice_moved_codec
+	slice_parallelItem_codec          *slice_parallelItem_codec
+	slice_structType_codec            *slice_structType_codec
+	slice_int_codec                   *slice_int_codec
+	convNew_codec                     *convNew_codec
+	convOld_codec                     *convOld_codec
+	definedArray_codec                *definedArray_codec
+	definedMap_codec                  *definedMap_codec
+	definedSlice_codec                *definedSlice_codec
+	invoice_codec                     *invoice_codec
+	library_codec                     *library_codec
+	mergeConfig_codec                 *mergeConfig_codec
+	mergeDfltNew_codec                *mergeDfltNew_codec
+	mergeDfltOld_codec                *mergeDfltOld_codec
+	panicky_codec                     *panicky_codec
+	patch_codec                       *patch_codec
+	promoted_codec                    *promoted_codec
+	reading_codec                     *reading_codec
+	renamedA_codec                    *renamedA_codec
+	renamedB_codec                    *renamedB_codec
+	renamedC_codec                    *renamedC_codec
+	reqdA_codec                       *reqdA_codec
+	reqdB_codec                       *reqdB_codec
+	reqdC_codec                       *reqdC_codec
+	reqdEmbA_codec                    *reqdEmbA_codec
+	reqdEmbB_codec                    *reqdEmbB_codec
+	structType_codec                  *structType_codec
+	foo_T_codec                       *foo_T_codec
+	map_array_1_int__structType_codec *map_array_1_int__structType_codec
+	map_string__bool_codec            *map_string__bool_codec
+	net_IP_codec                      *net_IP_codec
+	fieldMap                          []int
+}
+
+func (c *generatedTestTypes_codec) Fields() []string {
+	return generatedTestTypes_fields
+}
+
+func (c *generatedTestTypes_codec) FieldKinds() []reflect.Kind {
+	return generatedTestTypes_kinds
+}
+
+func (c *generatedTestTypes_codec) FieldElemKinds() [][]reflect.Kind {
+	return generatedTestTypes_elemKinds
+}
+
+func (c *generatedTestTypes_codec) FieldTypes() []reflect.Type {
+	return generatedTestTypes_fieldTypes
+}
+
+func (c *generatedTestTypes_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *generatedTestTypes_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{ptr_array_1_int_type, ptr_slice_int_type, ptr_node_type, ptr_map_int__int_type, ptr_time_Time_type, array_1_structType_type, array_1_int_type, array_2_uint8_type, slice_ptr_int_type, slice_moved_type, slice_parallelItem_type, slice_structType_type, slice_int_type, convNew_type, convOld_type, definedArray_type, definedMap_type, definedSlice_type, invoice_type, library_type, mergeConfig_type, mergeDfltNew_type, mergeDfltOld_type, panicky_type, patch_type, promoted_type, reading_type, renamedA_type, renamedB_type, renamedC_type, reqdA_type, reqdB_type, reqdC_type, reqdEmbA_type, reqdEmbB_type, structType_type, foo_T_type, map_array_1_int__structType_type, map_string__bool_type, net_IP_type}
+}
+
+func (c *generatedTestTypes_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.ptr_array_1_int_codec = tcs[0].(*ptr_array_1_int_codec)
+	c.ptr_slice_int_codec = tcs[1].(*ptr_slice_int_codec)
+	c.ptr_node_codec = tcs[2].(*ptr_node_codec)
+	c.ptr_map_int__int_codec = tcs[3].(*ptr_map_int__int_codec)
+	c.ptr_time_Time_codec = tcs[4].(*ptr_time_Time_codec)
+	c.array_1_structType_codec = tcs[5].(*array_1_structType_codec)
+	c.array_1_int_codec = tcs[6].(*array_1_int_codec)
+	c.array_2_uint8_codec = tcs[7].(*array_2_uint8_codec)
+	c.slice_ptr_int_codec = tcs[8].(*slice_ptr_int_codec)
+	c.slice_moved_codec = tcs[9].(*slice_moved_codec)
+	c.slice_parallelItem_codec = tcs[10].(*slice_parallelItem_codec)
+	c.slice_structType_codec = tcs[11].(*slice_structType_codec)
+	c.slice_int_codec = tcs[12].(*slice_int_codec)
+	c.convNew_codec = tcs[13].(*convNew_codec)
+	c.convOld_codec = tcs[14].(*convOld_codec)
+	c.definedArray_codec = tcs[15].(*definedArray_codec)
+	c.definedMap_codec = tcs[16].(*definedMap_codec)
+	c.definedSlice_codec = tcs[17].(*definedSlice_codec)
+	c.invoice_codec = tcs[18].(*invoice_codec)
+	c.library_codec = tcs[19].(*library_codec)
+	c.mergeConfig_codec = tcs[20].(*mergeConfig_codec)
+	c.mergeDfltNew_codec = tcs[21].(*mergeDfltNew_codec)
+	c.mergeDfltOld_codec = tcs[22].(*mergeDfltOld_codec)
+	c.panicky_codec = tcs[23].(*panicky_codec)
+	c.patch_codec = tcs[24].(*patch_codec)
+	c.promoted_codec = tcs[25].(*promoted_codec)
+	c.reading_codec = tcs[26].(*reading_codec)
+	c.renamedA_codec = tcs[27].(*renamedA_codec)
+	c.renamedB_codec = tcs[28].(*renamedB_codec)
+	c.renamedC_codec = tcs[29].(*renamedC_codec)
+	c.reqdA_codec = tcs[30].(*reqdA_codec)
+	c.reqdB_codec = tcs[31].(*reqdB_codec)
+	c.reqdC_codec = tcs[32].(*reqdC_codec)
+	c.reqdEmbA_codec = tcs[33].(*reqdEmbA_codec)
+	c.reqdEmbB_codec = tcs[34].(*reqdEmbB_codec)
+	c.structType_codec = tcs[35].(*structType_codec)
+	c.foo_T_codec = tcs[36].(*foo_T_codec)
+	c.map_array_1_int__structType_codec = tcs[37].(*map_array_1_int__structType_codec)
+	c.map_string__bool_codec = tcs[38].(*map_string__bool_codec)
+	c.net_IP_codec = tcs[39].(*net_IP_codec)
+}
+
+func (c *generatedTestTypes_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(generatedTestTypes)
+	c.encode(e, &s)
+}
+
+func (c *generatedTestTypes_codec) encode(e *codecapi.Encoder, x *generatedTestTypes) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Node != nil {
+		e.EncodeUint(0)
+		c.ptr_node_codec.encode(e, x.Node)
+	}
+	if x.Slice != nil {
+		e.EncodeUint(1)
+		c.slice_int_codec.encode(e, x.Slice)
+	}
+
+	e.EncodeUint(2)
+	c.array_1_int_codec.encode(e, &x.Array)
+	if x.ByteSlice != nil {
+		e.EncodeUint(3)
+		e.EncodeBytes(x.ByteSlice)
+	}
+
+	e.EncodeUint(4)
+	c.array_2_uint8_codec.encode(e, &x.ByteArray)
+	if x.Map != nil {
+		e.EncodeUint(5)
+		c.map_string__bool_codec.encode(e, x.Map)
+	}
+
+	e.EncodeUint(6)
+	c.structType_codec.encode(e, &x.Struct)
+	if x.IP != nil {
+		e.EncodeUint(7)
+		c.net_IP_codec.encode(e, x.IP)
+	}
+	if x.StructSlice != nil {
+		e.EncodeUint(8)
+		c.slice_structType_codec.encode(e, x.StructSlice)
+	}
+
+	e.EncodeUint(9)
+	c.array_1_structType_codec.encode(e, &x.StructArray)
+	if x.StructMap != nil {
+		e.EncodeUint(10)
+		c.map_array_1_int__structType_codec.encode(e, x.StructMap)
+	}
+	if x.DefSlice != nil {
+		e.EncodeUint(11)
+		c.definedSlice_codec.encode(e, x.DefSlice)
+	}
+
+	e.EncodeUint(12)
+	c.definedArray_codec.encode(e, &x.DefArray)
+	if x.DefMap != nil {
+		e.EncodeUint(13)
+		c.definedMap_codec.encode(e, x.DefMap)
+	}
+	if x.Pos != 0 {
+		e.EncodeUint(14)
+		e.EncodeInt(int64(x.Pos))
+	}
+	if x.T != nil {
+		e.EncodeUint(15)
+		c.foo_T_codec.encode(e, x.T)
+	}
+	if x.PtrSlice != nil {
+		e.EncodeUint(16)
+		c.ptr_slice_int_codec.encode(e, x.PtrSlice)
+	}
+	if x.PtrArray != nil {
+		e.EncodeUint(17)
+		c.ptr_array_1_int_codec.encode(e, x.PtrArray)
+	}
+	if x.PtrMap != nil {
+		e.EncodeUint(18)
+		c.ptr_map_int__int_codec.encode(e, x.PtrMap)
+	}
+	if x.PtrTime != nil {
+		e.EncodeUint(19)
+		c.ptr_time_Time_codec.encode(e, x.PtrTime)
+	}
+	if x.SlicePtrInt != nil {
+		e.EncodeUint(20)
+		c.slice_ptr_int_codec.encode(e, x.SlicePtrInt)
+	}
+
+	e.EncodeUint(21)
+	c.promoted_codec.encode(e, &x.Promoted)
+
+	e.EncodeUint(22)
+	c.patch_codec.encode(e, &x.Patch)
+
+	e.EncodeUint(23)
+	c.reqdA_codec.encode(e, &x.ReqdA)
+
+	e.EncodeUint(24)
+	c.reqdB_codec.encode(e, &x.ReqdB)
+
+	e.EncodeUint(25)
+	c.reqdC_codec.encode(e, &x.ReqdC)
+
+	e.EncodeUint(26)
+	c.reqdEmbA_codec.encode(e, &x.ReqdEmbA)
+
+	e.EncodeUint(27)
+	c.reqdEmbB_codec.encode(e, &x.ReqdEmbB)
+
+	e.EncodeUint(28)
+	c.renamedA_codec.encode(e, &x.RenamedA)
+
+	e.EncodeUint(29)
+	c.renamedB_codec.encode(e, &x.RenamedB)
+
+	e.EncodeUint(30)
+	c.renamedC_codec.encode(e, &x.RenamedC)
+	if x.Moved != nil {
+		e.EncodeUint(31)
+		c.slice_moved_codec.encode(e, x.Moved)
+	}
+
+	e.EncodeUint(32)
+	c.convOld_codec.encode(e, &x.ConvOld)
+
+	e.EncodeUint(33)
+	c.convNew_codec.encode(e, &x.ConvNew)
+
+	e.EncodeUint(34)
+	c.mergeConfig_codec.encode(e, &x.Merge)
+
+	e.EncodeUint(35)
+	c.mergeDfltNew_codec.encode(e, &x.MergeDflt)
+
+	e.EncodeUint(36)
+	c.mergeDfltOld_codec.encode(e, &x.MergeOld)
+	if x.Parallel != nil {
+		e.EncodeUint(37)
+		c.slice_parallelItem_codec.encode(e, x.Parallel)
+	}
+	if x.Panicky != 0 {
+		e.EncodeUint(38)
+		c.panicky_codec.encode(e, x.Panicky)
+	}
+
+	e.EncodeUint(39)
+	c.reading_codec.encode(e, &x.Reading)
+
+	e.EncodeUint(40)
+	c.invoice_codec.encode(e, &x.Invoice)
+
+	e.EncodeUint(41)
+	c.library_codec.encode(e, &x.Library)
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(generatedTestTypes_type, start)
+	}
+}
+
+func (c *generatedTestTypes_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x generatedTestTypes
+	c.decode(d, &x)
+	return x
+}
+
+func (c *generatedTestTypes_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*generatedTestTypes)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z generatedTestTypes
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *generatedTestTypes_codec) decode(d *codecapi.Decoder, x *generatedTestTypes) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(generatedTestTypes_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			c.ptr_node_codec.decode(d, &x.Node)
+		case 1:
+			c.slice_int_codec.decode(d, &x.Slice)
+		case 2:
+			c.array_1_int_codec.decode(d, &x.Array)
+		case 3:
+			x.ByteSlice = d.DecodeBytes()
+		case 4:
+			c.array_2_uint8_codec.decode(d, &x.ByteArray)
+		case 5:
+			c.map_string__bool_codec.decode(d, &x.Map)
+		case 6:
+			c.structType_codec.decode(d, &x.Struct)
+		case 7:
+			c.net_IP_codec.decode(d, &x.IP)
+		case 8:
+			c.slice_structType_codec.decode(d, &x.StructSlice)
+		case 9:
+			c.array_1_structType_codec.decode(d, &x.StructArray)
+		case 10:
+			c.map_array_1_int__structType_codec.decode(d, &x.StructMap)
+		case 11:
+			c.definedSlice_codec.decode(d, &x.DefSlice)
+		case 12:
+			c.definedArray_codec.decode(d, &x.DefArray)
+		case 13:
+			c.definedMap_codec.decode(d, &x.DefMap)
+		case 14:
+			x.Pos = token.Pos(d.DecodeInt())
+		case 15:
+			c.foo_T_codec.decode(d, &x.T)
+		case 16:
+			c.ptr_slice_int_codec.decode(d, &x.PtrSlice)
+		case 17:
+			c.ptr_array_1_int_codec.decode(d, &x.PtrArray)
+		case 18:
+			c.ptr_map_int__int_codec.decode(d, &x.PtrMap)
+		case 19:
+			c.ptr_time_Time_codec.decode(d, &x.PtrTime)
+		case 20:
+			c.slice_ptr_int_codec.decode(d, &x.SlicePtrInt)
+		case 21:
+			c.promoted_codec.decode(d, &x.Promoted)
+		case 22:
+			c.patch_codec.decode(d, &x.Patch)
+		case 23:
+			c.reqdA_codec.decode(d, &x.ReqdA)
+		case 24:
+			c.reqdB_codec.decode(d, &x.ReqdB)
+		case 25:
+			c.reqdC_codec.decode(d, &x.ReqdC)
+		case 26:
+			c.reqdEmbA_codec.decode(d, &x.ReqdEmbA)
+		case 27:
+			c.reqdEmbB_codec.decode(d, &x.ReqdEmbB)
+		case 28:
+			c.renamedA_codec.decode(d, &x.RenamedA)
+		case 29:
+			c.renamedB_codec.decode(d, &x.RenamedB)
+		case 30:
+			c.renamedC_codec.decode(d, &x.RenamedC)
+		case 31:
+			c.slice_moved_codec.decode(d, &x.Moved)
+		case 32:
+			c.convOld_codec.decode(d, &x.ConvOld)
+		case 33:
+			c.convNew_codec.decode(d, &x.ConvNew)
+		case 34:
+			c.mergeConfig_codec.decode(d, &x.Merge)
+		case 35:
+			c.mergeDfltNew_codec.decode(d, &x.MergeDflt)
+		case 36:
+			c.mergeDfltOld_codec.decode(d, &x.MergeOld)
+		case 37:
+			c.slice_parallelItem_codec.decode(d, &x.Parallel)
+		case 38:
+			c.panicky_codec.decode(d, &x.Panicky)
+		case 39:
+			c.reading_codec.decode(d, &x.Reading)
+		case 40:
+			c.invoice_codec.decode(d, &x.Invoice)
+		case 41:
+			c.library_codec.decode(d, &x.Library)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("generatedTestTypes")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(generatedTestTypes_type, func() codecapi.TypeCodec { return &generatedTestTypes_codec{} })
+}
+
+//// codec.index
+
+var index_type = reflect.TypeOf((*index)(nil)).Elem()
+
+type index_codec struct {
+	codecapi.NonStruct
+
+	indexProxy_codec *indexProxy_codec
+}
+
+func (c *index_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{indexProxy_type}
+}
+
+func (c *index_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.indexProxy_codec = tcs[0].(*indexProxy_codec)
+}
+
+func (c *index_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	p := reflect.New(index_type)
+	p.Elem().Set(reflect.ValueOf(x))
+	c.encode(e, p.Interface().(*index))
+}
+
+func (c *index_codec) encode(e *codecapi.Encoder, x *index) {
+	start := e.StatsStart()
+	px := x.ToCodec()
+	c.indexProxy_codec.encode(e, &px)
+	if start >= 0 {
+		e.StatsEnd(index_type, start)
+	}
+}
+
+func (c *index_codec) Decode(d *codecapi.Decoder) interface{} {
+	p := reflect.New(index_type)
+	c.decode(d, p.Interface().(*index))
+	return p.Elem().Interface()
+}
+
+func (c *index_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	c.decode(d, p.(*index))
+}
+
+func (c *index_codec) decode(d *codecapi.Decoder, p *index) {
+	var px indexProxy
+	if d.Merging() {
+		x := p
+		px = x.ToCodec()
+	}
+	c.indexProxy_codec.decode(d, &px)
+	if !d.Merging() {
+		// Clear *p only now, so that it is unchanged if decoding px fails.
+		*p = index{}
+	}
+	if err := p.FromCodec(px); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
+func init() {
+	codecapi.Register(index_type, func() codecapi.TypeCodec { return &index_codec{} })
+}
+
+//// codec.indexProxy
+
+var indexProxy_type = reflect.TypeOf((*indexProxy)(nil)).Elem()
+
+var indexProxy_fields = []string{"Words"}
+
+var indexProxy_kinds = []reflect.Kind{reflect.Slice}
+
+var indexProxy_elemKinds = [][]reflect.Kind{{reflect.String}}
+
+var indexProxy_fieldTypes = []reflect.Type{reflect.TypeOf((*[]string)(nil)).Elem()}
+
+type indexProxy_codec struct {
+	slice_string_codec *slice_string_codec
+	fieldMap           []int
+}
+
+func (c *indexProxy_codec) Fields() []string {
+	return indexProxy_fields
+}
+
+func (c *indexProxy_codec) FieldKinds() []reflect.Kind {
+	return indexProxy_kinds
+}
+
+func (c *indexProxy_codec) FieldElemKinds() [][]reflect.Kind {
+	return indexProxy_elemKinds
+}
+
+func (c *indexProxy_codec) FieldTypes() []reflect.Type {
+	return indexProxy_fieldTypes
+}
+
+func (c *indexProxy_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *indexProxy_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_string_type}
+}
+
+func (c *indexProxy_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_string_codec = tcs[0].(*slice_string_codec)
+}
+
+func (c *indexProxy_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(indexProxy)
+	c.encode(e, &s)
+}
+
+func (c *indexProxy_codec) encode(e *codecapi.Encoder, x *indexProxy) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Words != nil {
+		e.EncodeUint(0)
+		c.slice_string_codec.encode(e, x.Words)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(indexProxy_type, start)
+	}
+}
+
+func (c *indexProxy_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x indexProxy
+	c.decode(d, &x)
+	return x
+}
+
+func (c *indexProxy_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*indexProxy)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z indexProxy
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *indexProxy_codec) decode(d *codecapi.Decoder, x *indexProxy) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(indexProxy_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			c.slice_string_codec.decode(d, &x.Words)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("indexProxy")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(indexProxy_type, func() codecapi.TypeCodec { return &indexProxy_codec{} })
+}
+
+//// codec.invoice
+
+var invoice_type = reflect.TypeOf((*invoice)(nil)).Elem()
+
+var invoice_fields = []string{"Total", "Items"}
+
+var invoice_kinds = []reflect.Kind{reflect.Interface, reflect.Slice}
+
+var invoice_elemKinds = [][]reflect.Kind{nil, {reflect.Interface}}
+
+var invoice_fieldTypes = []reflect.Type{reflect.TypeOf((*money)(nil)).Elem(), reflect.TypeOf((*[]money)(nil)).Elem()}
+
+type invoice_codec struct {
+	slice_money_codec *slice_money_codec
+	money_codec       *money_codec
+	fieldMap          []int
+}
+
+func (c *invoice_codec) Fields() []string {
+	return invoice_fields
+}
+
+func (c *invoice_codec) FieldKinds() []reflect.Kind {
+	return invoice_kinds
+}
+
+func (c *invoice_codec) FieldElemKinds() [][]reflect.Kind {
+	return invoice_elemKinds
+}
+
+func (c *invoice_codec) FieldTypes() []reflect.Type {
+	return invoice_fieldTypes
+}
+
+func (c *invoice_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *invoice_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{slice_money_type, money_type}
+}
+
+func (c *invoice_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.slice_money_codec = tcs[0].(*slice_money_codec)
+	c.money_codec = tcs[1].(*money_codec)
+}
+
+func (c *invoice_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(invoice)
+	c.encode(e, &s)
+}
+
+func (c *invoice_codec) encode(e *codecapi.Encoder, x *invoice) {
+	start := e.StatsStart()
+	e.StartStruct()
+
+	e.EncodeUint(0)
+	c.money_codec.encode(e, x.Total)
+	if x.Items != nil {
+		e.EncodeUint(1)
+		c.slice_money_codec.encode(e, x.Items)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(invoice_type, start)
+	}
+}
+
+func (c *invoice_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x invoice
+	c.decode(d, &x)
+	return x
+}
+
+func (c *invoice_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*invoice)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z invoice
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *invoice_codec) decode(d *codecapi.Decoder, x *invoice) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(invoice_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			c.money_codec.decode(d, &x.Total)
+		case 1:
+			c.slice_money_codec.decode(d, &x.Items)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("invoice")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(invoice_type, func() codecapi.TypeCodec { return &invoice_codec{} })
+}
+
+//// codec.library
+
+var library_type = reflect.TypeOf((*library)(nil)).Elem()
+
+var library_fields = []string{"Name", "Index", "Color"}
+
+var library_kinds = []reflect.Kind{reflect.String, reflect.Ptr, reflect.String}
+
+var library_elemKinds = [][]reflect.Kind{nil, {reflect.Struct}, nil}
+
+var library_fieldTypes = []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((**index)(nil)).Elem(), reflect.TypeOf((*rgb)(nil)).Elem()}
+
+type library_codec struct {
+	ptr_index_codec *ptr_index_codec
+	rgb_codec       *rgb_codec
+	fieldMap        []int
+}
+
+func (c *library_codec) Fields() []string {
+	return library_fields
+}
+
+func (c *library_codec) FieldKinds() []reflect.Kind {
+	return library_kinds
+}
+
+func (c *library_codec) FieldElemKinds() [][]reflect.Kind {
+	return library_elemKinds
+}
+
+func (c *library_codec) FieldTypes() []reflect.Type {
+	return library_fieldTypes
+}
+
+func (c *library_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *library_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{ptr_index_type, rgb_type}
+}
+
+func (c *library_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.ptr_index_codec = tcs[0].(*ptr_index_codec)
+	c.rgb_codec = tcs[1].(*rgb_codec)
+}
+
+func (c *library_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(library)
+	c.encode(e, &s)
+}
+
+func (c *library_codec) encode(e *codecapi.Encoder, x *library) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Name != "" {
+		e.EncodeUint(0)
+		e.EncodeString(x.Name)
+	}
+	if x.Index != nil {
+		e.EncodeUint(1)
+		c.ptr_index_codec.encode(e, x.Index)
+	}
+
+	e.EncodeUint(2)
+	c.rgb_codec.encode(e, &x.Color)
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(library_type, start)
+	}
+}
+
+func (c *library_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x library
+	c.decode(d, &x)
+	return x
+}
+
+func (c *library_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*library)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z library
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *library_codec) decode(d *codecapi.Decoder, x *library) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(library_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.Name = d.DecodeString()
+		case 1:
+			c.ptr_index_codec.decode(d, &x.Index)
+		case 2:
+			c.rgb_codec.decode(d, &x.Color)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("library")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(library_type, func() codecapi.TypeCodec { return &library_codec{} })
+}
+
+//// codec.mergeConfig
+
+var mergeConfig_type = reflect.TypeOf((*mergeConfig)(nil)).Elem()
+
+var mergeConfig_fields = []string{"Name", "Port", "Tags", "Limits", "Sub", "Subs", "Array"}
+
+var mergeConfig_kinds = []reflect.Kind{reflect.String, reflect.Int, reflect.Slice, reflect.Map, reflect.Ptr, reflect.Map, reflect.Array}
+
+var mergeConfig_elemKinds = [][]reflect.Kind{nil, nil, {reflect.String}, {reflect.String, reflect.Int}, {reflect.Struct}, {reflect.String, reflect.Struct}, {reflect.Int}}
+
+var mergeConfig_fieldTypes = []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*[]string)(nil)).Elem(), reflect.TypeOf((*map[string]int)(nil)).Elem(), reflect.TypeOf((**mergeSub)(nil)).Elem(), reflect.TypeOf((*map[string]mergeSub)(nil)).Elem(), reflect.TypeOf((*[3]int)(nil)).Elem()}
+
+type mergeConfig_codec struct {
+	ptr_mergeSub_codec         *ptr_mergeSub_codec
+	array_3_int_codec          *array_3_int_codec
+	slice_string_codec         *slice_string_codec
+	map_string__mergeSub_codec *map_string__mergeSub_codec
+	map_string__int_codec      *map_string__int_codec
+	fieldMap                   []int
+}
+
+func (c *mergeConfig_codec) Fields() []string {
+	return mergeConfig_fields
+}
+
+func (c *mergeConfig_codec) FieldKinds() []reflect.Kind {
+	return mergeConfig_kinds
+}
+
+func (c *mergeConfig_codec) FieldElemKinds() [][]reflect.Kind {
+	return mergeConfig_elemKinds
+}
+
+func (c *mergeConfig_codec) FieldTypes() []reflect.Type {
+	return mergeConfig_fieldTypes
+}
+
+func (c *mergeConfig_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *mergeConfig_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{ptr_mergeSub_type, array_3_int_type, slice_string_type, map_string__mergeSub_type, map_string__int_type}
+}
+
+func (c *mergeConfig_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.ptr_mergeSub_codec = tcs[0].(*ptr_mergeSub_codec)
+	c.array_3_int_codec = tcs[1].(*array_3_int_codec)
+	c.slice_string_codec = tcs[2].(*slice_string_codec)
+	c.map_string__mergeSub_codec = tcs[3].(*map_string__mergeSub_codec)
+	c.map_string__int_codec = tcs[4].(*map_string__int_codec)
+}
+
+func (c *mergeConfig_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(mergeConfig)
+	c.encode(e, &s)
+}
+
+func (c *mergeConfig_codec) encode(e *codecapi.Encoder, x *mergeConfig) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Name != "" {
+		e.EncodeUint(0)
+		e.EncodeString(x.Name)
+	}
+	if x.Port != 0 {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.Port))
+	}
+	if x.Tags != nil {
+		e.EncodeUint(2)
+		c.slice_string_codec.encode(e, x.Tags)
+	}
+	if x.Limits != nil {
+		e.EncodeUint(3)
+		c.map_string__int_codec.encode(e, x.Limits)
+	}
+	if x.Sub != nil {
+		e.EncodeUint(4)
+		c.ptr_mergeSub_codec.encode(e, x.Sub)
+	}
+	if x.Subs != nil {
+		e.EncodeUint(5)
+		c.map_string__mergeSub_codec.encode(e, x.Subs)
+	}
+
+	e.EncodeUint(6)
+	c.array_3_int_codec.encode(e, &x.Array)
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(mergeConfig_type, start)
+	}
+}
+
+func (c *mergeConfig_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x mergeConfig
+	c.decode(d, &x)
+	return x
+}
+
+func (c *mergeConfig_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*mergeConfig)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z mergeConfig
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *mergeConfig_codec) decode(d *codecapi.Decoder, x *mergeConfig) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(mergeConfig_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.Name = d.DecodeString()
+		case 1:
+			x.Port = int(d.DecodeInt())
+		case 2:
+			c.slice_string_codec.decode(d, &x.Tags)
+		case 3:
+			c.map_string__int_codec.decode(d, &x.Limits)
+		case 4:
+			c.ptr_mergeSub_codec.decode(d, &x.Sub)
+		case 5:
+			c.map_string__mergeSub_codec.decode(d, &x.Subs)
+		case 6:
+			c.array_3_int_codec.decode(d, &x.Array)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("mergeConfig")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(mergeConfig_type, func() codecapi.TypeCodec { return &mergeConfig_codec{} })
+}
+
+//// codec.mergeDfltNew
+
+var mergeDfltNew_type = reflect.TypeOf((*mergeDfltNew)(nil)).Elem()
+
+var mergeDfltNew_fields = []string{"Ptr", "Map"}
+
+var mergeDfltNew_kinds = []reflect.Kind{reflect.Ptr, reflect.Map}
+
+var mergeDfltNew_elemKinds = [][]reflect.Kind{{reflect.Struct}, {reflect.String, reflect.Struct}}
+
+var mergeDfltNew_fieldTypes = []reflect.Type{reflect.TypeOf((**dfltNew)(nil)).Elem(), reflect.TypeOf((*map[string]dfltNew)(nil)).Elem()}
+
+type mergeDfltNew_codec struct {
+	ptr_dfltNew_codec         *ptr_dfltNew_codec
+	map_string__dfltNew_codec *map_string__dfltNew_codec
+	fieldMap                  []int
+}
+
+func (c *mergeDfltNew_codec) Fields() []string {
+	return mergeDfltNew_fields
+}
+
+func (c *mergeDfltNew_codec) FieldKinds() []reflect.Kind {
+	return mergeDfltNew_kinds
+}
+
+func (c *mergeDfltNew_codec) FieldElemKinds() [][]reflect.Kind {
+	return mergeDfltNew_elemKinds
+}
+
+func (c *mergeDfltNew_codec) FieldTypes() []reflect.Type {
+	return mergeDfltNew_fieldTypes
+}
+
+func (c *mergeDfltNew_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *mergeDfltNew_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{ptr_dfltNew_type, map_string__dfltNew_type}
+}
+
+func (c *mergeDfltNew_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.ptr_dfltNew_codec = tcs[0].(*ptr_dfltNew_codec)
+	c.map_string__dfltNew_codec = tcs[1].(*map_string__dfltNew_codec)
+}
+
+func (c *mergeDfltNew_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(mergeDfltNew)
+	c.encode(e, &s)
+}
+
+func (c *mergeDfltNew_codec) encode(e *codecapi.Encoder, x *mergeDfltNew) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Ptr != nil {
+		e.EncodeUint(0)
+		c.ptr_dfltNew_codec.encode(e, x.Ptr)
+	}
+	if x.Map != nil {
+		e.EncodeUint(1)
+		c.map_string__dfltNew_codec.encode(e, x.Map)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(mergeDfltNew_type, start)
+	}
+}
+
+func (c *mergeDfltNew_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x mergeDfltNew
+	c.decode(d, &x)
+	return x
+}
+
+func (c *mergeDfltNew_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*mergeDfltNew)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z mergeDfltNew
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *mergeDfltNew_codec) decode(d *codecapi.Decoder, x *mergeDfltNew) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(mergeDfltNew_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			c.ptr_dfltNew_codec.decode(d, &x.Ptr)
+		case 1:
+			c.map_string__dfltNew_codec.decode(d, &x.Map)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("mergeDfltNew")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(mergeDfltNew_type, func() codecapi.TypeCodec { return &mergeDfltNew_codec{} })
+}
+
+//// codec.mergeDfltOld
+
+var mergeDfltOld_type = reflect.TypeOf((*mergeDfltOld)(nil)).Elem()
+
+var mergeDfltOld_fields = []string{"Ptr", "Map"}
+
+var mergeDfltOld_kinds = []reflect.Kind{reflect.Ptr, reflect.Map}
+
+var mergeDfltOld_elemKinds = [][]reflect.Kind{{reflect.Struct}, {reflect.String, reflect.Struct}}
+
+var mergeDfltOld_fieldTypes = []reflect.Type{reflect.TypeOf((**dfltOld)(nil)).Elem(), reflect.TypeOf((*map[string]dfltOld)(nil)).Elem()}
+
+type mergeDfltOld_codec struct {
+	ptr_dfltOld_codec         *ptr_dfltOld_codec
+	map_string__dfltOld_codec *map_string__dfltOld_codec
+	fieldMap                  []int
+}
+
+func (c *mergeDfltOld_codec) Fields() []string {
+	return mergeDfltOld_fields
+}
+
+func (c *mergeDfltOld_codec) FieldKinds() []reflect.Kind {
+	return mergeDfltOld_kinds
+}
+
+func (c *mergeDfltOld_codec) FieldElemKinds() [][]reflect.Kind {
+	return mergeDfltOld_elemKinds
+}
+
+func (c *mergeDfltOld_codec) FieldTypes() []reflect.Type {
+	return mergeDfltOld_fieldTypes
+}
+
+func (c *mergeDfltOld_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *mergeDfltOld_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{ptr_dfltOld_type, map_string__dfltOld_type}
+}
+
+func (c *mergeDfltOld_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.ptr_dfltOld_codec = tcs[0].(*ptr_dfltOld_codec)
+	c.map_string__dfltOld_codec = tcs[1].(*map_string__dfltOld_codec)
+}
+
+func (c *mergeDfltOld_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(mergeDfltOld)
+	c.encode(e, &s)
+}
+
+func (c *mergeDfltOld_codec) encode(e *codecapi.Encoder, x *mergeDfltOld) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Ptr != nil {
+		e.EncodeUint(0)
+		c.ptr_dfltOld_codec.encode(e, x.Ptr)
+	}
+	if x.Map != nil {
+		e.EncodeUint(1)
+		c.map_string__dfltOld_codec.encode(e, x.Map)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(mergeDfltOld_type, start)
+	}
+}
+
+func (c *mergeDfltOld_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x mergeDfltOld
+	c.decode(d, &x)
+	return x
+}
+
+func (c *mergeDfltOld_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*mergeDfltOld)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z mergeDfltOld
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *mergeDfltOld_codec) decode(d *codecapi.Decoder, x *mergeDfltOld) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(mergeDfltOld_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			c.ptr_dfltOld_codec.decode(d, &x.Ptr)
+		case 1:
+			c.map_string__dfltOld_codec.decode(d, &x.Map)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("mergeDfltOld")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(mergeDfltOld_type, func() codecapi.TypeCodec { return &mergeDfltOld_codec{} })
+}
+
+//// codec.mergeSub
+
+var mergeSub_type = reflect.TypeOf((*mergeSub)(nil)).Elem()
+
+var mergeSub_fields = []string{"A", "B"}
+
+var mergeSub_kinds = []reflect.Kind{reflect.Int, reflect.Int}
+
+var mergeSub_elemKinds = [][]reflect.Kind{nil, nil}
+
+var mergeSub_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem()}
+
+type mergeSub_codec struct {
+	fieldMap []int
+}
+
+func (c *mergeSub_codec) Fields() []string {
+	return mergeSub_fields
+}
+
+func (c *mergeSub_codec) FieldKinds() []reflect.Kind {
+	return mergeSub_kinds
+}
+
+func (c *mergeSub_codec) FieldElemKinds() [][]reflect.Kind {
+	return mergeSub_elemKinds
+}
+
+func (c *mergeSub_codec) FieldTypes() []reflect.Type {
+	return mergeSub_fieldTypes
+}
+
+func (c *mergeSub_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *mergeSub_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *mergeSub_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *mergeSub_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(mergeSub)
+	c.encode(e, &s)
+}
+
+func (c *mergeSub_codec) encode(e *codecapi.Encoder, x *mergeSub) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.A != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.A))
+	}
+	if x.B != 0 {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.B))
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(mergeSub_type, start)
+	}
+}
+
+func (c *mergeSub_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x mergeSub
+	c.decode(d, &x)
+	return x
+}
+
+func (c *mergeSub_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*mergeSub)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z mergeSub
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *mergeSub_codec) decode(d *codecapi.Decoder, x *mergeSub) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(mergeSub_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.A = int(d.DecodeInt())
+		case 1:
+			x.B = int(d.DecodeInt())
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("mergeSub")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(mergeSub_type, func() codecapi.TypeCodec { return &mergeSub_codec{} })
+}
+
+//// codec.money
+
+var money_type = reflect.TypeOf((*money)(nil)).Elem()
+
+type money_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *money_codec) TypesUsed() []reflect.Type { return nil }
+
+func (c *money_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *money_codec) CustomName() string { return "money/v1" }
+
+func (c *money_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(money)) }
+
+func (c *money_codec) encode(e *codecapi.Encoder, x money) {
+	start := e.StatsStart()
+	if err := encodeMoney(e, x); err != nil {
+		codecapi.Fail(err)
+	}
+	if start >= 0 {
+		e.StatsEnd(money_type, start)
+	}
+}
+
+func (c *money_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x money
+	c.decode(d, &x)
+	return x
+}
+
+func (c *money_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*money)
+	if !d.Merging() {
+		var z money
+		*x = z
+	}
+	c.decode(d, x)
+}
+
+func (c *money_codec) decode(d *codecapi.Decoder, p *money) {
+	if err := decodeMoney(d, p); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
+func init() {
+	codecapi.Register(money_type, func() codecapi.TypeCodec { return &money_codec{} })
+}
+
+//// codec.moved
+
+var moved_type = reflect.TypeOf((*moved)(nil)).Elem()
+
+var moved_fields = []string{"A"}
+
+var moved_kinds = []reflect.Kind{reflect.Int}
+
+var moved_elemKinds = [][]reflect.Kind{nil}
+
+var moved_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem()}
+
+type moved_codec struct {
+	fieldMap []int
+}
+
+func (c *moved_codec) Fields() []string {
+	return moved_fields
+}
+
+func (c *moved_codec) FieldKinds() []reflect.Kind {
+	return moved_kinds
+}
+
+func (c *moved_codec) FieldElemKinds() [][]reflect.Kind {
+	return moved_elemKinds
+}
+
+func (c *moved_codec) FieldTypes() []reflect.Type {
+	return moved_fieldTypes
+}
+
+func (c *moved_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *moved_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *moved_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *moved_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(moved)
+	c.encode(e, &s)
+}
+
+func (c *moved_codec) encode(e *codecapi.Encoder, x *moved) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.A != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.A))
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(moved_type, start)
+	}
+}
+
+func (c *moved_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x moved
+	c.decode(d, &x)
+	return x
+}
+
+func (c *moved_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*moved)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z moved
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *moved_codec) decode(d *codecapi.Decoder, x *moved) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(moved_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.A = int(d.DecodeInt())
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("moved")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(moved_type, func() codecapi.TypeCodec { return &moved_codec{} })
+}
+
+//// codec.node
+
+var node_type = reflect.TypeOf((*node)(nil)).Elem()
+
+var node_fields = []string{"Value", "Next"}
+
+var node_kinds = []reflect.Kind{reflect.Int, reflect.Ptr}
+
+var node_elemKinds = [][]reflect.Kind{nil, {reflect.Struct}}
+
+var node_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((**node)(nil)).Elem()}
+
+type node_codec struct {
+	ptr_node_codec *ptr_node_codec
+	fieldMap       []int
+}
+
+func (c *node_codec) Fields() []string {
+	return node_fields
+}
+
+func (c *node_codec) FieldKinds() []reflect.Kind {
+	return node_kinds
+}
+
+func (c *node_codec) FieldElemKinds() [][]reflect.Kind {
+	return node_elemKinds
+}
+
+func (c *node_codec) FieldTypes() []reflect.Type {
+	return node_fieldTypes
+}
+
+func (c *node_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *node_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{ptr_node_type}
+}
+
+func (c *node_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.ptr_node_codec = tcs[0].(*ptr_node_codec)
+}
+
+func (c *node_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(node)
+	c.encode(e, &s)
+}
+
+func (c *node_codec) encode(e *codecapi.Encoder, x *node) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Value != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.Value))
+	}
+	if x.Next != nil {
+		e.EncodeUint(1)
+		c.ptr_node_codec.encode(e, x.Next)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(node_type, start)
+	}
+}
+
+func (c *node_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x node
+	c.decode(d, &x)
+	return x
+}
+
+func (c *node_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*node)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z node
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *node_codec) decode(d *codecapi.Decoder, x *node) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(node_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.Value = int(d.DecodeInt())
+		case 1:
+			c.ptr_node_codec.decode(d, &x.Next)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("node")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(node_type, func() codecapi.TypeCodec { return &node_codec{} })
+}
+
+//// codec.panicky
+
+var panicky_type = reflect.TypeOf((*panicky)(nil)).Elem()
+
+type panicky_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *panicky_codec) TypesUsed() []reflect.Type { return nil }
+
+func (c *panicky_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *panicky_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(panicky)) }
+
+func (c *panicky_codec) encode(e *codecapi.Encoder, m panicky) {
+	start := e.StatsStart()
+	if err := m.MarshalCodec(e); err != nil {
+		codecapi.Fail(err)
+	}
+	if start >= 0 {
+		e.StatsEnd(panicky_type, start)
+	}
+}
+
+func (c *panicky_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x panicky
+	c.decode(d, &x)
+	return x
+}
+
+func (c *panicky_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*panicky)
+	if !d.Merging() {
+		var z panicky
+		*x = z
+	}
+	c.decode(d, x)
+}
+
+func (c *panicky_codec) decode(d *codecapi.Decoder, p *panicky) {
+	if err := p.UnmarshalCodec(d); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
+func init() {
+	codecapi.Register(panicky_type, func() codecapi.TypeCodec { return &panicky_codec{} })
+}
+
+//// codec.parallelItem
+
+var parallelItem_type = reflect.TypeOf((*parallelItem)(nil)).Elem()
+
+var parallelItem_fields = []string{"N", "V"}
+
+var parallelItem_kinds = []reflect.Kind{reflect.Int, reflect.Interface}
+
+var parallelItem_elemKinds = [][]reflect.Kind{nil, nil}
+
+var parallelItem_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*interface{})(nil)).Elem()}
+
+type parallelItem_codec struct {
+	fieldMap []int
+}
+
+func (c *parallelItem_codec) Fields() []string {
+	return parallelItem_fields
+}
+
+func (c *parallelItem_codec) FieldKinds() []reflect.Kind {
+	return parallelItem_kinds
+}
+
+func (c *parallelItem_codec) FieldElemKinds() [][]reflect.Kind {
+	return parallelItem_elemKinds
+}
+
+func (c *parallelItem_codec) FieldTypes() []reflect.Type {
+	return parallelItem_fieldTypes
+}
+
+func (c *parallelItem_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *parallelItem_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *parallelItem_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *parallelItem_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(parallelItem)
+	c.encode(e, &s)
+}
+
+func (c *parallelItem_codec) encode(e *codecapi.Encoder, x *parallelItem) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.N != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.N))
+	}
+	if x.V != nil {
+		e.EncodeUint(1)
+		e.EncodeAny(x.V)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(parallelItem_type, start)
+	}
+}
+
+func (c *parallelItem_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x parallelItem
+	c.decode(d, &x)
+	return x
+}
+
+func (c *parallelItem_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*parallelItem)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z parallelItem
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *parallelItem_codec) decode(d *codecapi.Decoder, x *parallelItem) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(parallelItem_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.N = int(d.DecodeInt())
+		case 1:
+			x.V = d.DecodeAny()
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("parallelItem")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(parallelItem_type, func() codecapi.TypeCodec { return &parallelItem_codec{} })
+}
+
+//// codec.patch
+
+var patch_type = reflect.TypeOf((*patch)(nil)).Elem()
+
+var patch_fields = []string{"A", "B", "Cee"}
+
+var patch_kinds = []reflect.Kind{reflect.Int, reflect.Int, reflect.String}
+
+var patch_elemKinds = [][]reflect.Kind{nil, nil, nil}
+
+var patch_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*string)(nil)).Elem()}
+
+type patch_codec struct {
+	fieldMap []int
+}
+
+func (c *patch_codec) Fields() []string {
+	return patch_fields
+}
+
+func (c *patch_codec) FieldKinds() []reflect.Kind {
+	return patch_kinds
+}
+
+func (c *patch_codec) FieldElemKinds() [][]reflect.Kind {
+	return patch_elemKinds
+}
+
+func (c *patch_codec) FieldTypes() []reflect.Type {
+	return patch_fieldTypes
+}
+
+func (c *patch_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *patch_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *patch_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *patch_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(patch)
+	c.encode(e, &s)
+}
+
+func (c *patch_codec) encode(e *codecapi.Encoder, x *patch) {
+	start := e.StatsStart()
+	e.StartStruct()
+
+	e.EncodeUint(0)
+	e.EncodeInt(int64(x.A))
+	if x.B != 0 {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.B))
+	}
+	if x.C != "" {
+		e.EncodeUint(2)
+		e.EncodeString(x.C)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(patch_type, start)
+	}
+}
+
+func (c *patch_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x patch
+	c.decode(d, &x)
+	return x
+}
+
+func (c *patch_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*patch)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z patch
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *patch_codec) decode(d *codecapi.Decoder, x *patch) {
+	d.StartStruct()
+	x.Present.Reset(patch_fields)
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(patch_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		if n >= 0 {
+			x.Present.Set(n)
+		}
+		field = n
+		switch n {
+		case 0:
+			x.A = int(d.DecodeInt())
+		case 1:
+			x.B = int(d.DecodeInt())
+		case 2:
+			x.C = d.DecodeString()
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("patch")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(patch_type, func() codecapi.TypeCodec { return &patch_codec{} })
+}
+
+//// codec.promoted
+
+var promoted_type = reflect.TypeOf((*promoted)(nil)).Elem()
+
+var promoted_fields = []string{"A", "E", "P", "Q"}
+
+var promoted_kinds = []reflect.Kind{reflect.Int, reflect.Int, reflect.String, reflect.Bool}
+
+var promoted_elemKinds = [][]reflect.Kind{nil, nil, nil, nil}
+
+var promoted_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*bool)(nil)).Elem()}
+
+type promoted_codec struct {
+	fieldMap []int
+}
+
+func (c *promoted_codec) Fields() []string {
+	return promoted_fields
+}
+
+func (c *promoted_codec) FieldKinds() []reflect.Kind {
+	return promoted_kinds
+}
+
+func (c *promoted_codec) FieldElemKinds() [][]reflect.Kind {
+	return promoted_elemKinds
+}
+
+func (c *promoted_codec) FieldTypes() []reflect.Type {
+	return promoted_fieldTypes
+}
+
+func (c *promoted_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *promoted_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *promoted_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *promoted_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(promoted)
+	c.encode(e, &s)
+}
+
+func (c *promoted_codec) encode(e *codecapi.Encoder, x *promoted) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.A != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.A))
+	}
+	if x.embed.E != 0 {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.embed.E))
+	}
+	if x.ptrEmbed != nil && x.ptrEmbed.P != "" {
+		e.EncodeUint(2)
+		e.EncodeString(x.ptrEmbed.P)
+	}
+	if x.In.Q != false {
+		e.EncodeUint(3)
+		e.EncodeBool(x.In.Q)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(promoted_type, start)
+	}
+}
+
+func (c *promoted_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x promoted
+	c.decode(d, &x)
+	return x
+}
+
+func (c *promoted_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*promoted)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z promoted
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *promoted_codec) decode(d *codecapi.Decoder, x *promoted) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(promoted_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.A = int(d.DecodeInt())
+		case 1:
+			x.embed.E = int(d.DecodeInt())
+		case 2:
+			if x.ptrEmbed == nil {
+				x.ptrEmbed = new(ptrEmbed)
+			}
+			x.ptrEmbed.P = d.DecodeString()
+		case 3:
+			x.In.Q = d.DecodeBool()
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("promoted")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(promoted_type, func() codecapi.TypeCodec { return &promoted_codec{} })
+}
+
+//// codec.ptrEmbed
+
+var ptrEmbed_type = reflect.TypeOf((*ptrEmbed)(nil)).Elem()
+
+var ptrEmbed_fields = []string{"P"}
+
+var ptrEmbed_kinds = []reflect.Kind{reflect.String}
+
+var ptrEmbed_elemKinds = [][]reflect.Kind{nil}
+
+var ptrEmbed_fieldTypes = []reflect.Type{reflect.TypeOf((*string)(nil)).Elem()}
+
+type ptrEmbed_codec struct {
+	fieldMap []int
+}
+
+func (c *ptrEmbed_codec) Fields() []string {
+	return ptrEmbed_fields
+}
+
+func (c *ptrEmbed_codec) FieldKinds() []reflect.Kind {
+	return ptrEmbed_kinds
+}
+
+func (c *ptrEmbed_codec) FieldElemKinds() [][]reflect.Kind {
+	return ptrEmbed_elemKinds
+}
+
+func (c *ptrEmbed_codec) FieldTypes() []reflect.Type {
+	return ptrEmbed_fieldTypes
+}
+
+func (c *ptrEmbed_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *ptrEmbed_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *ptrEmbed_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *ptrEmbed_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(ptrEmbed)
+	c.encode(e, &s)
+}
+
+func (c *ptrEmbed_codec) encode(e *codecapi.Encoder, x *ptrEmbed) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.P != "" {
+		e.EncodeUint(0)
+		e.EncodeString(x.P)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(ptrEmbed_type, start)
+	}
+}
+
+func (c *ptrEmbed_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x ptrEmbed
+	c.decode(d, &x)
+	return x
+}
+
+func (c *ptrEmbed_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*ptrEmbed)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z ptrEmbed
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *ptrEmbed_codec) decode(d *codecapi.Decoder, x *ptrEmbed) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(ptrEmbed_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.P = d.DecodeString()
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("ptrEmbed")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(ptrEmbed_type, func() codecapi.TypeCodec { return &ptrEmbed_codec{} })
+}
+
+//// codec.reading
+
+var reading_type = reflect.TypeOf((*reading)(nil)).Elem()
+
+var reading_fields = []string{"Where", "Temp"}
+
+var reading_kinds = []reflect.Kind{reflect.String, reflect.Interface}
+
+var reading_elemKinds = [][]reflect.Kind{nil, nil}
+
+var reading_fieldTypes = []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*celsius)(nil)).Elem()}
+
+type reading_codec struct {
+	celsius_codec *celsius_codec
+	fieldMap      []int
+}
+
+func (c *reading_codec) Fields() []string {
+	return reading_fields
+}
+
+func (c *reading_codec) FieldKinds() []reflect.Kind {
+	return reading_kinds
+}
+
+func (c *reading_codec) FieldElemKinds() [][]reflect.Kind {
+	return reading_elemKinds
+}
+
+func (c *reading_codec) FieldTypes() []reflect.Type {
+	return reading_fieldTypes
+}
+
+func (c *reading_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *reading_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{celsius_type}
+}
+
+func (c *reading_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.celsius_codec = tcs[0].(*celsius_codec)
+}
+
+func (c *reading_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(reading)
+	c.encode(e, &s)
+}
+
+func (c *reading_codec) encode(e *codecapi.Encoder, x *reading) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Where != "" {
+		e.EncodeUint(0)
+		e.EncodeString(x.Where)
+	}
+
+	e.EncodeUint(1)
+	c.celsius_codec.encode(e, x.Temp)
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(reading_type, start)
+	}
+}
+
+func (c *reading_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x reading
+	c.decode(d, &x)
+	return x
+}
+
+func (c *reading_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*reading)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z reading
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *reading_codec) decode(d *codecapi.Decoder, x *reading) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(reading_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.Where = d.DecodeString()
+		case 1:
+			c.celsius_codec.decode(d, &x.Temp)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("reading")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(reading_type, func() codecapi.TypeCodec { return &reading_codec{} })
+}
+
+//// codec.renamedA
+
+var renamedA_type = reflect.TypeOf((*renamedA)(nil)).Elem()
+
+var renamedA_fields = []string{"New", "X"}
+
+var renamedA_kinds = []reflect.Kind{reflect.Int, reflect.Int}
+
+var renamedA_elemKinds = [][]reflect.Kind{nil, nil}
+
+var renamedA_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem()}
+
+type renamedA_codec struct {
+	fieldMap []int
+}
+
+func (c *renamedA_codec) Fields() []string {
+	return renamedA_fields
+}
+
+func (c *renamedA_codec) FieldKinds() []reflect.Kind {
+	return renamedA_kinds
+}
+
+func (c *renamedA_codec) FieldElemKinds() [][]reflect.Kind {
+	return renamedA_elemKinds
+}
+
+func (c *renamedA_codec) FieldTypes() []reflect.Type {
+	return renamedA_fieldTypes
+}
+
+var renamedA_aliases = map[string]string{
+	"Old":   "New",
+	"Older": "New",
+}
+
+func (c *renamedA_codec) FieldAliases() map[string]string {
+	return renamedA_aliases
+}
+
+func (c *renamedA_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *renamedA_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *renamedA_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *renamedA_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(renamedA)
+	c.encode(e, &s)
+}
+
+func (c *renamedA_codec) encode(e *codecapi.Encoder, x *renamedA) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.New != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.New))
+	}
+	if x.X != 0 {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.X))
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(renamedA_type, start)
+	}
+}
+
+func (c *renamedA_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x renamedA
+	c.decode(d, &x)
+	return x
+}
+
+func (c *renamedA_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*renamedA)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z renamedA
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *renamedA_codec) decode(d *codecapi.Decoder, x *renamedA) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(renamedA_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.New = int(d.DecodeInt())
+		case 1:
+			x.X = int(d.DecodeInt())
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("renamedA")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(renamedA_type, func() codecapi.TypeCodec { return &renamedA_codec{} })
+}
+
+//// codec.renamedB
+
+var renamedB_type = reflect.TypeOf((*renamedB)(nil)).Elem()
+
+var renamedB_fields = []string{"X", "Old"}
+
+var renamedB_kinds = []reflect.Kind{reflect.Int, reflect.Int}
+
+var renamedB_elemKinds = [][]reflect.Kind{nil, nil}
+
+var renamedB_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem()}
+
+type renamedB_codec struct {
+	fieldMap []int
+}
+
+func (c *renamedB_codec) Fields() []string {
+	return renamedB_fields
+}
+
+func (c *renamedB_codec) FieldKinds() []reflect.Kind {
+	return renamedB_kinds
+}
+
+func (c *renamedB_codec) FieldElemKinds() [][]reflect.Kind {
+	return renamedB_elemKinds
+}
+
+func (c *renamedB_codec) FieldTypes() []reflect.Type {
+	return renamedB_fieldTypes
+}
+
+func (c *renamedB_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *renamedB_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *renamedB_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *renamedB_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(renamedB)
+	c.encode(e, &s)
+}
+
+func (c *renamedB_codec) encode(e *codecapi.Encoder, x *renamedB) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.X != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.X))
+	}
+	if x.Old != 0 {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.Old))
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(renamedB_type, start)
+	}
+}
+
+func (c *renamedB_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x renamedB
+	c.decode(d, &x)
+	return x
+}
+
+func (c *renamedB_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*renamedB)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z renamedB
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *renamedB_codec) decode(d *codecapi.Decoder, x *renamedB) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(renamedB_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.X = int(d.DecodeInt())
+		case 1:
+			x.Old = int(d.DecodeInt())
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("renamedB")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(renamedB_type, func() codecapi.TypeCodec { return &renamedB_codec{} })
+}
+
+//// codec.renamedC
+
+var renamedC_type = reflect.TypeOf((*renamedC)(nil)).Elem()
+
+var renamedC_fields = []string{"Older", "X"}
+
+var renamedC_kinds = []reflect.Kind{reflect.Int, reflect.Int}
+
+var renamedC_elemKinds = [][]reflect.Kind{nil, nil}
+
+var renamedC_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem()}
+
+type renamedC_codec struct {
+	fieldMap []int
+}
+
+func (c *renamedC_codec) Fields() []string {
+	return renamedC_fields
+}
+
+func (c *renamedC_codec) FieldKinds() []reflect.Kind {
+	return renamedC_kinds
+}
+
+func (c *renamedC_codec) FieldElemKinds() [][]reflect.Kind {
+	return renamedC_elemKinds
+}
+
+func (c *renamedC_codec) FieldTypes() []reflect.Type {
+	return renamedC_fieldTypes
+}
+
+func (c *renamedC_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *renamedC_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *renamedC_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *renamedC_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(renamedC)
+	c.encode(e, &s)
+}
+
+func (c *renamedC_codec) encode(e *codecapi.Encoder, x *renamedC) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.Older != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.Older))
+	}
+	if x.X != 0 {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.X))
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(renamedC_type, start)
+	}
+}
+
+func (c *renamedC_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x renamedC
+	c.decode(d, &x)
+	return x
+}
+
+func (c *renamedC_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*renamedC)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z renamedC
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *renamedC_codec) decode(d *codecapi.Decoder, x *renamedC) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(renamedC_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.Older = int(d.DecodeInt())
+		case 1:
+			x.X = int(d.DecodeInt())
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("renamedC")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(renamedC_type, func() codecapi.TypeCodec { return &renamedC_codec{} })
+}
+
+//// codec.reqdA
+
+var reqdA_type = reflect.TypeOf((*reqdA)(nil)).Elem()
+
+var reqdA_fields = []string{"R", "D", "S", "F", "L", "P"}
+
+var reqdA_kinds = []reflect.Kind{reflect.Int, reflect.Int, reflect.String, reflect.Float32, reflect.Slice, reflect.Ptr}
+
+var reqdA_elemKinds = [][]reflect.Kind{nil, nil, nil, nil, {reflect.String}, {reflect.Struct}}
+
+var reqdA_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*float32)(nil)).Elem(), reflect.TypeOf((*[]string)(nil)).Elem(), reflect.TypeOf((**ptrEmbed)(nil)).Elem()}
+
+type reqdA_codec struct {
+	ptr_ptrEmbed_codec *ptr_ptrEmbed_codec
+	slice_string_codec *slice_string_codec
+	fieldMap           []int
+}
+
+func (c *reqdA_codec) Fields() []string {
+	return reqdA_fields
+}
+
+func (c *reqdA_codec) FieldKinds() []reflect.Kind {
+	return reqdA_kinds
+}
+
+func (c *reqdA_codec) FieldElemKinds() [][]reflect.Kind {
+	return reqdA_elemKinds
+}
+
+func (c *reqdA_codec) FieldTypes() []reflect.Type {
+	return reqdA_fieldTypes
+}
+
+func (c *reqdA_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *reqdA_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{ptr_ptrEmbed_type, slice_string_type}
+}
+
+func (c *reqdA_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.ptr_ptrEmbed_codec = tcs[0].(*ptr_ptrEmbed_codec)
+	c.slice_string_codec = tcs[1].(*slice_string_codec)
+}
+
+func (c *reqdA_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(reqdA)
+	c.encode(e, &s)
+}
+
+func (c *reqdA_codec) encode(e *codecapi.Encoder, x *reqdA) {
+	start := e.StatsStart()
+	e.StartStruct()
+
+	e.EncodeUint(0)
+	e.EncodeInt(int64(x.R))
+
+	e.EncodeUint(1)
+	e.EncodeInt(int64(x.D))
+
+	e.EncodeUint(2)
+	e.EncodeString(x.S)
+
+	e.EncodeUint(3)
+	e.EncodeFloat(float64(x.F))
+
+	e.EncodeUint(4)
+	c.slice_string_codec.encode(e, x.L)
+
+	e.EncodeUint(5)
+	c.ptr_ptrEmbed_codec.encode(e, x.P)
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(reqdA_type, start)
+	}
+}
+
+func (c *reqdA_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x reqdA
+	c.decode(d, &x)
+	return x
+}
+
+func (c *reqdA_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*reqdA)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z reqdA
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *reqdA_codec) decode(d *codecapi.Decoder, x *reqdA) {
+	d.StartStruct()
+	if !d.Merging() {
+		x.Default()
+	}
+	var seen [6]bool
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(reqdA_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		if n >= 0 {
+			seen[n] = true
+		}
+		field = n
+		switch n {
+		case 0:
+			x.R = int(d.DecodeInt())
+		case 1:
+			x.D = int(d.DecodeInt())
+		case 2:
+			x.S = d.DecodeString()
+		case 3:
+			x.F = float32(d.DecodeFloat())
+		case 4:
+			c.slice_string_codec.decode(d, &x.L)
+		case 5:
+			c.ptr_ptrEmbed_codec.decode(d, &x.P)
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("reqdA")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+	if !seen[0] {
+		codecapi.Failf("%s: missing required field %s", "reqdA", "R")
+	}
+	if !seen[1] && (!d.Merging()) {
+		x.D = 7
+	}
+	if !seen[2] && (!d.Merging()) {
+		x.S = "hi"
+	}
+	if !seen[3] && (!d.Merging()) {
+		x.F = 1.5
+	}
+}
+
+func init() {
+	codecapi.Register(reqdA_type, func() codecapi.TypeCodec { return &reqdA_codec{} })
+}
+
+//// codec.reqdB
+
+var reqdB_type = reflect.TypeOf((*reqdB)(nil)).Elem()
+
+var reqdB_fields = []string{}
+
+var reqdB_kinds = []reflect.Kind{}
+
+var reqdB_elemKinds = [][]reflect.Kind{}
+
+var reqdB_fieldTypes = []reflect.Type{}
+
+type reqdB_codec struct {
+	fieldMap []int
+}
+
+func (c *reqdB_codec) Fields() []string {
+	return reqdB_fields
+}
+
+func (c *reqdB_codec) FieldKinds() []reflect.Kind {
+	return reqdB_kinds
+}
+
+func (c *reqdB_codec) FieldElemKinds() [][]reflect.Kind {
+	return reqdB_elemKinds
+}
+
+func (c *reqdB_codec) FieldTypes() []reflect.Type {
+	return reqdB_fieldTypes
+}
+
+func (c *reqdB_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *reqdB_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *reqdB_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *reqdB_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(reqdB)
+	c.encode(e, &s)
+}
+
+func (c *reqdB_codec) encode(e *codecapi.Encoder, x *reqdB) {
+	start := e.StatsStart()
+	e.StartStruct()
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(reqdB_type, start)
+	}
+}
+
+func (c *reqdB_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x reqdB
+	c.decode(d, &x)
+	return x
+}
+
+func (c *reqdB_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*reqdB)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z reqdB
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *reqdB_codec) decode(d *codecapi.Decoder, x *reqdB) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(reqdB_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("reqdB")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(reqdB_type, func() codecapi.TypeCodec { return &reqdB_codec{} })
+}
+
+//// codec.reqdC
+
+var reqdC_type = reflect.TypeOf((*reqdC)(nil)).Elem()
+
+var reqdC_fields = []string{"R"}
+
+var reqdC_kinds = []reflect.Kind{reflect.Int}
+
+var reqdC_elemKinds = [][]reflect.Kind{nil}
+
+var reqdC_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem()}
+
+type reqdC_codec struct {
+	fieldMap []int
+}
+
+func (c *reqdC_codec) Fields() []string {
+	return reqdC_fields
+}
+
+func (c *reqdC_codec) FieldKinds() []reflect.Kind {
+	return reqdC_kinds
+}
+
+func (c *reqdC_codec) FieldElemKinds() [][]reflect.Kind {
+	return reqdC_elemKinds
+}
+
+func (c *reqdC_codec) FieldTypes() []reflect.Type {
+	return reqdC_fieldTypes
+}
+
+func (c *reqdC_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *reqdC_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *reqdC_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *reqdC_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(reqdC)
+	c.encode(e, &s)
+}
+
+func (c *reqdC_codec) encode(e *codecapi.Encoder, x *reqdC) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.R != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.R))
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(reqdC_type, start)
+	}
+}
+
+func (c *reqdC_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x reqdC
+	c.decode(d, &x)
+	return x
+}
+
+func (c *reqdC_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*reqdC)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z reqdC
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *reqdC_codec) decode(d *codecapi.Decoder, x *reqdC) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(reqdC_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.R = int(d.DecodeInt())
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("reqdC")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(reqdC_type, func() codecapi.TypeCodec { return &reqdC_codec{} })
+}
+
+//// codec.reqdEmbA
+
+var reqdEmbA_type = reflect.TypeOf((*reqdEmbA)(nil)).Elem()
+
+var reqdEmbA_fields = []string{"A", "R", "S"}
+
+var reqdEmbA_kinds = []reflect.Kind{reflect.Int, reflect.Int, reflect.String}
+
+var reqdEmbA_elemKinds = [][]reflect.Kind{nil, nil, nil}
+
+var reqdEmbA_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*string)(nil)).Elem()}
+
+type reqdEmbA_codec struct {
+	fieldMap []int
+}
+
+func (c *reqdEmbA_codec) Fields() []string {
+	return reqdEmbA_fields
+}
+
+func (c *reqdEmbA_codec) FieldKinds() []reflect.Kind {
+	return reqdEmbA_kinds
+}
+
+func (c *reqdEmbA_codec) FieldElemKinds() [][]reflect.Kind {
+	return reqdEmbA_elemKinds
+}
+
+func (c *reqdEmbA_codec) FieldTypes() []reflect.Type {
+	return reqdEmbA_fieldTypes
+}
+
+func (c *reqdEmbA_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *reqdEmbA_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *reqdEmbA_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *reqdEmbA_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(reqdEmbA)
+	c.encode(e, &s)
+}
+
+func (c *reqdEmbA_codec) encode(e *codecapi.Encoder, x *reqdEmbA) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.A != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.A))
+	}
+	if x.reqdInA != nil {
+		e.EncodeUint(1)
+		e.EncodeInt(int64(x.reqdInA.R))
+	}
+	if x.reqdInA != nil && x.reqdInA.S != "" {
+		e.EncodeUint(2)
+		e.EncodeString(x.reqdInA.S)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(reqdEmbA_type, start)
+	}
+}
+
+func (c *reqdEmbA_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x reqdEmbA
+	c.decode(d, &x)
+	return x
+}
+
+func (c *reqdEmbA_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*reqdEmbA)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z reqdEmbA
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *reqdEmbA_codec) decode(d *codecapi.Decoder, x *reqdEmbA) {
+	d.StartStruct()
+	var seen [3]bool
+	// Whether decoding allocates an embedded struct holding field 1.
+	fresh1 := x.reqdInA == nil
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(reqdEmbA_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		if n >= 0 {
+			seen[n] = true
+		}
+		field = n
+		switch n {
+		case 0:
+			x.A = int(d.DecodeInt())
+		case 1:
+			if x.reqdInA == nil {
+				x.reqdInA = new(reqdInA)
+			}
+			x.reqdInA.R = int(d.DecodeInt())
+		case 2:
+			if x.reqdInA == nil {
+				x.reqdInA = new(reqdInA)
+			}
+			x.reqdInA.S = d.DecodeString()
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("reqdEmbA")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+	// The field is missing only if decoding allocated the embedded
+	// struct holding it. Otherwise the struct's pointer was nil when
+	// it was encoded, or may have been, when merging.
+	if !seen[1] && fresh1 && x.reqdInA != nil {
+		codecapi.Failf("%s: missing required field %s", "reqdEmbA", "R")
+	}
+}
+
+func init() {
+	codecapi.Register(reqdEmbA_type, func() codecapi.TypeCodec { return &reqdEmbA_codec{} })
+}
+
+//// codec.reqdEmbB
+
+var reqdEmbB_type = reflect.TypeOf((*reqdEmbB)(nil)).Elem()
+
+var reqdEmbB_fields = []string{"A", "S"}
+
+var reqdEmbB_kinds = []reflect.Kind{reflect.Int, reflect.String}
+
+var reqdEmbB_elemKinds = [][]reflect.Kind{nil, nil}
+
+var reqdEmbB_fieldTypes = []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*string)(nil)).Elem()}
+
+type reqdEmbB_codec struct {
+	fieldMap []int
+}
+
+func (c *reqdEmbB_codec) Fields() []string {
+	return reqdEmbB_fields
+}
+
+func (c *reqdEmbB_codec) FieldKinds() []reflect.Kind {
+	return reqdEmbB_kinds
+}
+
+func (c *reqdEmbB_codec) FieldElemKinds() [][]reflect.Kind {
+	return reqdEmbB_elemKinds
+}
+
+func (c *reqdEmbB_codec) FieldTypes() []reflect.Type {
+	return reqdEmbB_fieldTypes
+}
+
+func (c *reqdEmbB_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *reqdEmbB_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *reqdEmbB_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *reqdEmbB_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(reqdEmbB)
+	c.encode(e, &s)
+}
+
+func (c *reqdEmbB_codec) encode(e *codecapi.Encoder, x *reqdEmbB) {
+	start := e.StatsStart()
+	e.StartStruct()
+	if x.A != 0 {
+		e.EncodeUint(0)
+		e.EncodeInt(int64(x.A))
+	}
+	if x.reqdInB != nil && x.reqdInB.S != "" {
+		e.EncodeUint(1)
+		e.EncodeString(x.reqdInB.S)
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(reqdEmbB_type, start)
+	}
+}
+
+func (c *reqdEmbB_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x reqdEmbB
+	c.decode(d, &x)
+	return x
+}
+
+func (c *reqdEmbB_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*reqdEmbB)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z reqdEmbB
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *reqdEmbB_codec) decode(d *codecapi.Decoder, x *reqdEmbB) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(reqdEmbB_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			x.A = int(d.DecodeInt())
+		case 1:
+			if x.reqdInB == nil {
+				x.reqdInB = new(reqdInB)
+			}
+			x.reqdInB.S = d.DecodeString()
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("reqdEmbB")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(reqdEmbB_type, func() codecapi.TypeCodec { return &reqdEmbB_codec{} })
+}
+
+//// codec.rgb
+
+var rgb_type = reflect.TypeOf((*rgb)(nil)).Elem()
+
+type rgb_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *rgb_codec) TypesUsed() []reflect.Type      { return nil }
+func (c *rgb_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *rgb_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	p := reflect.New(rgb_type)
+	p.Elem().Set(reflect.ValueOf(x))
+	c.encode(e, p.Interface().(*rgb))
+}
+
+func (c *rgb_codec) encode(e *codecapi.Encoder, x *rgb) {
+	start := e.StatsStart()
+	px := rgbToHex(x)
+	e.EncodeString(px)
+	if start >= 0 {
+		e.StatsEnd(rgb_type, start)
+	}
+}
+
+func (c *rgb_codec) Decode(d *codecapi.Decoder) interface{} {
+	p := reflect.New(rgb_type)
+	c.decode(d, p.Interface().(*rgb))
+	return p.Elem().Interface()
+}
+
+func (c *rgb_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	c.decode(d, p.(*rgb))
+}
+
+func (c *rgb_codec) decode(d *codecapi.Decoder, p *rgb) {
+	var px string
+	if d.Merging() {
+		x := p
+		px = rgbToHex(x)
+	}
+	px = d.DecodeString()
+	if !d.Merging() {
+		// Clear *p only now, so that it is unchanged if decoding px fails.
+		*p = rgb{}
+	}
+	if err := rgbFromHex(p, px); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
+func init() {
+	codecapi.Register(rgb_type, func() codecapi.TypeCodec { return &rgb_codec{} })
+}
+
+//// codec.structType
+
+var structType_type = reflect.TypeOf((*structType)(nil)).Elem()
+
+var structType_fields = []string{"N", "B", "unexported", "E"}
+
+var structType_kinds = []reflect.Kind{reflect.Struct, reflect.Uint8, reflect.Int, reflect.Int}
+
+var structType_elemKinds = [][]reflect.Kind{nil, nil, nil, nil}
+
+var structType_fieldTypes = []reflect.Type{reflect.TypeOf((*node)(nil)).Elem(), reflect.TypeOf((*uint8)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem()}
+
+type structType_codec struct {
+	node_codec *node_codec
+	fieldMap   []int
+}
+
+func (c *structType_codec) Fields() []string {
+	return structType_fields
+}
+
+func (c *structType_codec) FieldKinds() []reflect.Kind {
+	return structType_kinds
+}
+
+func (c *structType_codec) FieldElemKinds() [][]reflect.Kind {
+	return structType_elemKinds
+}
+
+func (c *structType_codec) FieldTypes() []reflect.Type {
+	return structType_fieldTypes
+}
+
+func (c *structType_codec) SetFieldMap(fm []int) {
+	c.fieldMap = fm
+}
+
+func (c *structType_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{node_type}
+}
+
+func (c *structType_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.node_codec = tcs[0].(*node_codec)
+}
+
+func (c *structType_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	s := x.(structType)
+	c.encode(e, &s)
+}
+
+func (c *structType_codec) encode(e *codecapi.Encoder, x *structType) {
+	start := e.StatsStart()
+	e.StartStruct()
+
+	e.EncodeUint(0)
+	c.node_codec.encode(e, &x.N)
+	if x.B != 0 {
+		e.EncodeUint(1)
+		e.EncodeByte(x.B)
+	}
+	if x.unexported != 0 {
+		e.EncodeUint(2)
+		e.EncodeInt(int64(x.unexported))
+	}
+	if x.embed.E != 0 {
+		e.EncodeUint(3)
+		e.EncodeInt(int64(x.embed.E))
+	}
+	e.EndStruct()
+	if start >= 0 {
+		e.StatsEnd(structType_type, start)
+	}
+}
+
+func (c *structType_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x structType
+	c.decode(d, &x)
+	return x
+}
+
+func (c *structType_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*structType)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z structType
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *structType_codec) decode(d *codecapi.Decoder, x *structType) {
+	d.StartStruct()
+	field := -1 // the field being decoded
+	if d.RecordingPath() {
+		defer func() {
+			if field >= 0 {
+				d.PathField(structType_fields[field])
+			}
+		}()
+	}
+loop:
+	for {
+		field = -1
+		n := d.NextStructField(c.fieldMap)
+		field = n
+		switch n {
+		case 0:
+			c.node_codec.decode(d, &x.N)
+		case 1:
+			x.B = d.DecodeByte()
+		case 2:
+			x.unexported = int(d.DecodeInt())
+		case 3:
+			x.embed.E = int(d.DecodeInt())
+		case -1:
+			break loop
+		case -2:
+			d.UnknownField("structType")
+		default:
+			codecapi.Failf("bad struct field value: %d", n)
+		}
+	}
+}
+
+func init() {
+	codecapi.Register(structType_type, func() codecapi.TypeCodec { return &structType_codec{} })
+}
+
+//// foo.T
+
+var foo_T_type = reflect.TypeOf((*foo.T)(nil)).Elem()
+
+type foo_T_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *foo_T_codec) TypesUsed() []reflect.Type      { return nil }
+func (c *foo_T_codec) SetCodecs([]codecapi.TypeCodec) {}
+
+func (c *foo_T_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(foo.T)) }
+
+func (c *foo_T_codec) encode(e *codecapi.Encoder, s foo.T) {
+	start := e.StatsStart()
+	if s == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(len(s))
+		for _, x := range s {
+			e.EncodeInt(int64(x))
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(foo_T_type, start)
+	}
+}
+
+func (c *foo_T_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	s := x.(foo.T)
+	size := (len(s) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(s); i += size {
+		part := s[i:]
+		if len(part) > size {
+			part = part[:size]
+		}
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for _, x := range part {
+				e.EncodeInt(int64(x))
+			}
+		})
+	}
+	return len(s), parts
+}
+
+func (c *foo_T_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x foo.T
+	c.decode(d, &x)
+	return x
+}
+
+func (c *foo_T_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*foo.T)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z foo.T
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *foo_T_codec) decode(d *codecapi.Decoder, p *foo.T) {
+	_, elemConv := d.ElemConversions()
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]int, n)
+	i := -1
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				d.PathIndex(i)
+			}
+		}()
+	}
+	// The elements are new, so don't merge into them.
+	merging := d.Merging()
+	d.SetMerging(false)
+	for i = 0; i < n; i++ {
+		d.Convert(elemConv)
+		s[i] = int(d.DecodeInt())
+	}
+	d.SetMerging(merging)
+	if d.AppendingSlices() {
+		s = append(*p, s...)
+	}
+	*p = s
+}
+
+func init() {
+	codecapi.Register(foo_T_type, func() codecapi.TypeCodec { return &foo_T_codec{} })
+}
+
+//// map[[1]int]codec.structType
+
+var map_array_1_int__structType_type = reflect.TypeOf((*map[[1]int]structType)(nil)).Elem()
+
+type map_array_1_int__structType_codec struct {
+	codecapi.NonStruct
+	array_1_int_codec *array_1_int_codec
+	structType_codec  *structType_codec
+}
+
+func (c *map_array_1_int__structType_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{
+		array_1_int_type,
+		structType_type,
+	}
+}
+
+func (c *map_array_1_int__structType_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.array_1_int_codec = tcs[0].(*array_1_int_codec)
+	c.structType_codec = tcs[1].(*structType_codec)
+}
+
+func (c *map_array_1_int__structType_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(map[[1]int]structType))
+}
+
+func (c *map_array_1_int__structType_codec) encode(e *codecapi.Encoder, m map[[1]int]structType) {
+	start := e.StatsStart()
+	if m == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			c.array_1_int_codec.encode(e, &k)
+			c.structType_codec.encode(e, &v)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(map_array_1_int__structType_type, start)
+	}
+}
+
+func (c *map_array_1_int__structType_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[[1]int]structType)
+	keys := make([][1]int, 0, len(m))
+	vals := make([]structType, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				c.array_1_int_codec.encode(e, &k)
+				c.structType_codec.encode(e, &v)
+			}
+		})
+	}
+	return 2 * len(m), parts
+}
+
+func (c *map_array_1_int__structType_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[[1]int]structType
+	c.decode(d, &x)
+	return x
+}
+
+func (c *map_array_1_int__structType_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[[1]int]structType)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[[1]int]structType
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_array_1_int__structType_codec) decode(d *codecapi.Decoder, p *map[[1]int]structType) {
+	n2 := d.StartList()
+	if n2 < 0 {
+		return
+	}
+	n := n2 / 2
+	var m map[[1]int]structType
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[[1]int]structType, n)
+	}
+	var k [1]int
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk [1]int
+		k, inKey = zk, true
+		d.SetMerging(false)
+		c.array_1_int_codec.decode(d, &k)
+		d.SetMerging(merging)
+		inKey = false
+		var v structType
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		c.structType_codec.decode(d, &v)
+		d.SetMerging(merging)
+		m[k] = v
+	}
+	*p = m
+}
+
+func init() {
+	codecapi.Register(map_array_1_int__structType_type, func() codecapi.TypeCodec { return &map_array_1_int__structType_codec{} })
+}
+
+//// map[int]int
+
+var map_int__int_type = reflect.TypeOf((*map[int]int)(nil)).Elem()
+
+type map_int__int_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *map_int__int_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *map_int__int_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *map_int__int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(map[int]int)) }
+
+func (c *map_int__int_codec) encode(e *codecapi.Encoder, m map[int]int) {
+	start := e.StatsStart()
+	if m == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeInt(int64(k))
+			e.EncodeInt(int64(v))
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(map_int__int_type, start)
+	}
+}
+
+func (c *map_int__int_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[int]int)
+	keys := make([]int, 0, len(m))
+	vals := make([]int, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeInt(int64(k))
+				e.EncodeInt(int64(v))
+			}
+		})
+	}
+	return 2 * len(m), parts
+}
+
+func (c *map_int__int_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[int]int
+	c.decode(d, &x)
+	return x
+}
+
+func (c *map_int__int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[int]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[int]int
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_int__int_codec) decode(d *codecapi.Decoder, p *map[int]int) {
+	keyConv, elemConv := d.ElemConversions()
+	n2 := d.StartList()
+	if n2 < 0 {
+		return
+	}
+	n := n2 / 2
+	var m map[int]int
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[int]int, n)
+	}
+	var k int
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk int
+		k, inKey = zk, true
+		d.SetMerging(false)
+		d.Convert(keyConv)
+		k = int(d.DecodeInt())
+		d.SetMerging(merging)
+		inKey = false
+		var v int
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		d.Convert(elemConv)
+		v = int(d.DecodeInt())
+		d.SetMerging(merging)
+		m[k] = v
+	}
+	*p = m
+}
+
+func init() {
+	codecapi.Register(map_int__int_type, func() codecapi.TypeCodec { return &map_int__int_codec{} })
+}
+
+//// map[string]bool
+
+var map_string__bool_type = reflect.TypeOf((*map[string]bool)(nil)).Elem()
+
+type map_string__bool_codec struct {
+	codecapi.NonStruct
+}
+
+func (c *map_string__bool_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
+}
+
+func (c *map_string__bool_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+}
+
+func (c *map_string__bool_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(map[string]bool))
+}
+
+func (c *map_string__bool_codec) encode(e *codecapi.Encoder, m map[string]bool) {
+	start := e.StatsStart()
+	if m == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			e.EncodeBool(v)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(map_string__bool_type, start)
+	}
+}
+
+func (c *map_string__bool_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[string]bool)
+	keys := make([]string, 0, len(m))
+	vals := make([]bool, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				e.EncodeBool(v)
+			}
+		})
+	}
+	return 2 * len(m), parts
+}
+
+func (c *map_string__bool_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[string]bool
+	c.decode(d, &x)
+	return x
+}
+
+func (c *map_string__bool_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[string]bool)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[string]bool
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_string__bool_codec) decode(d *codecapi.Decoder, p *map[string]bool) {
+	n2 := d.StartList()
+	if n2 < 0 {
+		return
+	}
+	n := n2 / 2
+	var m map[string]bool
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[string]bool, n)
+	}
+	var k string
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
+		k = d.DecodeString()
+		d.SetMerging(merging)
+		inKey = false
+		var v bool
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		v = d.DecodeBool()
+		d.SetMerging(merging)
+		m[k] = v
+	}
+	*p = m
+}
+
+func init() {
+	codecapi.Register(map_string__bool_type, func() codecapi.TypeCodec { return &map_string__bool_codec{} })
+}
+
+//// map[string]codec.dfltNew
+
+var map_string__dfltNew_type = reflect.TypeOf((*map[string]dfltNew)(nil)).Elem()
+
+type map_string__dfltNew_codec struct {
+	codecapi.NonStruct
+	dfltNew_codec *dfltNew_codec
+}
+
+func (c *map_string__dfltNew_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{
+
+		dfltNew_type,
+	}
+}
+
+func (c *map_string__dfltNew_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.dfltNew_codec = tcs[0].(*dfltNew_codec)
+}
+
+func (c *map_string__dfltNew_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(map[string]dfltNew))
+}
+
+func (c *map_string__dfltNew_codec) encode(e *codecapi.Encoder, m map[string]dfltNew) {
+	start := e.StatsStart()
+	if m == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			c.dfltNew_codec.encode(e, &v)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(map_string__dfltNew_type, start)
+	}
+}
+
+func (c *map_string__dfltNew_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[string]dfltNew)
+	keys := make([]string, 0, len(m))
+	vals := make([]dfltNew, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				c.dfltNew_codec.encode(e, &v)
+			}
+		})
+	}
+	return 2 * len(m), parts
+}
+
+func (c *map_string__dfltNew_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[string]dfltNew
+	c.decode(d, &x)
+	return x
+}
+
+func (c *map_string__dfltNew_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[string]dfltNew)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[string]dfltNew
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_string__dfltNew_codec) decode(d *codecapi.Decoder, p *map[string]dfltNew) {
+	n2 := d.StartList()
+	if n2 < 0 {
+		return
+	}
+	n := n2 / 2
+	var m map[string]dfltNew
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[string]dfltNew, n)
+	}
+	var k string
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
+		k = d.DecodeString()
+		d.SetMerging(merging)
+		inKey = false
+		var v dfltNew
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		c.dfltNew_codec.decode(d, &v)
+		d.SetMerging(merging)
+		m[k] = v
+	}
+	*p = m
+}
+
+func init() {
+	codecapi.Register(map_string__dfltNew_type, func() codecapi.TypeCodec { return &map_string__dfltNew_codec{} })
 }
 
-//// codec.structType
+//// map[string]codec.dfltOld
 
-var structType_type = reflect.TypeOf((*structType)(nil)).Elem()
+var map_string__dfltOld_type = reflect.TypeOf((*map[string]dfltOld)(nil)).Elem()
 
-type structType_codec struct {
-	embed_codec *embed_codec
-	node_codec  *node_codec
-	fieldMap    []int
+type map_string__dfltOld_codec struct {
+	codecapi.NonStruct
+	dfltOld_codec *dfltOld_codec
 }
 
-func (c *structType_codec) Fields() []string {
-	return []string{"N", "B", "unexported", "embed"}
-}
+func (c *map_string__dfltOld_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{
 
-func (c *structType_codec) SetFieldMap(fm []int) {
-	c.fieldMap = fm
+		dfltOld_type,
+	}
 }
 
-func (c *structType_codec) TypesUsed() []reflect.Type {
-	return []reflect.Type{embed_type, node_type}
+func (c *map_string__dfltOld_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.dfltOld_codec = tcs[0].(*dfltOld_codec)
 }
 
-func (c *structType_codec) SetCodecs(tcs []codecapi.TypeCodec) {
-	c.embed_codec = tcs[0].(*embed_codec)
-	c.node_codec = tcs[1].(*node_codec)
+func (c *map_string__dfltOld_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(map[string]dfltOld))
 }
 
-func (c *structType_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	s := x.(structType)
-	c.encode(e, &s)
+func (c *map_string__dfltOld_codec) encode(e *codecapi.Encoder, m map[string]dfltOld) {
+	start := e.StatsStart()
+	if m == nil {
+		e.EncodeNil()
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			c.dfltOld_codec.encode(e, &v)
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(map_string__dfltOld_type, start)
+	}
 }
 
-func (c *structType_codec) encode(e *codecapi.Encoder, x *structType) {
-	e.StartStruct()
-
-	e.EncodeUint(0)
-	c.node_codec.encode(e, &x.N)
-	if x.B != 0 {
-		e.EncodeUint(1)
-		e.EncodeByte(x.B)
+func (c *map_string__dfltOld_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[string]dfltOld)
+	keys := make([]string, 0, len(m))
+	vals := make([]dfltOld, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		vals = append(vals, v)
 	}
-	if x.unexported != 0 {
-		e.EncodeUint(2)
-		e.EncodeInt(int64(x.unexported))
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				c.dfltOld_codec.encode(e, &v)
+			}
+		})
 	}
-
-	e.EncodeUint(3)
-	c.embed_codec.encode(e, &x.embed)
-	e.EndStruct()
+	return 2 * len(m), parts
 }
 
-func (c *structType_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x structType
+func (c *map_string__dfltOld_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[string]dfltOld
 	c.decode(d, &x)
 	return x
 }
 
-func (c *structType_codec) decode(d *codecapi.Decoder, x *structType) {
-	d.StartStruct()
-loop:
-	for {
-		n := d.NextStructField(c.fieldMap)
-		switch n {
-		case 0:
-			c.node_codec.decode(d, &x.N)
-		case 1:
-			x.B = d.DecodeByte()
-		case 2:
-			x.unexported = int(d.DecodeInt())
-		case 3:
-			c.embed_codec.decode(d, &x.embed)
-		case -1:
-			break loop
-		case -2:
-			d.UnknownField("structType")
-		default:
-			codecapi.Failf("bad struct field value: %d", n)
+func (c *map_string__dfltOld_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[string]dfltOld)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[string]dfltOld
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_string__dfltOld_codec) decode(d *codecapi.Decoder, p *map[string]dfltOld) {
+	n2 := d.StartList()
+	if n2 < 0 {
+		return
+	}
+	n := n2 / 2
+	var m map[string]dfltOld
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[string]dfltOld, n)
+	}
+	var k string
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
+		k = d.DecodeString()
+		d.SetMerging(merging)
+		inKey = false
+		var v dfltOld
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
 		}
+		c.dfltOld_codec.decode(d, &v)
+		d.SetMerging(merging)
+		m[k] = v
 	}
+	*p = m
 }
 
 func init() {
-	codecapi.Register(structType_type, func() codecapi.TypeCodec { return &structType_codec{} })
+	codecapi.Register(map_string__dfltOld_type, func() codecapi.TypeCodec { return &map_string__dfltOld_codec{} })
 }
 
-//// foo.T
+//// map[string]codec.mergeSub
 
-var foo_T_type = reflect.TypeOf((*foo.T)(nil)).Elem()
+var map_string__mergeSub_type = reflect.TypeOf((*map[string]mergeSub)(nil)).Elem()
 
-type foo_T_codec struct {
+type map_string__mergeSub_codec struct {
 	codecapi.NonStruct
+	mergeSub_codec *mergeSub_codec
 }
 
-func (c *foo_T_codec) TypesUsed() []reflect.Type      { return nil }
-func (c *foo_T_codec) SetCodecs([]codecapi.TypeCodec) {}
+func (c *map_string__mergeSub_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{
 
-func (c *foo_T_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(foo.T)) }
+		mergeSub_type,
+	}
+}
 
-func (c *foo_T_codec) encode(e *codecapi.Encoder, s foo.T) {
-	if s == nil {
+func (c *map_string__mergeSub_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+	c.mergeSub_codec = tcs[0].(*mergeSub_codec)
+}
+
+func (c *map_string__mergeSub_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(map[string]mergeSub))
+}
+
+func (c *map_string__mergeSub_codec) encode(e *codecapi.Encoder, m map[string]mergeSub) {
+	start := e.StatsStart()
+	if m == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			c.mergeSub_codec.encode(e, &v)
+		}
 	}
-	e.StartList(len(s))
-	for _, x := range s {
-		e.EncodeInt(int64(x))
+	if start >= 0 {
+		e.StatsEnd(map_string__mergeSub_type, start)
 	}
 }
 
-func (c *foo_T_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x foo.T
+func (c *map_string__mergeSub_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[string]mergeSub)
+	keys := make([]string, 0, len(m))
+	vals := make([]mergeSub, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				c.mergeSub_codec.encode(e, &v)
+			}
+		})
+	}
+	return 2 * len(m), parts
+}
+
+func (c *map_string__mergeSub_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[string]mergeSub
 	c.decode(d, &x)
 	return x
 }
 
-func (c *foo_T_codec) decode(d *codecapi.Decoder, p *foo.T) {
-	n := d.StartList()
-	if n < 0 {
+func (c *map_string__mergeSub_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[string]mergeSub)
+	if d.Merging() {
+		c.decode(d, x)
 		return
 	}
-	s := make([]int, n)
-	for i := 0; i < n; i++ {
-		s[i] = int(d.DecodeInt())
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[string]mergeSub
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_string__mergeSub_codec) decode(d *codecapi.Decoder, p *map[string]mergeSub) {
+	n2 := d.StartList()
+	if n2 < 0 {
+		return
 	}
-	*p = s
+	n := n2 / 2
+	var m map[string]mergeSub
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[string]mergeSub, n)
+	}
+	var k string
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
+		k = d.DecodeString()
+		d.SetMerging(merging)
+		inKey = false
+		var v mergeSub
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		c.mergeSub_codec.decode(d, &v)
+		d.SetMerging(merging)
+		m[k] = v
+	}
+	*p = m
 }
 
 func init() {
-	codecapi.Register(foo_T_type, func() codecapi.TypeCodec { return &foo_T_codec{} })
+	codecapi.Register(map_string__mergeSub_type, func() codecapi.TypeCodec { return &map_string__mergeSub_codec{} })
 }
 
-//// map[[1]int]codec.structType
+//// map[string]int
 
-var map_array_1_int__structType_type = reflect.TypeOf((*map[[1]int]structType)(nil)).Elem()
+var map_string__int_type = reflect.TypeOf((*map[string]int)(nil)).Elem()
 
-type map_array_1_int__structType_codec struct {
+type map_string__int_codec struct {
 	codecapi.NonStruct
-	array_1_int_codec *array_1_int_codec
-	structType_codec  *structType_codec
 }
 
-func (c *map_array_1_int__structType_codec) TypesUsed() []reflect.Type {
-	return []reflect.Type{
-		array_1_int_type,
-		structType_type,
-	}
+func (c *map_string__int_codec) TypesUsed() []reflect.Type {
+	return []reflect.Type{}
 }
 
-func (c *map_array_1_int__structType_codec) SetCodecs(tcs []codecapi.TypeCodec) {
-	c.array_1_int_codec = tcs[0].(*array_1_int_codec)
-	c.structType_codec = tcs[1].(*structType_codec)
+func (c *map_string__int_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 }
 
-func (c *map_array_1_int__structType_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	c.encode(e, x.(map[[1]int]structType))
+func (c *map_string__int_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(map[string]int))
 }
 
-func (c *map_array_1_int__structType_codec) encode(e *codecapi.Encoder, m map[[1]int]structType) {
+func (c *map_string__int_codec) encode(e *codecapi.Encoder, m map[string]int) {
+	start := e.StatsStart()
 	if m == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			e.EncodeInt(int64(v))
+		}
 	}
-	e.StartList(2 * len(m))
+	if start >= 0 {
+		e.StatsEnd(map_string__int_type, start)
+	}
+}
+
+func (c *map_string__int_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[string]int)
+	keys := make([]string, 0, len(m))
+	vals := make([]int, 0, len(m))
 	for k, v := range m {
-		c.array_1_int_codec.encode(e, &k)
-		c.structType_codec.encode(e, &v)
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				e.EncodeInt(int64(v))
+			}
+		})
 	}
+	return 2 * len(m), parts
 }
 
-func (c *map_array_1_int__structType_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x map[[1]int]structType
+func (c *map_string__int_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[string]int
 	c.decode(d, &x)
 	return x
 }
 
-func (c *map_array_1_int__structType_codec) decode(d *codecapi.Decoder, p *map[[1]int]structType) {
+func (c *map_string__int_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[string]int)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[string]int
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_string__int_codec) decode(d *codecapi.Decoder, p *map[string]int) {
+	_, elemConv := d.ElemConversions()
 	n2 := d.StartList()
 	if n2 < 0 {
 		return
 	}
 	n := n2 / 2
-	m := make(map[[1]int]structType, n)
-	var k [1]int
-	var v structType
-	for i := 0; i < n; i++ {
-		c.array_1_int_codec.decode(d, &k)
-		c.structType_codec.decode(d, &v)
+	var m map[string]int
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[string]int, n)
+	}
+	var k string
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
+		k = d.DecodeString()
+		d.SetMerging(merging)
+		inKey = false
+		var v int
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		d.Convert(elemConv)
+		v = int(d.DecodeInt())
+		d.SetMerging(merging)
 		m[k] = v
 	}
 	*p = m
 }
 
 func init() {
-	codecapi.Register(map_array_1_int__structType_type, func() codecapi.TypeCodec { return &map_array_1_int__structType_codec{} })
+	codecapi.Register(map_string__int_type, func() codecapi.TypeCodec { return &map_string__int_codec{} })
 }
 
-//// map[int]int
+//// map[string]int64
 
-var map_int__int_type = reflect.TypeOf((*map[int]int)(nil)).Elem()
+var map_string__int64_type = reflect.TypeOf((*map[string]int64)(nil)).Elem()
 
-type map_int__int_codec struct {
+type map_string__int64_codec struct {
 	codecapi.NonStruct
 }
 
-func (c *map_int__int_codec) TypesUsed() []reflect.Type {
+func (c *map_string__int64_codec) TypesUsed() []reflect.Type {
 	return []reflect.Type{}
 }
 
-func (c *map_int__int_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+func (c *map_string__int64_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 }
 
-func (c *map_int__int_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(map[int]int)) }
+func (c *map_string__int64_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(map[string]int64))
+}
 
-func (c *map_int__int_codec) encode(e *codecapi.Encoder, m map[int]int) {
+func (c *map_string__int64_codec) encode(e *codecapi.Encoder, m map[string]int64) {
+	start := e.StatsStart()
 	if m == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			e.EncodeInt(v)
+		}
 	}
-	e.StartList(2 * len(m))
+	if start >= 0 {
+		e.StatsEnd(map_string__int64_type, start)
+	}
+}
+
+func (c *map_string__int64_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[string]int64)
+	keys := make([]string, 0, len(m))
+	vals := make([]int64, 0, len(m))
 	for k, v := range m {
-		e.EncodeInt(int64(k))
-		e.EncodeInt(int64(v))
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				e.EncodeInt(v)
+			}
+		})
 	}
+	return 2 * len(m), parts
 }
 
-func (c *map_int__int_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x map[int]int
+func (c *map_string__int64_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[string]int64
 	c.decode(d, &x)
 	return x
 }
 
-func (c *map_int__int_codec) decode(d *codecapi.Decoder, p *map[int]int) {
+func (c *map_string__int64_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[string]int64)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[string]int64
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_string__int64_codec) decode(d *codecapi.Decoder, p *map[string]int64) {
+	_, elemConv := d.ElemConversions()
 	n2 := d.StartList()
 	if n2 < 0 {
 		return
 	}
 	n := n2 / 2
-	m := make(map[int]int, n)
-	var k int
-	var v int
-	for i := 0; i < n; i++ {
-		k = int(d.DecodeInt())
-		v = int(d.DecodeInt())
+	var m map[string]int64
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[string]int64, n)
+	}
+	var k string
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
+		k = d.DecodeString()
+		d.SetMerging(merging)
+		inKey = false
+		var v int64
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		d.Convert(elemConv)
+		v = d.DecodeInt()
+		d.SetMerging(merging)
 		m[k] = v
 	}
 	*p = m
 }
 
 func init() {
-	codecapi.Register(map_int__int_type, func() codecapi.TypeCodec { return &map_int__int_codec{} })
+	codecapi.Register(map_string__int64_type, func() codecapi.TypeCodec { return &map_string__int64_codec{} })
 }
 
-//// map[string]bool
+//// map[string]uint16
 
-var map_string__bool_type = reflect.TypeOf((*map[string]bool)(nil)).Elem()
+var map_string__uint16_type = reflect.TypeOf((*map[string]uint16)(nil)).Elem()
 
-type map_string__bool_codec struct {
+type map_string__uint16_codec struct {
 	codecapi.NonStruct
 }
 
-func (c *map_string__bool_codec) TypesUsed() []reflect.Type {
+func (c *map_string__uint16_codec) TypesUsed() []reflect.Type {
 	return []reflect.Type{}
 }
 
-func (c *map_string__bool_codec) SetCodecs(tcs []codecapi.TypeCodec) {
+func (c *map_string__uint16_codec) SetCodecs(tcs []codecapi.TypeCodec) {
 }
 
-func (c *map_string__bool_codec) Encode(e *codecapi.Encoder, x interface{}) {
-	c.encode(e, x.(map[string]bool))
+func (c *map_string__uint16_codec) Encode(e *codecapi.Encoder, x interface{}) {
+	c.encode(e, x.(map[string]uint16))
 }
 
-func (c *map_string__bool_codec) encode(e *codecapi.Encoder, m map[string]bool) {
+func (c *map_string__uint16_codec) encode(e *codecapi.Encoder, m map[string]uint16) {
+	start := e.StatsStart()
 	if m == nil {
 		e.EncodeNil()
-		return
+	} else {
+		e.StartList(2 * len(m))
+		for k, v := range m {
+			e.EncodeString(k)
+			e.EncodeUint(uint64(v))
+		}
+	}
+	if start >= 0 {
+		e.StatsEnd(map_string__uint16_type, start)
 	}
-	e.StartList(2 * len(m))
+}
+
+func (c *map_string__uint16_codec) Split(x interface{}, n int) (int, []func(*codecapi.Encoder)) {
+	m := x.(map[string]uint16)
+	keys := make([]string, 0, len(m))
+	vals := make([]uint16, 0, len(m))
 	for k, v := range m {
-		e.EncodeString(k)
-		e.EncodeBool(v)
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	size := (len(keys) + n - 1) / n
+	var parts []func(*codecapi.Encoder)
+	for i := 0; i < len(keys); i += size {
+		end := i + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ks, vs := keys[i:end], vals[i:end]
+		parts = append(parts, func(e *codecapi.Encoder) {
+			for j, k := range ks {
+				v := vs[j]
+				e.EncodeString(k)
+				e.EncodeUint(uint64(v))
+			}
+		})
 	}
+	return 2 * len(m), parts
 }
 
-func (c *map_string__bool_codec) Decode(d *codecapi.Decoder) interface{} {
-	var x map[string]bool
+func (c *map_string__uint16_codec) Decode(d *codecapi.Decoder) interface{} {
+	var x map[string]uint16
 	c.decode(d, &x)
 	return x
 }
 
-func (c *map_string__bool_codec) decode(d *codecapi.Decoder, p *map[string]bool) {
+func (c *map_string__uint16_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*map[string]uint16)
+	if d.Merging() {
+		c.decode(d, x)
+		return
+	}
+	// Decode into a new value, so that *x is unchanged if decoding fails.
+	var z map[string]uint16
+	c.decode(d, &z)
+	*x = z
+}
+
+func (c *map_string__uint16_codec) decode(d *codecapi.Decoder, p *map[string]uint16) {
+	_, elemConv := d.ElemConversions()
 	n2 := d.StartList()
 	if n2 < 0 {
 		return
 	}
 	n := n2 / 2
-	m := make(map[string]bool, n)
+	var m map[string]uint16
+	if d.Merging() {
+		m = *p
+	}
+	if m == nil {
+		m = make(map[string]uint16, n)
+	}
 	var k string
-	var v bool
-	for i := 0; i < n; i++ {
+	i, inKey := -1, false
+	if d.RecordingPath() {
+		defer func() {
+			if i >= 0 && i < n {
+				if inKey {
+					d.PathMapKey(i)
+				} else {
+					d.PathKey(k)
+				}
+			}
+		}()
+	}
+	// Don't merge into keys or new entries.
+	merging := d.Merging()
+	for i = 0; i < n; i++ {
+		var zk string
+		k, inKey = zk, true
+		d.SetMerging(false)
 		k = d.DecodeString()
-		v = d.DecodeBool()
+		d.SetMerging(merging)
+		inKey = false
+		var v uint16
+		if merging {
+			var ok bool
+			if v, ok = m[k]; !ok {
+				d.SetMerging(false)
+			}
+		}
+		d.Convert(elemConv)
+		v = uint16(d.DecodeUint())
+		d.SetMerging(merging)
 		m[k] = v
 	}
 	*p = m
 }
 
 func init() {
-	codecapi.Register(map_string__bool_type, func() codecapi.TypeCodec { return &map_string__bool_codec{} })
+	codecapi.Register(map_string__uint16_type, func() codecapi.TypeCodec { return &map_string__uint16_codec{} })
 }
 
 //// net.IP
@@ -1444,11 +8279,15 @@ func (c *net_IP_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *net_IP_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(net.IP)) }
 
 func (c *net_IP_codec) encode(e *codecapi.Encoder, m net.IP) {
+	start := e.StatsStart()
 	data, err := m.MarshalText()
 	if err != nil {
 		codecapi.Fail(err)
 	}
 	e.EncodeBytes(data)
+	if start >= 0 {
+		e.StatsEnd(net_IP_type, start)
+	}
 }
 
 func (c *net_IP_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -1457,6 +8296,19 @@ func (c *net_IP_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *net_IP_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*net.IP)
+	// Read the data before clearing *x, so that it is unchanged if that fails.
+	data := d.DecodeBytes()
+	if !d.Merging() {
+		var z net.IP
+		*x = z
+	}
+	if err := x.UnmarshalText(data); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
 func (c *net_IP_codec) decode(d *codecapi.Decoder, p *net.IP) {
 	data := d.DecodeBytes()
 	if err := p.UnmarshalText(data); err != nil {
@@ -1483,11 +8335,15 @@ func (c *time_Time_codec) SetCodecs([]codecapi.TypeCodec) {}
 func (c *time_Time_codec) Encode(e *codecapi.Encoder, x interface{}) { c.encode(e, x.(time.Time)) }
 
 func (c *time_Time_codec) encode(e *codecapi.Encoder, m time.Time) {
+	start := e.StatsStart()
 	data, err := m.MarshalBinary()
 	if err != nil {
 		codecapi.Fail(err)
 	}
 	e.EncodeBytes(data)
+	if start >= 0 {
+		e.StatsEnd(time_Time_type, start)
+	}
 }
 
 func (c *time_Time_codec) Decode(d *codecapi.Decoder) interface{} {
@@ -1496,6 +8352,19 @@ func (c *time_Time_codec) Decode(d *codecapi.Decoder) interface{} {
 	return x
 }
 
+func (c *time_Time_codec) DecodeInto(d *codecapi.Decoder, p interface{}) {
+	x := p.(*time.Time)
+	// Read the data before clearing *x, so that it is unchanged if that fails.
+	data := d.DecodeBytes()
+	if !d.Merging() {
+		var z time.Time
+		*x = z
+	}
+	if err := x.UnmarshalBinary(data); err != nil {
+		codecapi.Fail(err)
+	}
+}
+
 func (c *time_Time_codec) decode(d *codecapi.Decoder, p *time.Time) {
 	data := d.DecodeBytes()
 	if err := p.UnmarshalBinary(data); err != nil {
@@ -1506,3 +8375,9 @@ func (c *time_Time_codec) decode(d *codecapi.Decoder, p *time.Time) {
 func init() {
 	codecapi.Register(time_Time_type, func() codecapi.TypeCodec { return &time_Time_codec{} })
 }
+
+//// previous names
+
+func init() {
+	codecapi.RegisterAlias("example.com/old/path.Moved", moved_type)
+}